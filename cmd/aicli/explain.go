@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/studyzy/aicli/internal/app"
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/i18n"
+	"github.com/studyzy/aicli/pkg/safety"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <command>",
+	Short: "", // 将在 main 中通过 updateCommandDescriptions 设置
+	Long:  "", // 将在 main 中通过 updateCommandDescriptions 设置
+	RunE:  runExplain,
+}
+
+func init() {
+	// 根命令的 --shell 是本地标志，explain 需要单独注册；--timeout 等执行相关的标志不适用于解释
+	explainCmd.Flags().StringVar(&flags.Shell, "shell", flags.Shell, "命令所用的 Shell（bash、zsh、fish、nu 等），覆盖 execution.shell")
+	rootCmd.AddCommand(explainCmd)
+}
+
+// runExplain 解释参数中（或标准输入中）的 shell 命令
+func runExplain(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadConfig), err)
+	}
+	i18n.Init(cfg)
+
+	command := strings.Join(args, " ")
+
+	// 没有参数时从标准输入读取命令，便于解释脚本片段
+	if command == "" {
+		stat, _ := os.Stdin.Stat()
+		if (stat.Mode() & os.ModeCharDevice) == 0 {
+			data, readErr := io.ReadAll(os.Stdin)
+			if readErr != nil {
+				return fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadStdin), readErr)
+			}
+			command = string(data)
+		}
+	}
+	if strings.TrimSpace(command) == "" {
+		return fmt.Errorf("%s", i18n.T(i18n.ErrNoCommandToExplain))
+	}
+
+	provider, err := createLLMProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateProvider), err)
+	}
+
+	exec, err := createExecutor(cfg)
+	if err != nil {
+		return err
+	}
+
+	application := app.NewApp(cfg, provider, exec, safety.NewChecker(cfg.Safety.EnableChecks))
+
+	// 解释请求的 token 用量记录到历史记录，计入 aicli stats
+	hist := history.NewHistory()
+	historyPath := getHistoryPath()
+	if loadErr := hist.Load(historyPath); loadErr != nil && flags.Verbose {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseLoadHistoryFailed, loadErr))
+	}
+	application.SetHistory(hist)
+
+	_, err = application.Explain(command, flags)
+
+	if saveErr := hist.Save(historyPath); saveErr != nil && flags.Verbose {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseSaveHistoryFailed, saveErr))
+	}
+	return err
+}
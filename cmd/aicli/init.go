@@ -17,6 +17,8 @@ const (
 	providerLocal     = "local"
 	providerAnthropic = "anthropic"
 	providerBuiltin   = "builtin"
+	providerGemini    = "gemini"
+	providerAzure     = "azure"
 	apiBaseOpenAI     = "https://api.openai.com/v1"
 )
 
@@ -96,9 +98,11 @@ func configureProvider(reader *bufio.Reader, cfg *config.Config) error {
 	fmt.Println(i18n.T(i18n.InitProviderAnthropic))
 	fmt.Println(i18n.T(i18n.InitProviderLocal))
 	fmt.Println(i18n.T(i18n.InitProviderDeepSeek))
+	fmt.Println(i18n.T(i18n.InitProviderGemini))
+	fmt.Println(i18n.T(i18n.InitProviderAzure))
 	fmt.Println(i18n.T(i18n.InitProviderOther))
 
-	providerChoice := prompt(reader, i18n.T(i18n.PromptInputChoice)+" [1-5]", "1")
+	providerChoice := prompt(reader, i18n.T(i18n.PromptInputChoice)+" [1-7]", "1")
 
 	switch providerChoice {
 	case "1":
@@ -118,6 +122,15 @@ func configureProvider(reader *bufio.Reader, cfg *config.Config) error {
 		cfg.LLM.Model = "deepseek-chat"
 		cfg.LLM.APIBase = "https://api.deepseek.com/v1"
 	case "5":
+		cfg.LLM.Provider = providerGemini
+		cfg.LLM.Model = "gemini-1.5-flash"
+		cfg.LLM.APIBase = "https://generativelanguage.googleapis.com/v1beta"
+	case "6":
+		cfg.LLM.Provider = providerAzure
+		cfg.LLM.Model = "gpt-4o"
+		cfg.LLM.APIBase = "" // 资源地址因人而异，没有可用的默认值
+		cfg.LLM.APIVersion = "2024-06-01"
+	case "7":
 		cfg.LLM.Provider = providerOpenAI
 		cfg.LLM.Model = "gpt-3.5-turbo"
 		cfg.LLM.APIBase = apiBaseOpenAI
@@ -142,9 +155,21 @@ func configureAPI(reader *bufio.Reader, cfg *config.Config) error {
 	modelPrompt := fmt.Sprintf("%s (%s: %s)", i18n.T(i18n.PromptEnterModel), i18n.T(i18n.MsgDefault), cfg.LLM.Model)
 	cfg.LLM.Model = prompt(reader, modelPrompt, cfg.LLM.Model)
 	
-	apiBasePrompt := fmt.Sprintf("%s (%s: %s)", i18n.T(i18n.PromptEnterAPIBase), i18n.T(i18n.MsgDefault), cfg.LLM.APIBase)
+	apiBasePrompt := i18n.T(i18n.PromptEnterAPIBase)
+	if cfg.LLM.APIBase != "" {
+		apiBasePrompt = fmt.Sprintf("%s (%s: %s)", apiBasePrompt, i18n.T(i18n.MsgDefault), cfg.LLM.APIBase)
+	}
 	cfg.LLM.APIBase = prompt(reader, apiBasePrompt, cfg.LLM.APIBase)
 
+	// Azure OpenAI 需要部署名称和 API 版本
+	if cfg.LLM.Provider == providerAzure {
+		deploymentPrompt := fmt.Sprintf("%s (%s: %s)", i18n.T(i18n.PromptEnterDeployment), i18n.T(i18n.MsgDefault), cfg.LLM.Model)
+		cfg.LLM.Deployment = prompt(reader, deploymentPrompt, cfg.LLM.Model)
+
+		apiVersionPrompt := fmt.Sprintf("%s (%s: %s)", i18n.T(i18n.PromptEnterAPIVersion), i18n.T(i18n.MsgDefault), cfg.LLM.APIVersion)
+		cfg.LLM.APIVersion = prompt(reader, apiVersionPrompt, cfg.LLM.APIVersion)
+	}
+
 	return nil
 }
 
@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/studyzy/aicli/internal/app"
 	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/internal/session"
+	"github.com/studyzy/aicli/pkg/collector"
 	"github.com/studyzy/aicli/pkg/config"
 	"github.com/studyzy/aicli/pkg/executor"
 	"github.com/studyzy/aicli/pkg/i18n"
@@ -21,6 +27,9 @@ const version = "1.0.0"
 
 var (
 	flags = app.NewFlags()
+
+	// exitCode 是 aicli 进程的退出码，执行的命令失败时为该命令的退出码
+	exitCode = 0
 )
 
 func main() {
@@ -44,6 +53,38 @@ func main() {
 		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T("label.error"), err)
 		os.Exit(1)
 	}
+	os.Exit(exitCode)
+}
+
+// commandExit 处理执行的命令失败的情况：把命令的退出码作为 aicli 的退出码，便于在脚本中组合使用
+// 命令本身的错误输出已经显示过，因此只有错误带有额外说明（如计划的第几步失败）时才再显示一次
+func commandExit(err error) error {
+	var exitErr *executor.ExitError
+	if !errors.As(err, &exitErr) {
+		return err
+	}
+
+	exitCode = exitErr.Code
+	if err != error(exitErr) {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.LabelError), err)
+	}
+	return nil
+}
+
+// interruptExit 处理 aicli 被中断信号打断的情况：以 128+信号值结束，命令本身的退出码优先
+func interruptExit(ctx context.Context, err error) error {
+	intr, ok := context.Cause(ctx).(*interruptError)
+	if !ok {
+		return err
+	}
+
+	if exitCode == 0 {
+		exitCode = intr.exitCode()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.LabelError), err)
+	}
+	return nil
 }
 
 // rootCmd 是根命令
@@ -90,6 +131,11 @@ func run(cmd *cobra.Command, args []string) error {
 		return retryCommand(flags.Retry)
 	}
 
+	// 加载自定义提示词模板（失败时使用内置提示词）
+	if tmplErr := loadPromptTemplates(cfg); tmplErr != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgPromptTemplateFallback, tmplErr))
+	}
+
 	// 创建 LLM Provider
 	provider, err := createLLMProvider(cfg)
 	if err != nil {
@@ -97,13 +143,17 @@ func run(cmd *cobra.Command, args []string) error {
 	}
 
 	// 创建 Executor
-	exec := executor.NewExecutor()
+	exec, err := createExecutor(cfg)
+	if err != nil {
+		return err
+	}
 
 	// 创建 Safety Checker
 	checker := safety.NewChecker(cfg.Safety.EnableChecks)
 
 	// 创建应用实例
 	application := app.NewApp(cfg, provider, exec, checker)
+	application.SetCollectors(collector.NewRunnerFromConfig(&cfg.Context))
 
 	// 加载历史记录
 	hist := history.NewHistory()
@@ -116,6 +166,17 @@ func run(cmd *cobra.Command, args []string) error {
 	}
 	application.SetHistory(hist)
 
+	// 加载对话会话（用于 --continue 追问）
+	sess := session.NewSession()
+	sessionPath := getSessionPath()
+	if loadErr := sess.Load(sessionPath); loadErr != nil {
+		if flags.Verbose {
+			msg := i18n.T(i18n.VerboseLoadSessionFailed, loadErr)
+			fmt.Fprintf(os.Stderr, "%s\n", msg)
+		}
+	}
+	application.SetSession(sess)
+
 	// 获取自然语言输入
 	input := strings.Join(args, " ")
 
@@ -131,7 +192,10 @@ func run(cmd *cobra.Command, args []string) error {
 		stdin = string(stdinBytes)
 	}
 
-	// 执行应用逻辑
+	// 执行应用逻辑；收到中断信号时取消进行中的 LLM 请求，历史记录仍会保存
+	ctx, stop := signalContext()
+	defer stop()
+	application.SetContext(ctx)
 	_, err = application.Run(input, stdin, flags)
 
 	// 保存历史记录（即使执行失败也保存）
@@ -140,14 +204,20 @@ func run(cmd *cobra.Command, args []string) error {
 		fmt.Fprintf(os.Stderr, "%s\n", msg)
 	}
 
+	// 保存对话会话
+	if saveErr := sess.Save(sessionPath); saveErr != nil && flags.Verbose {
+		msg := i18n.T(i18n.VerboseSaveSessionFailed, saveErr)
+		fmt.Fprintf(os.Stderr, "%s\n", msg)
+	}
+
 	if err != nil {
-		return err
+		err = commandExit(err)
 	}
 
 	// 注意：输出已经在 ExecuteWithOutput 中通过 MultiWriter 实时显示了，
 	// 这里不需要再次打印，否则会导致输出重复
 
-	return nil
+	return interruptExit(ctx, err)
 }
 
 // loadConfig 加载配置文件
@@ -176,10 +246,82 @@ func loadConfig() (*config.Config, error) {
 	return config.Load(configPath)
 }
 
+// loadPromptTemplates 加载配置中的提示词模板并设置为全局模板
+func loadPromptTemplates(cfg *config.Config) error {
+	if cfg.Prompt.System == "" && cfg.Prompt.User == "" {
+		return nil
+	}
+
+	systemPath, err := config.ExpandPath(cfg.Prompt.System)
+	if err != nil {
+		return err
+	}
+	userPath, err := config.ExpandPath(cfg.Prompt.User)
+	if err != nil {
+		return err
+	}
+
+	templates, err := llm.LoadPromptTemplates(systemPath, userPath)
+	if err != nil {
+		return err
+	}
+	llm.SetPromptTemplates(templates)
+	return nil
+}
+
+// createExecutor 创建命令执行器，使用 --shell 或 execution.shell 指定的 Shell（auto 时自动检测）
+func createExecutor(cfg *config.Config) (*executor.Executor, error) {
+	exec := executor.NewExecutor()
+
+	name := cfg.Execution.Shell
+	if flags.Shell != "" {
+		name = flags.Shell
+	}
+	if name == "" || strings.EqualFold(name, executor.ShellAuto) {
+		return exec, nil
+	}
+
+	shell, err := executor.NewShell(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrSelectShell), err)
+	}
+	exec.SetShell(shell)
+	return exec, nil
+}
+
 // createLLMProvider 创建 LLM Provider
 // 使用工厂函数统一管理 Provider 创建
 func createLLMProvider(cfg *config.Config) (llm.Provider, error) {
-	return llm.NewProvider(cfg)
+	provider, err := llm.NewProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置了备用提供商时，切换时提示用户（静默模式除外）
+	if fallback, ok := llm.FindProvider[*llm.FallbackProvider](provider); ok && !flags.Quiet {
+		fallback.SetFallbackHandler(func(from, to string, err error) {
+			fmt.Fprintf(os.Stderr, "\n%s\n", i18n.T(i18n.MsgProviderFallback, from, err, to))
+		})
+	}
+
+	// 启用缓存时包装一层磁盘缓存
+	if cfg.Cache.IsEnabled() {
+		dir := cfg.Cache.Dir
+		if dir == "" {
+			if dir, err = llm.DefaultCacheDir(); err != nil {
+				// 无法确定缓存目录时不使用缓存
+				return provider, nil
+			}
+		}
+
+		cache := llm.NewCache(dir, time.Duration(cfg.Cache.TTL)*time.Second, cfg.Cache.MaxEntries)
+		cached := llm.NewCachedProvider(provider, cache, cfg.LLM.Model)
+		cached.SetIgnoreWorkDir(cfg.Cache.IgnoreWorkDir)
+		cached.SetRefresh(flags.NoCache)
+		provider = cached
+	}
+
+	return provider, nil
 }
 
 func init() {
@@ -194,6 +336,15 @@ func init() {
 	rootCmd.Flags().BoolVar(&flags.NoSendStdin, "no-send-stdin", flags.NoSendStdin, "不将 stdin 数据发送到 LLM")
 	rootCmd.Flags().BoolVar(&flags.History, "history", flags.History, "显示历史记录")
 	rootCmd.Flags().IntVar(&flags.Retry, "retry", flags.Retry, "重新执行历史命令 ID")
+	rootCmd.Flags().IntVar(&flags.Candidates, "candidates", flags.Candidates, "生成多个候选命令并交互选择")
+	rootCmd.Flags().BoolVar(&flags.Continue, "continue", flags.Continue, "在上一条命令的基础上追问修改")
+	rootCmd.Flags().BoolVar(&flags.NoCache, "no-cache", flags.NoCache, "跳过命令缓存，重新请求 LLM")
+	rootCmd.Flags().BoolVar(&flags.ShowPrompt, "show-prompt", flags.ShowPrompt, "打印渲染后的提示词，不调用 LLM")
+	rootCmd.Flags().BoolVar(&flags.NoInstructions, "no-instructions", flags.NoInstructions, "不读取 .aicli.md 项目说明文件")
+	rootCmd.Flags().BoolVar(&flags.Plan, "plan", flags.Plan, "将任务拆分为多个步骤，审阅后依次执行")
+	rootCmd.Flags().IntVar(&flags.Timeout, "timeout", flags.Timeout, "命令执行超时（秒），覆盖 execution.timeout")
+	rootCmd.Flags().StringVar(&flags.Shell, "shell", flags.Shell, "执行命令使用的 Shell（bash、zsh、fish、nu 等），覆盖 execution.shell")
+	rootCmd.Flags().BoolVar(&flags.TTY, "tty", flags.TTY, "在伪终端中执行命令（vim、less 等交互式程序会被自动识别）")
 
 	// 设置版本模板
 	rootCmd.SetVersionTemplate(`{{printf "aicli version %s\n" .Version}}`)
@@ -220,6 +371,11 @@ func getHistoryPath() string {
 	return homeDir + "/.aicli_history.json"
 }
 
+// getSessionPath 获取对话会话文件路径（与历史记录文件放在同一目录）
+func getSessionPath() string {
+	return filepath.Join(filepath.Dir(getHistoryPath()), ".aicli_session.json")
+}
+
 // showHistory 显示历史记录
 func showHistory() error {
 	hist := history.NewHistory()
@@ -238,13 +394,33 @@ func showHistory() error {
 	fmt.Println(i18n.T(i18n.MsgHistoryCount, len(entries)) + "\n")
 	for _, entry := range entries {
 		status := "✓"
-		if !entry.Success {
+		switch {
+		case entry.Status == history.StatusTimeout:
+			status = "⏱"
+		case entry.Status == history.StatusInterrupted:
+			status = "⏹"
+		case entry.Status == history.StatusDryRun:
+			status = "○"
+		case entry.Status == history.StatusCancelled:
+			status = "⊘"
+		case entry.Status == history.StatusExplain:
+			status = "?"
+		case !entry.Success:
 			status = "✗"
 		}
 
 		fmt.Printf("[%d] %s %s\n", entry.ID, status, entry.Timestamp.Format("2006-01-02 15:04:05"))
 		fmt.Printf("    %s: %s\n", i18n.T(i18n.LabelInput), entry.Input)
 		fmt.Printf("    %s: %s\n", i18n.T(i18n.LabelCommand), entry.Command)
+		if entry.Explanation != "" {
+			fmt.Printf("    %s: %s\n", i18n.T(i18n.LabelExplanation), entry.Explanation)
+		}
+		if entry.Provider != "" {
+			fmt.Printf("    %s: %s\n", i18n.T(i18n.LabelProvider), entry.Provider)
+		}
+		if entry.ParentID > 0 {
+			fmt.Printf("    %s: #%d\n", i18n.T(i18n.LabelFixOf), entry.ParentID)
+		}
 
 		if entry.Error != "" {
 			fmt.Printf("    %s: %s\n", i18n.T(i18n.LabelError), entry.Error)
@@ -281,6 +457,11 @@ func retryCommand(id int) error {
 		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadConfig), err)
 	}
 
+	// 加载自定义提示词模板（失败时使用内置提示词）
+	if tmplErr := loadPromptTemplates(cfg); tmplErr != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgPromptTemplateFallback, tmplErr))
+	}
+
 	// 创建 LLM Provider
 	provider, err := createLLMProvider(cfg)
 	if err != nil {
@@ -288,16 +469,23 @@ func retryCommand(id int) error {
 	}
 
 	// 创建 Executor
-	exec := executor.NewExecutor()
+	exec, err := createExecutor(cfg)
+	if err != nil {
+		return err
+	}
 
 	// 创建 Safety Checker
 	checker := safety.NewChecker(cfg.Safety.EnableChecks)
 
 	// 创建应用实例
 	application := app.NewApp(cfg, provider, exec, checker)
+	application.SetCollectors(collector.NewRunnerFromConfig(&cfg.Context))
 	application.SetHistory(hist)
 
 	// 执行命令（使用原始输入重新转换）
+	ctx, stop := signalContext()
+	defer stop()
+	application.SetContext(ctx)
 	_, err = application.Run(entry.Input, "", flags)
 
 	// 保存历史记录
@@ -306,23 +494,26 @@ func retryCommand(id int) error {
 	}
 
 	if err != nil {
-		return err
+		err = commandExit(err)
 	}
 
 	// 注意：输出已经在 ExecuteWithOutput 中通过 MultiWriter 实时显示了
 
-	return nil
+	return interruptExit(ctx, err)
 }
 
 // updateCommandDescriptions 更新命令描述为对应语言
 func updateCommandDescriptions(cmd *cobra.Command) {
 	// 更新根命令描述（包括 Use 字段）
 	// 通过检查命令名称来判断是否为根命令，避免初始化循环
-	if cmd.Name() == "aicli" && cmd.Parent() == nil {
-		cmd.Use = i18n.T(i18n.CobraUse)
+	// 递归处理带子命令的子命令（如 stats、completion）时不能覆盖它们自己的描述
+	if cmd.Parent() == nil {
+		if cmd.Name() == "aicli" {
+			cmd.Use = i18n.T(i18n.CobraUse)
+		}
+		cmd.Short = i18n.T(i18n.CobraShort)
+		cmd.Long = i18n.T(i18n.CobraLong)
 	}
-	cmd.Short = i18n.T(i18n.CobraShort)
-	cmd.Long = i18n.T(i18n.CobraLong)
 	
 	// 更新子命令描述（包括 Cobra 自动生成的命令）
 	for _, subCmd := range cmd.Commands() {
@@ -331,6 +522,18 @@ func updateCommandDescriptions(cmd *cobra.Command) {
 			subCmd.Use = i18n.T(i18n.InitUse)
 			subCmd.Short = i18n.T(i18n.InitShort)
 			subCmd.Long = i18n.T(i18n.InitLong)
+		case "explain":
+			subCmd.Use = i18n.T(i18n.ExplainUse)
+			subCmd.Short = i18n.T(i18n.ExplainShort)
+			subCmd.Long = i18n.T(i18n.ExplainLong)
+			if flag := subCmd.Flags().Lookup("shell"); flag != nil {
+				flag.Usage = i18n.T(i18n.ExplainFlagShell)
+			}
+		case "stats":
+			subCmd.Short = i18n.T(i18n.StatsShort)
+		case "usage":
+			subCmd.Short = i18n.T(i18n.StatsUsageShort)
+			subCmd.Long = i18n.T(i18n.StatsUsageLong)
 		case "completion":
 			subCmd.Short = i18n.T(i18n.CompletionShort)
 		case "help":
@@ -367,6 +570,36 @@ func updateCommandDescriptions(cmd *cobra.Command) {
 	if flag := cmd.Flags().Lookup("retry"); flag != nil {
 		flag.Usage = i18n.T(i18n.CobraFlagRetry)
 	}
+	if flag := cmd.Flags().Lookup("candidates"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagCandidates)
+	}
+	if flag := cmd.Flags().Lookup("continue"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagContinue)
+	}
+	if flag := cmd.Flags().Lookup("no-cache"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagNoCache)
+	}
+	if flag := cmd.Flags().Lookup("show-prompt"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagShowPrompt)
+	}
+	if flag := cmd.Flags().Lookup("no-instructions"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagNoInstructions)
+	}
+	if flag := cmd.Flags().Lookup("plan"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagPlan)
+	}
+	if flag := cmd.Flags().Lookup("timeout"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagTimeout)
+	}
+	if flag := cmd.Flags().Lookup("shell"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagShell)
+	}
+	if flag := cmd.Flags().Lookup("tty"); flag != nil {
+		flag.Usage = i18n.T(i18n.CobraFlagTTY)
+	}
+	if flag := cmd.Flags().Lookup("days"); flag != nil {
+		flag.Usage = i18n.T(i18n.StatsFlagDays)
+	}
 	if flag := cmd.Flags().Lookup("version"); flag != nil {
 		flag.Usage = i18n.T(i18n.VersionShort)
 	}
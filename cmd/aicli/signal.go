@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/studyzy/aicli/pkg/executor"
+)
+
+// interruptError 表示 aicli 收到了中断信号，是根上下文的取消原因
+type interruptError struct {
+	signal os.Signal
+}
+
+func (e *interruptError) Error() string {
+	return "signal: " + e.signal.String()
+}
+
+// exitCode 返回被信号中断时的退出码（128+信号值，与 shell 的约定一致）
+func (e *interruptError) exitCode() int {
+	if sig, ok := e.signal.(syscall.Signal); ok {
+		return 128 + int(sig)
+	}
+	return 1
+}
+
+// signalContext 返回收到中断信号（Ctrl-C、SIGTERM、SIGHUP）时取消的根上下文，
+// context.Cause 返回 *interruptError；进行中的 LLM 请求和等待中的确认随之结束，
+// 正在执行的命令由 executor 转发信号。收到第一个信号后恢复默认处理，再次按 Ctrl-C 直接结束 aicli
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, executor.InterruptSignals...)
+	go func() {
+		select {
+		case sig := <-signals:
+			cancel(&interruptError{signal: sig})
+		case <-ctx.Done():
+		}
+		signal.Stop(signals)
+	}()
+
+	return ctx, func() { cancel(nil) }
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+var statsDays = 30
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "", // 将在 main 中通过 updateCommandDescriptions 设置
+}
+
+var statsUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "", // 将在 main 中通过 updateCommandDescriptions 设置
+	Long:  "", // 将在 main 中通过 updateCommandDescriptions 设置
+	Args:  cobra.NoArgs,
+	RunE:  runStatsUsage,
+}
+
+func init() {
+	statsUsageCmd.Flags().IntVar(&statsDays, "days", statsDays, "统计最近多少天（0 表示全部历史）")
+	statsCmd.AddCommand(statsUsageCmd)
+	rootCmd.AddCommand(statsCmd)
+}
+
+// runStatsUsage 按日期、提供商和模型汇总历史记录中的 token 用量和费用
+func runStatsUsage(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadConfig), err)
+	}
+	i18n.Init(cfg)
+
+	hist := history.NewHistory()
+	if err := hist.Load(getHistoryPath()); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadHistory), err)
+	}
+
+	// 从 statsDays-1 天前的零点开始统计（包含今天）
+	var since time.Time
+	if statsDays > 0 {
+		now := time.Now()
+		since = time.Date(now.Year(), now.Month(), now.Day()-statsDays+1, 0, 0, 0, 0, now.Location())
+	}
+
+	rows := hist.SummarizeUsage(since)
+	if len(rows) == 0 {
+		fmt.Println(i18n.T(i18n.MsgNoUsage))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+		i18n.T(i18n.LabelDate), i18n.T(i18n.LabelProvider), i18n.T(i18n.LabelModel), i18n.T(i18n.LabelRequests),
+		i18n.T(i18n.LabelPromptTokens), i18n.T(i18n.LabelCompletionTokens), i18n.T(i18n.LabelCost))
+
+	var total history.UsageSummary
+	for _, row := range rows {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\n",
+			row.Day, row.Provider, row.Model, row.Requests, row.PromptTokens, row.CompletionTokens, formatCost(row.Cost))
+
+		total.Requests += row.Requests
+		total.PromptTokens += row.PromptTokens
+		total.CompletionTokens += row.CompletionTokens
+		total.Cost += row.Cost
+	}
+
+	fmt.Fprintf(w, "%s\t\t\t%d\t%d\t%d\t%s\n",
+		i18n.T(i18n.LabelTotal), total.Requests, total.PromptTokens, total.CompletionTokens, formatCost(total.Cost))
+
+	return w.Flush()
+}
+
+// formatCost 格式化费用，未配置单价（费用为 0）时显示 "-"
+func formatCost(cost float64) string {
+	if cost == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", cost)
+}
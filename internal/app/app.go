@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/internal/session"
+	"github.com/studyzy/aicli/pkg/collector"
 	"github.com/studyzy/aicli/pkg/config"
 	"github.com/studyzy/aicli/pkg/executor"
 	"github.com/studyzy/aicli/pkg/i18n"
@@ -23,6 +26,13 @@ type App struct {
 	executor *executor.Executor
 	safety   *safety.Checker
 	history  *history.History
+	session  *session.Session
+
+	// collectors 环境信息收集器，为 nil 时不收集
+	collectors *collector.Runner
+
+	// ctx 根上下文，收到中断信号时取消，用于取消进行中的 LLM 请求和等待中的确认
+	ctx context.Context
 }
 
 // NewApp 创建一个新的应用实例
@@ -33,14 +43,31 @@ func NewApp(cfg *config.Config, provider llm.Provider, exec *executor.Executor,
 		executor: exec,
 		safety:   checker,
 		history:  history.NewHistory(),
+		session:  session.NewSession(),
+		ctx:      context.Background(),
 	}
 }
 
+// SetContext 设置根上下文（通常在收到 Ctrl-C 等中断信号时取消）
+func (a *App) SetContext(ctx context.Context) {
+	a.ctx = ctx
+}
+
 // SetHistory 设置历史记录管理器
 func (a *App) SetHistory(h *history.History) {
 	a.history = h
 }
 
+// SetSession 设置对话会话
+func (a *App) SetSession(s *session.Session) {
+	a.session = s
+}
+
+// SetCollectors 设置环境信息收集器
+func (a *App) SetCollectors(r *collector.Runner) {
+	a.collectors = r
+}
+
 // Run 执行应用主逻辑
 // input: 用户的自然语言输入
 // stdin: 标准输入数据（来自管道）
@@ -60,45 +87,84 @@ func (a *App) Run(input string, stdin string, flags *Flags) (string, error) {
 		}
 	}
 
-	// 构建执行上下文
-	execCtx := a.buildExecutionContext(stdin, flags)
+	// 构建执行上下文；先查询缓存，命中时跳过耗时的环境信息收集器
+	execCtx := a.baseExecutionContext(stdin, flags)
+	cached := a.lookupCache(input, execCtx, flags)
+	if cached == nil {
+		a.collectDetails(execCtx, flags)
+	}
 
 	// 详细模式：显示上下文
 	if flags.Verbose {
 		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseContext), llm.BuildContextDescription(execCtx))
+		if len(execCtx.History) > 0 {
+			fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseSessionTurns, len(execCtx.History)/2))
+		}
+	}
+
+	// 只打印提示词，不调用 LLM
+	if flags.ShowPrompt {
+		prompt := formatPrompt(a.promptMessages(input, execCtx, flags))
+		fmt.Print(prompt)
+		return prompt, nil
+	}
+
+	// 多步计划模式
+	if flags.Plan {
+		return a.runPlan(input, execCtx, stdin, flags)
 	}
 
 	// 调用 LLM 转换命令
 	startTime := time.Now()
 
-	ctx := context.Background()
-	if a.config.LLM.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, time.Duration(a.config.LLM.Timeout)*time.Second)
-		defer cancel()
-	}
+	var err error
+	ctx, cancel := a.llmContext()
+	defer cancel()
 
-	command, err := a.llm.Translate(ctx, input, execCtx)
+	var result *llm.TranslationResult
+	if cached != nil {
+		result = cached
+	} else if flags.Candidates > 1 {
+		result, err = a.pickCandidate(ctx, input, execCtx, stdin, flags)
+	} else {
+		result, err = a.translate(ctx, input, execCtx, flags)
+	}
 	if err != nil {
+		// 收到中断信号：记录一条中断的历史后退出
+		if a.ctx.Err() != nil {
+			a.saveHistory(input, &llm.TranslationResult{}, nil, context.Cause(a.ctx), 0)
+			return "", interruptedError(context.Cause(a.ctx))
+		}
 		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrTranslateFailed), err)
 	}
 
 	translateTime := time.Since(startTime)
 
 	// 验证命令不为空
-	if command == "" {
+	if result == nil || result.Command == "" {
 		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyCommand))
 	}
+	command := result.Command
+	if result.Provider == "" {
+		result.Provider = a.llm.Name()
+	}
+
+	// 记录本轮对话，供下一次 --continue 追问
+	a.recordSession(input, result, flags)
 
 	// 详细模式：显示转换结果
 	if flags.Verbose {
-		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseCommand), command)
+		a.printResultDetails(result)
 		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.VerboseTranslateTime), translateTime)
 	}
 
 	// 默认显示翻译后的命令到 stderr（除非开启了 quiet 模式）
 	if !flags.Quiet && !flags.Verbose {
-		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgTranslatedCommand, command))
+		line := i18n.T(i18n.MsgTranslatedCommand, command)
+		if result.Explanation != "" {
+			line += "  # " + result.Explanation
+		}
+		fmt.Fprintf(os.Stderr, "%s\n", line)
 		// 如果使用的是内置试用 API,显示提示信息
 		if a.config.LLM.Provider == "builtin" {
 			fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgTrialAPINotice))
@@ -108,12 +174,14 @@ func (a *App) Run(input string, stdin string, flags *Flags) (string, error) {
 	// 安全检查
 	if a.safety != nil && a.safety.IsEnabled() {
 		if safetyErr := a.handleDangerousCommand(command, stdin, flags); safetyErr != nil {
+			a.saveUnexecuted(input, result, history.StatusCancelled, safetyErr, 0)
 			return "", safetyErr
 		}
 	}
 
 	// Dry-run 模式：只显示命令不执行
 	if flags.DryRun {
+		a.saveUnexecuted(input, result, history.StatusDryRun, nil, 0)
 		return i18n.T(i18n.DryRunWillExecute, command), nil
 	}
 
@@ -123,26 +191,175 @@ func (a *App) Run(input string, stdin string, flags *Flags) (string, error) {
 		fmt.Fprintf(os.Stderr, "%s\n", msg)
 	}
 
-	execStartTime := time.Now()
-	
-	// 使用支持输出捕获的执行方式，同时保持实时显示
-	output, err := a.executor.ExecuteWithOutput(command, stdin)
-	execTime := time.Since(execStartTime)
+	res, err := a.execute(command, stdin, flags)
 
 	// 保存历史记录
-	a.saveHistory(input, command, output, err)
+	entryID := a.saveHistory(input, result, res, err, 0)
 
+	// 命令无法启动
 	if err != nil {
-		return output, fmt.Errorf("%s: %w", i18n.T(i18n.ErrExecuteFailed), err)
+		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrExecuteFailed), err)
 	}
 
 	// 详细模式：显示执行时间
 	if flags.Verbose {
-		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.VerboseExecuteTime), execTime)
-		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.VerboseTotalTime), translateTime+execTime)
+		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.VerboseExecuteTime), res.Duration)
+		fmt.Fprintf(os.Stderr, "%s: %v\n", i18n.T(i18n.VerboseTotalTime), translateTime+res.Duration)
+	}
+
+	// 超时或被中断的命令不请求修正，直接报告
+	if res.TimedOut {
+		return res.Output(), timeoutError(res)
+	}
+	if res.Interrupted {
+		return res.Output(), interruptedError(res.Err())
+	}
+
+	// 命令失败时询问用户是否请 LLM 修正；仍然失败时返回 *executor.ExitError，
+	// 由调用方以命令的退出码结束 aicli 进程
+	if !res.Success() {
+		return a.fixFailedCommand(input, execCtx, command, res, stdin, flags, entryID)
+	}
+
+	return res.Output(), nil
+}
+
+// executionTimeout 返回命令执行超时：--timeout 优先，否则使用 execution.timeout
+func (a *App) executionTimeout(flags *Flags) time.Duration {
+	if flags.Timeout > 0 {
+		return time.Duration(flags.Timeout) * time.Second
+	}
+	return time.Duration(a.config.Execution.Timeout) * time.Second
+}
+
+// useTTY 判断命令是否在伪终端中执行：需要标准输入连接终端且没有管道输入，
+// 指定 --tty 或标准输出为终端且命令是交互式程序时使用
+func useTTY(command, stdin string, flags *Flags) bool {
+	if stdin != "" || !isTerminal(os.Stdin) {
+		return false
+	}
+	return flags.TTY || (isTerminal(os.Stdout) && executor.NeedsTTY(command))
+}
+
+// execute 执行命令，实时显示输出的同时捕获输出用于历史记录
+// 交互式程序在伪终端中执行，此时只有显式指定的 --timeout 生效（编辑器、分页器的运行时间由用户决定）
+func (a *App) execute(command, stdin string, flags *Flags) (*executor.ExecResult, error) {
+	if useTTY(command, stdin, flags) {
+		if flags.Verbose {
+			fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseUsingTTY))
+		}
+		a.executor.SetTimeout(time.Duration(flags.Timeout) * time.Second)
+		return a.executor.ExecuteTTY(command)
+	}
+
+	a.executor.SetTimeout(a.executionTimeout(flags))
+	return a.executor.ExecuteWithOutput(command, stdin)
+}
+
+// timeoutError 返回命令超时的错误，仍可通过 errors.As 取得 *executor.ExitError
+func timeoutError(res *executor.ExecResult) error {
+	return fmt.Errorf("%s: %w", i18n.T(i18n.ErrCommandTimeout), res.Err())
+}
+
+// interruptedError 返回被中断的错误，err 为中断原因（如 *executor.ExitError）
+func interruptedError(err error) error {
+	return fmt.Errorf("%s: %w", i18n.T(i18n.ErrInterrupted), err)
+}
+
+// llmContext 返回调用 LLM 使用的上下文，收到中断信号时随根上下文一起取消
+// 配置了备用提供商时，总超时为所有提供商超时之和
+func (a *App) llmContext() (context.Context, context.CancelFunc) {
+	if timeout := a.config.LLM.TotalTimeout(); timeout > 0 {
+		return context.WithTimeout(a.ctx, time.Duration(timeout)*time.Second)
+	}
+	return context.WithCancel(a.ctx)
+}
+
+// translate 调用 LLM 转换命令
+// 如果 Provider 支持流式输出且 stderr 是终端，则实时显示生成过程，否则回退到 Translate
+func (a *App) translate(ctx context.Context, input string, execCtx *llm.ExecutionContext, flags *Flags) (*llm.TranslationResult, error) {
+	streamer, ok := a.llm.(llm.StreamingProvider)
+	if !ok || flags.Quiet || !isTerminal(os.Stderr) {
+		return a.llm.Translate(ctx, input, execCtx)
+	}
+
+	fmt.Fprint(os.Stderr, i18n.T(i18n.MsgGenerating))
+	result, err := streamer.TranslateStream(ctx, input, execCtx, func(chunk string) {
+		fmt.Fprint(os.Stderr, chunk)
+	})
+	fmt.Fprintln(os.Stderr)
+
+	return result, err
+}
+
+// printResultDetails 在详细模式下显示转换结果的各个字段
+func (a *App) printResultDetails(result *llm.TranslationResult) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseCommand), result.Command)
+	fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseProvider), result.Provider)
+	if result.Cached {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseCacheHit))
+	}
+	if result.Explanation != "" {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseExplanation), result.Explanation)
+	}
+	if result.Risk != "" {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseModelRisk), result.Risk)
+	}
+	if len(result.Tools) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseTools), strings.Join(result.Tools, ", "))
+	}
+	if result.Usage.TotalTokens > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseUsage),
+			i18n.T(i18n.VerboseUsageFormat, result.Usage.TotalTokens, result.Usage.PromptTokens, result.Usage.CompletionTokens))
+		if price, ok := a.config.PriceFor(result.Provider, result.Model); ok {
+			fmt.Fprintf(os.Stderr, "%s: %.6f\n", i18n.T(i18n.LabelCost),
+				price.Cost(result.Usage.PromptTokens, result.Usage.CompletionTokens))
+		}
+	}
+}
+
+// pickCandidate 请求多个候选命令并让用户选择其中一个
+// 每个候选都会单独进行安全检查，检查结果显示在列表中
+func (a *App) pickCandidate(ctx context.Context, input string, execCtx *llm.ExecutionContext, stdin string, flags *Flags) (*llm.TranslationResult, error) {
+	candidates, err := llm.TranslateCandidates(ctx, a.llm, input, execCtx, flags.Candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 1 {
+		return candidates[0].Result(), nil
 	}
 
-	return output, nil
+	items := make([]candidateItem, 0, len(candidates))
+	for _, c := range candidates {
+		item := candidateItem{Command: c.Command, Explanation: c.Explanation}
+		if a.safety != nil && a.safety.IsEnabled() {
+			if isDangerous, description, riskLevel := a.safety.IsDangerous(c.Command); isDangerous {
+				item.Warning = fmt.Sprintf("%s (%s: %s)", description, i18n.T(i18n.WarnRiskLevel), riskLevel.String())
+			}
+		}
+		items = append(items, item)
+	}
+
+	// 管道模式下 stdin 已被占用，无法交互选择，使用第一个候选
+	if a.isPipeMode(stdin) {
+		if !flags.Quiet {
+			renderCandidates(os.Stderr, items, 0, "\n")
+		}
+		return candidates[0].Result(), nil
+	}
+
+	idx, err := selectCandidate(items)
+	if err != nil {
+		// 取消选择时仍记录生成候选命令的用量
+		usage := &llm.TranslationResult{Provider: candidates[0].Provider, Model: candidates[0].Model, Usage: candidates[0].Usage}
+		if usage.Provider == "" {
+			usage.Provider = a.llm.Name()
+		}
+		a.saveUnexecuted(input, usage, history.StatusCancelled, err, 0)
+		return nil, err
+	}
+
+	return candidates[idx].Result(), nil
 }
 
 // handleDangerousCommand 处理危险命令的安全检查和确认
@@ -162,7 +379,7 @@ func (a *App) handleDangerousCommand(command string, stdin string, flags *Flags)
 
 	// 非管道模式：如果没有强制执行，需要用户确认
 	if !flags.Force {
-		if !confirmDangerousCommand(command, description, riskLevel.String()) {
+		if !confirmDangerousCommand(a.ctx, command, description, riskLevel.String()) {
 			return fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
 		}
 	}
@@ -171,36 +388,141 @@ func (a *App) handleDangerousCommand(command string, stdin string, flags *Flags)
 }
 
 // saveHistory 保存命令执行历史记录
-func (a *App) saveHistory(input string, command string, output string, err error) {
+// res 为命令的执行结果，err 为命令无法启动时的错误
+// parentID 为修正尝试所对应的原始记录 ID（0 表示不是修正尝试），返回新记录的 ID
+func (a *App) saveHistory(input string, result *llm.TranslationResult, res *executor.ExecResult, err error, parentID int) int {
 	if a.history == nil {
-		return
+		return 0
 	}
 
-	entry := &history.Entry{
-		Input:     input,
-		Command:   command,
-		Timestamp: time.Now(),
-		Success:   err == nil,
-		ExitCode:  0,
-	}
+	entry := a.newHistoryEntry(input, result, parentID)
+	entry.Success = err == nil && res != nil && res.Success()
 
 	if err != nil {
 		entry.Error = err.Error()
-		entry.ExitCode = 1
-	} else {
+	} else if exitErr := res.Err(); exitErr != nil {
+		entry.Error = exitErr.Error()
+	}
+
+	switch {
+	case entry.Success:
+		entry.Status = history.StatusSuccess
+	case res != nil && res.TimedOut:
+		entry.Status = history.StatusTimeout
+	case res != nil && res.Interrupted, a.ctx.Err() != nil:
+		entry.Status = history.StatusInterrupted
+	default:
+		entry.Status = history.StatusFailed
+	}
+
+	if res != nil {
+		entry.ExitCode = res.ExitCode
+		entry.Signal = res.Signal
+		entry.DurationMs = res.Duration.Milliseconds()
+
 		// 截断输出（避免历史文件过大）
-		if len(output) > 500 {
-			entry.Output = output[:500] + "... (truncated)"
-		} else {
-			entry.Output = output
-		}
+		entry.Output = truncateOutput(res.Stdout)
+		entry.Stderr = truncateOutput(res.Stderr)
+	}
+
+	a.history.Add(entry)
+	return entry.ID
+}
+
+// saveUnexecuted 保存未执行命令的历史记录（dry-run、取消、解释），使调用 LLM 的 token 用量和费用计入统计
+// 收到中断信号时状态记为 StatusInterrupted；返回新记录的 ID
+func (a *App) saveUnexecuted(input string, result *llm.TranslationResult, status string, err error, parentID int) int {
+	if a.history == nil {
+		return 0
+	}
+
+	entry := a.newHistoryEntry(input, result, parentID)
+	entry.Status = status
+	if a.ctx.Err() != nil {
+		entry.Status = history.StatusInterrupted
+	}
+	if err != nil {
+		entry.Error = err.Error()
 	}
 
 	a.history.Add(entry)
+	return entry.ID
 }
 
-// buildExecutionContext 构建执行上下文
-func (a *App) buildExecutionContext(stdin string, flags *Flags) *llm.ExecutionContext {
+// newHistoryEntry 创建包含转换结果、token 用量和按单价表计算的费用的历史记录（命中缓存时用量为 0）
+func (a *App) newHistoryEntry(input string, result *llm.TranslationResult, parentID int) *history.Entry {
+	entry := &history.Entry{
+		Input:            input,
+		Command:          result.Command,
+		Explanation:      result.Explanation,
+		Provider:         result.Provider,
+		Timestamp:        time.Now(),
+		ParentID:         parentID,
+		Model:            result.Model,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+	}
+	if price, ok := a.config.PriceFor(result.Provider, result.Model); ok {
+		entry.Cost = price.Cost(result.Usage.PromptTokens, result.Usage.CompletionTokens)
+	}
+	return entry
+}
+
+// truncateOutput 截断写入历史记录的命令输出
+func truncateOutput(output string) string {
+	if len(output) > 500 {
+		return output[:500] + "... (truncated)"
+	}
+	return output
+}
+
+// recordSession 记录本轮对话
+// 非追问模式下开始新的会话，只保留本轮
+func (a *App) recordSession(input string, result *llm.TranslationResult, flags *Flags) {
+	if a.session == nil {
+		return
+	}
+
+	if !flags.Continue {
+		a.session.Reset()
+	}
+
+	a.session.Add(&session.Turn{
+		Input:       input,
+		Command:     result.Command,
+		Explanation: result.Explanation,
+		Timestamp:   time.Now(),
+	})
+}
+
+// lookupCache 在单条命令转换前查询缓存，不适用或未命中时返回 nil
+// 计划和候选模式不使用缓存，打印提示词时需要完整的上下文
+// 查询前把项目摘要（标记文件和 git 分支）写入执行上下文，未命中时保存结果使用同一个缓存键
+func (a *App) lookupCache(input string, execCtx *llm.ExecutionContext, flags *Flags) *llm.TranslationResult {
+	if flags.ShowPrompt || flags.Plan || flags.Candidates > 1 {
+		return nil
+	}
+
+	cached, ok := llm.FindProvider[*llm.CachedProvider](a.llm)
+	if !ok {
+		return nil
+	}
+	if a.collectors != nil {
+		execCtx.Fingerprint = a.collectors.Fingerprint(a.ctx, &collector.Env{
+			OS:      execCtx.OS,
+			Shell:   execCtx.Shell,
+			WorkDir: execCtx.WorkDir,
+		})
+	}
+	result, ok := cached.Lookup(input, execCtx)
+	if !ok {
+		return nil
+	}
+	return result
+}
+
+// baseExecutionContext 构建不含环境信息收集结果的执行上下文
+func (a *App) baseExecutionContext(stdin string, flags *Flags) *llm.ExecutionContext {
 	// 获取当前工作目录
 	workDir, _ := os.Getwd()
 
@@ -213,21 +535,111 @@ func (a *App) buildExecutionContext(stdin string, flags *Flags) *llm.ExecutionCo
 		WorkDir: workDir,
 	}
 
-	// 添加 stdin（除非禁用）
+	// 添加 stdin（除非禁用）：超出预算时只发送结构摘要，二进制数据不发送
 	if !flags.NoSendStdin && stdin != "" {
-		ctx.Stdin = stdin
+		summary := llm.SummarizeStdin(stdin, a.config.Context.StdinBytes)
+		if summary.Format != llm.StdinBinary {
+			ctx.Stdin = stdin
+		}
+		ctx.StdinSummary = summary.Text
+
+		if flags.Verbose {
+			fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseStdinFormat, summary.Format, summary.Lines, len(summary.Text)))
+		}
+	}
+
+	// 追问模式：附带之前的对话历史
+	if flags.Continue && a.session != nil {
+		ctx.History = a.session.Messages()
+	}
+
+	// 读取工作目录及上级目录中的项目说明（.aicli.md）
+	if !flags.NoInstructions {
+		files := findInstructions(workDir)
+		ctx.Instructions = joinInstructions(files, maxInstructionsBytes)
+
+		if flags.Verbose {
+			for _, f := range files {
+				fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.VerboseInstructions, f.Path, len(f.Content)))
+			}
+			if ctx.Instructions != "" {
+				fmt.Fprintf(os.Stderr, "%s\n", ctx.Instructions)
+			}
+		}
 	}
 
 	return ctx
 }
 
+// collectDetails 运行环境信息收集器，把可用工具、发行版等信息写入执行上下文
+func (a *App) collectDetails(ctx *llm.ExecutionContext, flags *Flags) {
+	if a.collectors == nil {
+		return
+	}
+
+	sections := a.collectors.Run(a.ctx, &collector.Env{
+		OS:      ctx.OS,
+		Shell:   ctx.Shell,
+		WorkDir: ctx.WorkDir,
+	})
+	ctx.Details = collector.Format(sections, a.collectors.Budget())
+
+	if flags.Verbose {
+		printCollected(sections)
+	}
+}
+
+// printCollected 在详细模式下显示各收集器的结果
+func printCollected(sections []collector.Section) {
+	fmt.Fprintf(os.Stderr, "%s:\n", i18n.T(i18n.VerboseCollected))
+	for _, s := range sections {
+		if s.Err != nil {
+			fmt.Fprintf(os.Stderr, "  %s\n", i18n.T(i18n.VerboseCollectorFailed, s.Name, s.Err))
+			continue
+		}
+		if s.Content == "" {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  [%s %v] %s: %s\n", s.Name, s.Duration.Round(time.Millisecond), s.Title, s.Content)
+	}
+}
+
 // isPipeMode 检测是否处于管道模式
 // 管道模式下（有 stdin 输入）不应该进行交互式确认
 func (a *App) isPipeMode(stdin string) bool {
 	return stdin != ""
 }
 
+// promptMessages 返回本次请求将发送给 LLM 的对话消息
+func (a *App) promptMessages(input string, execCtx *llm.ExecutionContext, flags *Flags) []llm.Message {
+	if flags.Plan {
+		return llm.BuildPlanMessages(input, execCtx)
+	}
+	if flags.Candidates > 1 {
+		return llm.BuildCandidatesMessages(input, execCtx, flags.Candidates)
+	}
+	return llm.BuildMessages(input, execCtx)
+}
+
+// formatPrompt 将对话消息格式化为便于阅读的文本
+func formatPrompt(messages []llm.Message) string {
+	var sb strings.Builder
+	for i, msg := range messages {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("[" + msg.Role + "]\n")
+		sb.WriteString(strings.TrimRight(msg.Content, "\n") + "\n")
+	}
+	return sb.String()
+}
+
 // GetHistory 获取历史记录管理器
 func (a *App) GetHistory() *history.History {
 	return a.history
 }
+
+// GetSession 获取对话会话
+func (a *App) GetSession() *session.Session {
+	return a.session
+}
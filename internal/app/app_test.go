@@ -2,9 +2,16 @@ package app
 
 import (
 	"context"
+	"errors"
+	"os"
+	"runtime"
+	"sort"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/collector"
 	"github.com/studyzy/aicli/pkg/config"
 	"github.com/studyzy/aicli/pkg/executor"
 	"github.com/studyzy/aicli/pkg/i18n"
@@ -288,3 +295,565 @@ func TestApp_NoSendStdin(t *testing.T) {
 		t.Error("LLM Provider was not called")
 	}
 }
+
+// TestApp_BinaryStdinNotSent 测试二进制标准输入不会发送给模型，大输入只发送摘要
+func TestApp_BinaryStdinNotSent(t *testing.T) {
+	var got *llm.ExecutionContext
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (string, error) {
+			got = execCtx
+			return "echo done", nil
+		},
+	}
+
+	cfg := config.Default()
+	cfg.Context.StdinBytes = 64
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+
+	if _, err := application.Run("解压", "PK\x03\x04\x00\x00secret", NewFlags()); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if got.Stdin != "" || strings.Contains(got.StdinSummary, "secret") {
+		t.Errorf("binary stdin should not be sent: %+v", got)
+	}
+
+	large := strings.Repeat("line of plain text\n", 100)
+	if _, err := application.Run("统计行数", large, NewFlags()); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if got.Stdin != large || len(got.StdinSummary) > 64 {
+		t.Errorf("large stdin should be summarized within budget, got %d bytes", len(got.StdinSummary))
+	}
+}
+
+// TestApp_CandidatesPipeMode 测试管道模式下自动使用第一个候选命令
+func TestApp_CandidatesPipeMode(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			return &llm.Completion{Content: `[{"command":"echo first","explanation":"1"},{"command":"echo second","explanation":"2"}]`}, nil
+		},
+	}
+
+	cfg := config.Default()
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+
+	flags := NewFlags()
+	flags.Candidates = 2
+	flags.DryRun = true
+
+	output, err := application.Run("打印", "data", flags)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if !strings.Contains(output, "echo first") {
+		t.Errorf("output = %s, should contain 'echo first'", output)
+	}
+}
+
+// TestApp_ContinueSendsHistory 测试 --continue 追问时发送上一轮对话
+func TestApp_ContinueSendsHistory(t *testing.T) {
+	var history []llm.Message
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			history = execCtx.History
+			if input == "only .log files" {
+				return &llm.TranslationResult{Command: "ls -S *.log"}, nil
+			}
+			return &llm.TranslationResult{Command: "ls -S"}, nil
+		},
+	}
+
+	cfg := config.Default()
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+
+	flags := NewFlags()
+	flags.DryRun = true
+	flags.Quiet = true
+
+	if _, err := application.Run("list big files here", "", flags); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("first run history = %v, want empty", history)
+	}
+
+	flags.Continue = true
+	if _, err := application.Run("only .log files", "", flags); err != nil {
+		t.Fatalf("Run() with continue failed: %v", err)
+	}
+	if len(history) != 2 || history[0].Content != "list big files here" || !strings.Contains(history[1].Content, "ls -S") {
+		t.Errorf("continue history = %v, want previous turn", history)
+	}
+	if application.GetSession().Len() != 2 {
+		t.Errorf("session turns = %d, want 2", application.GetSession().Len())
+	}
+
+	// 不带 --continue 时开始新会话
+	flags.Continue = false
+	if _, err := application.Run("show disk usage", "", flags); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if application.GetSession().Len() != 1 {
+		t.Errorf("session turns = %d, want 1", application.GetSession().Len())
+	}
+}
+
+// TestApp_ShowPrompt 测试 --show-prompt 只打印提示词而不调用 LLM
+func TestApp_ShowPrompt(t *testing.T) {
+	called := false
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			called = true
+			return &llm.TranslationResult{Command: "ls"}, nil
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+
+	flags := NewFlags()
+	flags.ShowPrompt = true
+
+	output, err := application.Run("list files", "a.txt\nb.txt", flags)
+	if err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if called {
+		t.Error("--show-prompt should not call the provider")
+	}
+	if !strings.Contains(output, "[system]") || !strings.Contains(output, "[user]") {
+		t.Errorf("output = %q, want system and user sections", output)
+	}
+	if !strings.Contains(output, "list files") || !strings.Contains(output, "b.txt") {
+		t.Errorf("output = %q, want input and stdin", output)
+	}
+}
+
+// staticCollector 返回固定内容的环境信息收集器
+type staticCollector struct{}
+
+func (staticCollector) Name() string { return "static" }
+
+func (staticCollector) Collect(ctx context.Context, env *collector.Env) (string, string, error) {
+	return "Tools", "jq 1.7", nil
+}
+
+// TestApp_CollectorsEnrichContext 测试收集器的输出写入执行上下文
+func TestApp_CollectorsEnrichContext(t *testing.T) {
+	var details string
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			details = execCtx.Details
+			return &llm.TranslationResult{Command: "jq . data.json"}, nil
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+	application.SetCollectors(collector.NewRunner(0, staticCollector{}))
+
+	flags := NewFlags()
+	flags.DryRun = true
+	flags.Quiet = true
+
+	if _, err := application.Run("pretty print data.json", "", flags); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if details != "- Tools: jq 1.7\n" {
+		t.Errorf("Details = %q, want collected tools", details)
+	}
+}
+
+// countingCollector 记录运行次数的环境信息收集器，Fingerprint 返回 project 指向的内容
+type countingCollector struct {
+	runs    *int
+	project *string
+}
+
+func (c countingCollector) Name() string { return "counting" }
+
+func (c countingCollector) Collect(ctx context.Context, env *collector.Env) (string, string, error) {
+	*c.runs++
+	return "Tools", "jq 1.7", nil
+}
+
+func (c countingCollector) Fingerprint(ctx context.Context, env *collector.Env) string {
+	if c.project == nil {
+		return ""
+	}
+	return *c.project
+}
+
+// TestApp_CacheHitSkipsCollectors 测试命中缓存时不运行环境信息收集器，项目变化时不命中旧的缓存
+func TestApp_CacheHitSkipsCollectors(t *testing.T) {
+	calls := 0
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			calls++
+			return &llm.TranslationResult{Command: "jq . data.json"}, nil
+		},
+	}
+	cache := llm.NewCache(t.TempDir(), time.Hour, 10)
+	provider := llm.NewCachedProvider(mockProvider, cache, "test-model")
+
+	runs := 0
+	project := "Go module example.com/shop\ngit branch main"
+	application := NewApp(config.Default(), provider, executor.NewExecutor(), safety.NewChecker(true))
+	application.SetCollectors(collector.NewRunner(0, countingCollector{runs: &runs, project: &project}))
+
+	flags := NewFlags()
+	flags.DryRun = true
+	flags.Quiet = true
+
+	run := func() {
+		t.Helper()
+		output, err := application.Run("pretty print data.json", "", flags)
+		if err != nil {
+			t.Fatalf("Run() failed: %v", err)
+		}
+		if !strings.Contains(output, "jq . data.json") {
+			t.Errorf("output = %q, want cached command", output)
+		}
+	}
+
+	run()
+	run()
+	if calls != 1 || runs != 1 {
+		t.Errorf("LLM calls = %d, collector runs = %d, want 1 and 1", calls, runs)
+	}
+
+	// 切换分支后不应复用之前的结果
+	project = "Go module example.com/shop\ngit branch feature"
+	run()
+	if calls != 2 || runs != 2 {
+		t.Errorf("after switching branch: LLM calls = %d, collector runs = %d, want 2 and 2", calls, runs)
+	}
+}
+
+// TestApp_Explain 测试命令解释及安全检查结论
+func TestApp_Explain(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			return &llm.Completion{
+				Content: `{"summary":"递归删除目录","parts":[{"token":"rm","meaning":"删除文件"},{"token":"-rf","meaning":"递归且强制"}]}`,
+			}, nil
+		},
+	}
+
+	cfg := config.Default()
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+	runs := 0
+	application.SetCollectors(collector.NewRunner(0, countingCollector{runs: &runs}))
+
+	output, err := application.Explain("rm -rf /tmp/test", NewFlags())
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if runs != 0 {
+		t.Errorf("解释命令不应运行环境信息收集器，实际运行 %d 次", runs)
+	}
+
+	for _, want := range []string{"$ rm -rf /tmp/test", "递归删除目录", "  rm   删除文件", "  -rf  递归且强制", "安全检查"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("输出缺少 %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, i18n.T(i18n.MsgExplainSafe)) {
+		t.Errorf("危险命令不应显示为安全:\n%s", output)
+	}
+
+	if _, err := application.Explain("  ", NewFlags()); err == nil {
+		t.Error("空命令应返回错误")
+	}
+}
+
+// TestApp_FixFailedCommand 测试命令失败后请求 LLM 修正并关联历史记录
+func TestApp_FixFailedCommand(t *testing.T) {
+	var fixMessages []llm.Message
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFn: func(input string) string {
+			return "exit 3"
+		},
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			fixMessages = messages
+			return &llm.Completion{Content: `{"command":"echo fixed","explanation":"修正"}`}, nil
+		},
+	}
+
+	asked := 0
+	original := confirmFix
+	confirmFix = func(ctx context.Context, exitCode int) bool {
+		asked++
+		if exitCode != 3 {
+			t.Errorf("期望退出码为 3, 实际为 %d", exitCode)
+		}
+		return true
+	}
+	defer func() { confirmFix = original }()
+
+	cfg := config.Default()
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	output, err := application.Run("测试修正", "", NewFlags())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !strings.Contains(output, "fixed") {
+		t.Errorf("期望输出为修正后命令的输出, 实际为 %q", output)
+	}
+	if asked != 1 {
+		t.Errorf("期望询问 1 次, 实际为 %d", asked)
+	}
+	if len(fixMessages) == 0 || fixMessages[len(fixMessages)-2].Content != "exit 3" {
+		t.Errorf("修正请求应包含失败的命令: %+v", fixMessages)
+	}
+
+	entries := hist.List()
+	if len(entries) != 2 {
+		t.Fatalf("期望 2 条历史记录, 实际为 %d", len(entries))
+	}
+	fixed, first := entries[0], entries[1]
+	if first.Success || first.ExitCode != 3 {
+		t.Errorf("原始记录应为失败且退出码为 3: %+v", first)
+	}
+	if !fixed.Success || fixed.ParentID != first.ID || fixed.Command != "echo fixed" {
+		t.Errorf("修正记录应关联原始记录: %+v", fixed)
+	}
+}
+
+// TestApp_FixDeclined 测试用户拒绝修正时不再请求 LLM
+func TestApp_FixDeclined(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFn: func(input string) string {
+			return "exit 1"
+		},
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			t.Error("用户拒绝后不应请求修正")
+			return nil, nil
+		},
+	}
+
+	original := confirmFix
+	confirmFix = func(ctx context.Context, exitCode int) bool { return false }
+	defer func() { confirmFix = original }()
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+
+	// 命令仍然失败时返回 *executor.ExitError，由 main 以命令的退出码结束进程
+	_, err := application.Run("测试", "", NewFlags())
+	var exitErr *executor.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 1 {
+		t.Errorf("期望返回退出码为 1 的 *executor.ExitError, 实际为: %v", err)
+	}
+}
+
+// TestApp_HistoryRecordsUsage 测试历史记录保存 token 用量和费用
+func TestApp_HistoryRecordsUsage(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			return &llm.TranslationResult{
+				Command: "echo usage",
+				Model:   "gpt-4o-mini",
+				Usage:   llm.Usage{PromptTokens: 1000, CompletionTokens: 100, TotalTokens: 1100},
+			}, nil
+		},
+	}
+
+	cfg := config.Default()
+	cfg.Pricing = map[string]config.ModelPrice{"gpt-4o-mini": {Input: 0.15, Output: 0.6}}
+	application := NewApp(cfg, mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	if _, err := application.Run("测试用量", "", NewFlags()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	entries := hist.List()
+	if len(entries) != 1 {
+		t.Fatalf("期望 1 条历史记录, 实际为 %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Provider != "mock" || entry.Model != "gpt-4o-mini" || entry.PromptTokens != 1000 || entry.CompletionTokens != 100 {
+		t.Errorf("历史记录的用量不正确: %+v", entry)
+	}
+	if want := 0.00021; entry.Cost < want-1e-9 || entry.Cost > want+1e-9 {
+		t.Errorf("Cost = %v, 期望 %v", entry.Cost, want)
+	}
+}
+
+// TestApp_HistoryRecordsUnexecutedUsage 测试 dry-run、安全检查拒绝和解释命令也记录 token 用量
+func TestApp_HistoryRecordsUnexecutedUsage(t *testing.T) {
+	usage := llm.Usage{PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110}
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			return &llm.TranslationResult{Command: input, Usage: usage}, nil
+		},
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			return &llm.Completion{Content: `{"summary":"递归删除目录"}`, Usage: usage}, nil
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	flags := NewFlags()
+	flags.DryRun = true
+	if _, err := application.Run("echo dry-run", "", flags); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// 管道模式下危险命令被拒绝
+	if _, err := application.Run("rm -rf /tmp/test", "data", NewFlags()); err == nil {
+		t.Fatal("管道模式下危险命令应被拒绝")
+	}
+
+	if _, err := application.Explain("rm -rf /tmp/test", NewFlags()); err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+
+	statuses := make([]string, 0, 3)
+	for _, entry := range hist.List() {
+		if entry.PromptTokens != 100 || entry.CompletionTokens != 10 || entry.Success {
+			t.Errorf("历史记录的用量不正确: %+v", entry)
+		}
+		statuses = append(statuses, entry.Status)
+	}
+	sort.Strings(statuses)
+	want := []string{history.StatusCancelled, history.StatusDryRun, history.StatusExplain}
+	if strings.Join(statuses, ",") != strings.Join(want, ",") {
+		t.Errorf("历史记录状态 = %v, 期望 %v", statuses, want)
+	}
+}
+
+// TestApp_HistoryRecordsExecResult 测试历史记录保存真实的退出码和分离的 stdout/stderr
+func TestApp_HistoryRecordsExecResult(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("跳过 Windows 测试，因为命令语法不同")
+	}
+
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFn: func(input string) string {
+			return "echo out; echo err >&2; exit 2"
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	// 管道模式下不询问修正，直接返回命令的退出码
+	_, err := application.Run("测试", "input", NewFlags())
+	var exitErr *executor.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 2 {
+		t.Fatalf("期望返回退出码为 2 的 *executor.ExitError, 实际为: %v", err)
+	}
+
+	entries := hist.List()
+	if len(entries) != 1 {
+		t.Fatalf("期望 1 条历史记录, 实际为 %d", len(entries))
+	}
+	entry := entries[0]
+	if entry.Success || entry.Status != history.StatusFailed || entry.ExitCode != 2 || entry.Error != "exit status 2" {
+		t.Errorf("历史记录的退出状态不正确: %+v", entry)
+	}
+	if entry.Output != "out\n" || !strings.Contains(entry.Stderr, "err\n") {
+		t.Errorf("历史记录的输出不正确: output=%q stderr=%q", entry.Output, entry.Stderr)
+	}
+}
+
+// TestApp_CommandTimeout 测试 --timeout 覆盖配置，超时作为独立的错误和历史状态报告
+func TestApp_CommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("跳过 Windows 测试，因为命令语法不同")
+	}
+
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFn: func(input string) string {
+			return "sleep 30"
+		},
+	}
+
+	exec := executor.NewExecutor()
+	exec.SetKillGrace(100 * time.Millisecond)
+	application := NewApp(config.Default(), mockProvider, exec, safety.NewChecker(false))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	flags := NewFlags()
+	flags.Timeout = 1
+
+	start := time.Now()
+	_, err := application.Run("测试", "input", flags)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("命令应在超时后终止, 实际耗时 %v", elapsed)
+	}
+	if !errors.Is(err, executor.ErrTimeout) {
+		t.Fatalf("期望超时错误, 实际为: %v", err)
+	}
+	var exitErr *executor.ExitError
+	if !errors.As(err, &exitErr) || exitErr.Code != 124 {
+		t.Errorf("超时的退出码应为 124, 实际为: %v", err)
+	}
+
+	entries := hist.List()
+	if len(entries) != 1 || entries[0].Status != history.StatusTimeout || entries[0].Success {
+		t.Errorf("历史记录应标记为超时: %+v", entries)
+	}
+}
+
+// TestApp_InterruptedTranslation 测试收到中断信号时取消 LLM 请求并记录中断的历史
+func TestApp_InterruptedTranslation(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		TranslateFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (string, error) {
+			<-ctx.Done()
+			return "", ctx.Err()
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	stop := errors.New("signal: interrupt")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	application.SetContext(ctx)
+	time.AfterFunc(50*time.Millisecond, func() { cancel(stop) })
+
+	_, err := application.Run("测试", "input", NewFlags())
+	if !errors.Is(err, stop) {
+		t.Fatalf("期望返回中断原因, 实际为: %v", err)
+	}
+
+	entries := hist.List()
+	if len(entries) != 1 || entries[0].Status != history.StatusInterrupted || entries[0].Input != "测试" {
+		t.Errorf("历史记录应标记为中断: %+v", entries)
+	}
+}
+
+// TestApp_ExecutionContextShell 测试发送给 LLM 的 Shell 与实际执行命令的 Shell 一致
+func TestApp_ExecutionContextShell(t *testing.T) {
+	exec := executor.NewExecutor()
+	exec.SetShell(&executor.ShellAdapter{Type: executor.ShellFish, Path: "/usr/bin/fish", Args: []string{"-c"}})
+
+	application := NewApp(config.Default(), &llm.MockLLMProvider{}, exec, safety.NewChecker(false))
+	if ctx := application.baseExecutionContext("", NewFlags()); ctx.Shell != "fish" {
+		t.Errorf("ExecutionContext.Shell = %q, 期望 fish", ctx.Shell)
+	}
+}
+
+// TestUseTTY 测试只有标准输入连接终端且没有管道输入时才使用伪终端
+func TestUseTTY(t *testing.T) {
+	flags := NewFlags()
+	flags.TTY = true
+
+	// 有管道输入时命令需要读取管道数据
+	if useTTY("less", "data", flags) {
+		t.Error("有管道输入时不应使用伪终端")
+	}
+	// 测试进程的标准输入不是终端
+	if !isTerminal(os.Stdin) && useTTY("vim main.go", "", flags) {
+		t.Error("标准输入不是终端时不应使用伪终端")
+	}
+}
@@ -3,6 +3,7 @@ package app
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -18,8 +19,8 @@ const (
 // command: 要执行的命令
 // description: 危险描述
 // riskLevel: 风险等级
-// 返回: true 表示用户确认，false 表示用户拒绝
-func confirmDangerousCommand(command string, description string, riskLevel string) bool {
+// 返回: true 表示用户确认，false 表示用户拒绝或 ctx 被取消
+func confirmDangerousCommand(ctx context.Context, command string, description string, riskLevel string) bool {
 	// 显示警告信息
 	fmt.Fprintf(os.Stderr, "\n⚠️  %s\n", i18n.T(i18n.WarnDangerousCommand))
 	fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.LabelCommand), command)
@@ -30,8 +31,7 @@ func confirmDangerousCommand(command string, description string, riskLevel strin
 	fmt.Fprintf(os.Stderr, "%s", msg)
 
 	// 读取用户输入
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	response, err := readLine(ctx, bufio.NewReader(os.Stdin))
 	if err != nil {
 		return false
 	}
@@ -40,3 +40,21 @@ func confirmDangerousCommand(command string, description string, riskLevel strin
 	response = strings.ToLower(strings.TrimSpace(response))
 	return response == "y" || response == responseYes
 }
+
+// confirmFix 询问用户是否将失败的命令发送给 LLM 修正
+// stdin 不是终端时无法交互，直接返回 false；ctx 被取消（收到中断信号）时也返回 false
+var confirmFix = func(ctx context.Context, exitCode int) bool {
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+
+	fmt.Fprintf(os.Stderr, "\n%s", i18n.T(i18n.PromptFixCommand, exitCode))
+
+	response, err := readLine(ctx, bufio.NewReader(os.Stdin))
+	if err != nil {
+		return false
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == responseYes
+}
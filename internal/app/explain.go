@@ -0,0 +1,95 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/i18n"
+	"github.com/studyzy/aicli/pkg/llm"
+)
+
+// Explain 请求 LLM 逐个参数解释一条 shell 命令，并附上本地安全检查的结论
+// 解释输出到 stdout，返回值与输出内容相同
+func (a *App) Explain(command string, flags *Flags) (string, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("%s", i18n.T(i18n.ErrNoCommandToExplain))
+	}
+
+	if flags.Verbose {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseCommand), command)
+	}
+
+	// 解释只需要操作系统和 Shell，不读取项目说明，也不运行环境信息收集器
+	execCtx := &llm.ExecutionContext{
+		OS:    runtime.GOOS,
+		Shell: a.executor.GetShell().GetShellType(),
+	}
+
+	ctx, cancel := a.llmContext()
+	defer cancel()
+
+	explanation, err := llm.Explain(ctx, a.llm, command, execCtx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrTranslateFailed), err)
+	}
+
+	// 记录解释请求的 token 用量（命令不执行）
+	result := &llm.TranslationResult{
+		Command:     command,
+		Explanation: explanation.Summary,
+		Provider:    explanation.Provider,
+		Model:       explanation.Model,
+		Usage:       explanation.Usage,
+	}
+	if result.Provider == "" {
+		result.Provider = a.llm.Name()
+	}
+	a.saveUnexecuted(command, result, history.StatusExplain, nil, 0)
+
+	output := formatExplanation(command, explanation) + a.safetyVerdict(command) + "\n"
+	fmt.Print(output)
+	return output, nil
+}
+
+// formatExplanation 将解释格式化为 "命令 / 概述 / 逐项说明" 的文本
+func formatExplanation(command string, e *llm.Explanation) string {
+	var sb strings.Builder
+	sb.WriteString("$ " + command + "\n")
+	if e.Summary != "" {
+		sb.WriteString("\n" + e.Summary + "\n")
+	}
+
+	if len(e.Parts) > 0 {
+		width := 0
+		for _, p := range e.Parts {
+			if w := len([]rune(p.Token)); w > width {
+				width = w
+			}
+		}
+
+		sb.WriteString("\n")
+		for _, p := range e.Parts {
+			pad := strings.Repeat(" ", width-len([]rune(p.Token)))
+			sb.WriteString(fmt.Sprintf("  %s%s  %s\n", p.Token, pad, p.Meaning))
+		}
+	}
+
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// safetyVerdict 返回安全检查对命令的结论，未启用安全检查时返回空字符串
+func (a *App) safetyVerdict(command string) string {
+	if a.safety == nil || !a.safety.IsEnabled() {
+		return ""
+	}
+	isDangerous, description, riskLevel := a.safety.IsDangerous(command)
+	if !isDangerous {
+		return i18n.T(i18n.MsgExplainSafe)
+	}
+	return i18n.T(i18n.MsgExplainRisky, description, riskLevel.String())
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/executor"
+	"github.com/studyzy/aicli/pkg/i18n"
+	"github.com/studyzy/aicli/pkg/llm"
+)
+
+// fixFailedCommand 在命令以非零退出码结束后，询问用户是否请 LLM 修正命令
+// 修正后的命令与 Run 中一样经过安全检查后执行，最多尝试 execution.fix_attempts 次；
+// 每次尝试都记录到历史，并通过 ParentID 关联到原始记录
+// 最终仍然失败时返回最后一次执行的 *executor.ExitError
+func (a *App) fixFailedCommand(input string, execCtx *llm.ExecutionContext, command string, failure *executor.ExecResult, stdin string, flags *Flags, parentID int) (string, error) {
+	// 管道模式下 stdin 已被占用，无法交互
+	if a.isPipeMode(stdin) || flags.Quiet {
+		return failure.Output(), failure.Err()
+	}
+
+	maxAttempts := a.config.Execution.FixAttempts
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if !confirmFix(a.ctx, failure.ExitCode) {
+			break
+		}
+
+		ctx, cancel := a.llmContext()
+		result, err := llm.Fix(ctx, a.llm, input, execCtx, &llm.FailedCommand{
+			Command:  command,
+			ExitCode: failure.ExitCode,
+			Stderr:   failure.Stderr,
+		})
+		cancel()
+		if err != nil {
+			return failure.Output(), fmt.Errorf("%s: %w", i18n.T(i18n.ErrFixFailed), err)
+		}
+		if result.Provider == "" {
+			result.Provider = a.llm.Name()
+		}
+
+		line := i18n.T(i18n.MsgFixedCommand, attempt, maxAttempts, result.Command)
+		if result.Explanation != "" {
+			line += "  # " + result.Explanation
+		}
+		fmt.Fprintf(os.Stderr, "%s\n", line)
+
+		if a.safety != nil && a.safety.IsEnabled() {
+			if safetyErr := a.handleDangerousCommand(result.Command, stdin, flags); safetyErr != nil {
+				a.saveUnexecuted(input, result, history.StatusCancelled, safetyErr, parentID)
+				return failure.Output(), safetyErr
+			}
+		}
+
+		res, err := a.execute(result.Command, stdin, flags)
+		a.saveHistory(input, result, res, err, parentID)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrExecuteFailed), err)
+		}
+		if res.Success() {
+			return res.Output(), nil
+		}
+		if res.TimedOut {
+			return res.Output(), timeoutError(res)
+		}
+		if res.Interrupted {
+			return res.Output(), interruptedError(res.Err())
+		}
+
+		command, failure = result.Command, res
+	}
+
+	return failure.Output(), failure.Err()
+}
@@ -29,19 +29,55 @@ type Flags struct {
 
 	// Version 显示版本信息
 	Version bool
+
+	// Candidates 候选命令数量（大于 1 时进入交互式选择）
+	Candidates int
+
+	// Continue 追问模式，在上一次对话的基础上修改命令
+	Continue bool
+
+	// NoCache 不读取缓存，重新请求 LLM（结果仍会写入缓存）
+	NoCache bool
+
+	// ShowPrompt 打印渲染后的提示词，不调用 LLM
+	ShowPrompt bool
+
+	// NoInstructions 不读取项目说明文件（.aicli.md）
+	NoInstructions bool
+
+	// Plan 多步计划模式，将任务拆分为多个步骤审阅后依次执行
+	Plan bool
+
+	// Timeout 命令执行超时（秒），大于 0 时覆盖 execution.timeout
+	Timeout int
+
+	// Shell 执行命令使用的 Shell 名称或路径，非空时覆盖 execution.shell
+	Shell string
+
+	// TTY 在伪终端中执行命令（vim、less 等交互式程序会被自动识别）
+	TTY bool
 }
 
 // NewFlags 创建默认的标志配置
 func NewFlags() *Flags {
 	return &Flags{
-		DryRun:      false,
-		Verbose:     false,
-		Force:       false,
-		NoSendStdin: false,
-		Quiet:       false,
-		Config:      "~/.aicli.json",
-		History:     false,
-		Retry:       -1,
-		Version:     false,
+		DryRun:         false,
+		Verbose:        false,
+		Force:          false,
+		NoSendStdin:    false,
+		Quiet:          false,
+		Config:         "~/.aicli.json",
+		History:        false,
+		Retry:          -1,
+		Version:        false,
+		Candidates:     0,
+		Continue:       false,
+		NoCache:        false,
+		ShowPrompt:     false,
+		NoInstructions: false,
+		Plan:           false,
+		Timeout:        0,
+		Shell:          "",
+		TTY:            false,
 	}
 }
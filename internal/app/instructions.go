@@ -0,0 +1,74 @@
+// Package app 提供项目说明文件（.aicli.md）的加载功能
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// maxInstructionsBytes 写入提示词的项目说明最大字节数
+const maxInstructionsBytes = 4096
+
+// instructionFileNames 项目说明文件名，同一目录中只使用第一个存在的文件
+var instructionFileNames = []string{".aicli.md", "AICLI.md"}
+
+// instructionFile 是一个已加载的项目说明文件
+type instructionFile struct {
+	Path    string
+	Content string
+}
+
+// findInstructions 从工作目录向上查找项目说明文件，按从外到内的顺序返回
+// 外层目录（如主目录）的通用约定在前，离工作目录最近的约定在后，便于后者覆盖前者
+func findInstructions(workDir string) []instructionFile {
+	if workDir == "" {
+		return nil
+	}
+
+	var files []instructionFile
+	dir := filepath.Clean(workDir)
+	for {
+		for _, name := range instructionFileNames {
+			path := filepath.Join(dir, name)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if content := strings.TrimSpace(string(data)); content != "" {
+				files = append([]instructionFile{{Path: path, Content: content}}, files...)
+			}
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	return files
+}
+
+// joinInstructions 合并项目说明，总长度超过 maxBytes 时保留离工作目录最近的内容
+func joinInstructions(files []instructionFile, maxBytes int) string {
+	parts := make([]string, 0, len(files))
+	for _, f := range files {
+		parts = append(parts, f.Content)
+	}
+	text := strings.Join(parts, "\n\n")
+
+	if len(text) <= maxBytes {
+		return text
+	}
+
+	// 截断开头（外层目录的通用约定），不拆分多字节字符
+	start := len(text) - maxBytes
+	for start < len(text) && text[start]&0xC0 == 0x80 {
+		start++
+	}
+	return i18n.T(i18n.LLMTruncated) + "\n" + text[start:]
+}
@@ -0,0 +1,107 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/studyzy/aicli/pkg/config"
+	"github.com/studyzy/aicli/pkg/executor"
+	"github.com/studyzy/aicli/pkg/i18n"
+	"github.com/studyzy/aicli/pkg/llm"
+	"github.com/studyzy/aicli/pkg/safety"
+)
+
+// TestFindInstructions 测试从工作目录向上查找 .aicli.md
+func TestFindInstructions(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(filepath.Join(root, ".aicli.md"), "Logs live in /var/log/app\n")
+	writeFile(filepath.Join(sub, "AICLI.md"), "Deploy via make deploy ENV=staging\n")
+	writeFile(filepath.Join(root, "services", ".aicli.md"), "  \n")
+
+	files := findInstructions(sub)
+	if len(files) != 2 {
+		t.Fatalf("期望找到 2 个说明文件, 实际为 %d: %+v", len(files), files)
+	}
+	// 外层目录在前，离工作目录最近的在后
+	if files[0].Content != "Logs live in /var/log/app" || files[1].Content != "Deploy via make deploy ENV=staging" {
+		t.Errorf("说明文件顺序或内容错误: %+v", files)
+	}
+
+	got := joinInstructions(files, maxInstructionsBytes)
+	if got != "Logs live in /var/log/app\n\nDeploy via make deploy ENV=staging" {
+		t.Errorf("joinInstructions() = %q", got)
+	}
+}
+
+// TestJoinInstructions_Truncate 测试超出大小限制时保留最近的内容
+func TestJoinInstructions_Truncate(t *testing.T) {
+	files := []instructionFile{
+		{Path: "/a/.aicli.md", Content: strings.Repeat("外", 100)},
+		{Path: "/a/b/.aicli.md", Content: "use make deploy"},
+	}
+
+	got := joinInstructions(files, 50)
+	if !strings.HasSuffix(got, "use make deploy") {
+		t.Errorf("应保留离工作目录最近的内容: %q", got)
+	}
+	if len(got) > 50+len(i18n.T(i18n.LLMTruncated))+1 {
+		t.Errorf("截断结果错误 (%d 字节): %q", len(got), got)
+	}
+	if strings.ContainsRune(got, '�') {
+		t.Errorf("截断产生了无效的 UTF-8: %q", got)
+	}
+}
+
+// TestApp_InstructionsInPrompt 测试项目说明写入系统提示词，--no-instructions 可关闭
+func TestApp_InstructionsInPrompt(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".aicli.md"), []byte("Never use sudo"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	var system string
+	mockProvider := &llm.MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *llm.ExecutionContext) (*llm.TranslationResult, error) {
+			system = llm.GetSystemPrompt(execCtx)
+			return &llm.TranslationResult{Command: "apt list --upgradable"}, nil
+		},
+	}
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+
+	flags := NewFlags()
+	flags.DryRun = true
+	flags.Quiet = true
+
+	if _, err := application.Run("list upgradable packages", "", flags); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if !strings.Contains(system, "Never use sudo") {
+		t.Errorf("system prompt should contain instructions: %q", system)
+	}
+
+	flags.NoInstructions = true
+	if _, err := application.Run("list upgradable packages", "", flags); err != nil {
+		t.Fatalf("Run() failed: %v", err)
+	}
+	if strings.Contains(system, "Never use sudo") {
+		t.Error("--no-instructions should skip .aicli.md")
+	}
+}
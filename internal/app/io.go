@@ -2,6 +2,8 @@
 package app
 
 import (
+	"bufio"
+	"context"
 	"io"
 	"os"
 )
@@ -19,6 +21,15 @@ func hasStdin() bool {
 	return (stat.Mode() & os.ModeCharDevice) == 0
 }
 
+// isTerminal 检测文件是否连接到终端
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return (stat.Mode() & os.ModeCharDevice) != 0
+}
+
 // readStdin 读取所有标准输入数据
 // 返回读取的字符串和可能的错误
 func readStdin() (string, error) {
@@ -28,3 +39,25 @@ func readStdin() (string, error) {
 	}
 	return string(data), nil
 }
+
+// readLine 读取一行用户输入，ctx 被取消（如收到 Ctrl-C）时立即返回取消原因
+// 读取在单独的 goroutine 中进行，取消后不再等待它结束（aicli 随后会退出）
+func readLine(ctx context.Context, r *bufio.Reader) (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+
+	ch := make(chan line, 1)
+	go func() {
+		text, err := r.ReadString('\n')
+		ch <- line{text, err}
+	}()
+
+	select {
+	case l := <-ch:
+		return l.text, l.err
+	case <-ctx.Done():
+		return "", context.Cause(ctx)
+	}
+}
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"io"
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestHasStdin 测试 stdin 检测
@@ -309,3 +313,24 @@ func truncateStdinForLLM(data string, maxLen int) string {
 
 	return data[:keepLen] + truncateMsg
 }
+
+// TestReadLine 测试读取一行输入，上下文取消时不再等待输入
+func TestReadLine(t *testing.T) {
+	line, err := readLine(context.Background(), bufio.NewReader(strings.NewReader("yes\nno\n")))
+	if err != nil || line != "yes\n" {
+		t.Errorf("readLine() = %q, %v", line, err)
+	}
+
+	// 管道没有数据写入，读取会一直阻塞
+	r, w, _ := os.Pipe()
+	defer r.Close()
+	defer w.Close()
+
+	stop := errors.New("interrupted")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(50*time.Millisecond, func() { cancel(stop) })
+
+	if _, err := readLine(ctx, bufio.NewReader(r)); !errors.Is(err, stop) {
+		t.Errorf("取消后应返回取消原因, 实际 %v", err)
+	}
+}
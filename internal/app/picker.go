@@ -0,0 +1,151 @@
+// Package app 提供候选命令的交互式选择功能
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+	"golang.org/x/term"
+)
+
+const (
+	keyCtrlC = 0x03
+	keyEnter = '\r'
+	keyLF    = '\n'
+	keyEsc   = 0x1b
+)
+
+// candidateItem 表示候选列表中的一项
+type candidateItem struct {
+	// Command 候选命令
+	Command string
+
+	// Explanation 一行说明
+	Explanation string
+
+	// Warning 安全检查结果（为空表示未发现危险）
+	Warning string
+}
+
+// selectCandidate 让用户从候选列表中选择一个命令
+// 终端可用时使用方向键/数字键交互选择，否则回退到输入序号
+// 返回: 选中项的下标和可能的错误（用户取消时返回错误）
+func selectCandidate(items []candidateItem) (int, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return promptCandidateNumber(bufio.NewReader(os.Stdin), os.Stderr, items)
+	}
+
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return promptCandidateNumber(bufio.NewReader(os.Stdin), os.Stderr, items)
+	}
+	defer term.Restore(fd, oldState)
+
+	return readSelection(bufio.NewReader(os.Stdin), os.Stderr, items)
+}
+
+// readSelection 在原始模式终端中读取按键并实时刷新候选列表
+func readSelection(r *bufio.Reader, w io.Writer, items []candidateItem) (int, error) {
+	selected := 0
+	lines := renderCandidates(w, items, selected, "\r\n")
+
+	for {
+		key, err := r.ReadByte()
+		if err != nil {
+			return -1, fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
+		}
+
+		switch {
+		case key == keyEnter || key == keyLF:
+			return selected, nil
+		case key == keyCtrlC || key == 'q' || key == 'Q':
+			return -1, fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
+		case key >= '1' && key <= '9':
+			if idx := int(key - '1'); idx < len(items) {
+				return idx, nil
+			}
+			continue
+		case key == 'k':
+			selected = (selected - 1 + len(items)) % len(items)
+		case key == 'j':
+			selected = (selected + 1) % len(items)
+		case key == keyEsc:
+			// 方向键序列: ESC [ A (上) / ESC [ B (下)
+			if next, _ := r.ReadByte(); next != '[' {
+				continue
+			}
+			switch arrow, _ := r.ReadByte(); arrow {
+			case 'A':
+				selected = (selected - 1 + len(items)) % len(items)
+			case 'B':
+				selected = (selected + 1) % len(items)
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+
+		// 光标回到列表顶部并清除后重新绘制
+		fmt.Fprintf(w, "\x1b[%dA\r\x1b[J", lines)
+		lines = renderCandidates(w, items, selected, "\r\n")
+	}
+}
+
+// promptCandidateNumber 显示候选列表并读取用户输入的序号（非终端环境）
+func promptCandidateNumber(r *bufio.Reader, w io.Writer, items []candidateItem) (int, error) {
+	renderCandidates(w, items, -1, "\n")
+	fmt.Fprintf(w, "%s [1]: ", i18n.T(i18n.PromptInputChoice))
+
+	response, err := r.ReadString('\n')
+	response = strings.TrimSpace(response)
+	if err != nil && response == "" {
+		return -1, fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
+	}
+	if response == "" {
+		return 0, nil
+	}
+
+	idx, convErr := strconv.Atoi(response)
+	if convErr != nil || idx < 1 || idx > len(items) {
+		return -1, fmt.Errorf("%s", i18n.T(i18n.ErrInvalidCandidate, response))
+	}
+
+	return idx - 1, nil
+}
+
+// renderCandidates 绘制候选列表，selected 为 -1 时不显示选中标记
+// 返回: 输出的行数（用于重新绘制时移动光标）
+func renderCandidates(w io.Writer, items []candidateItem, selected int, newline string) int {
+	lines := 0
+	fmt.Fprintf(w, "%s%s", i18n.T(i18n.MsgSelectCandidate, len(items)), newline)
+	lines++
+
+	for i, item := range items {
+		marker := "  "
+		if i == selected {
+			marker = "❯ "
+		}
+
+		status := "✓"
+		if item.Warning != "" {
+			status = "⚠️  " + item.Warning
+		}
+
+		fmt.Fprintf(w, "%s%d. %s  [%s]%s", marker, i+1, item.Command, status, newline)
+		lines++
+
+		if item.Explanation != "" {
+			fmt.Fprintf(w, "     %s%s", item.Explanation, newline)
+			lines++
+		}
+	}
+
+	return lines
+}
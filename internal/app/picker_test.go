@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testCandidateItems() []candidateItem {
+	return []candidateItem{
+		{Command: "find . -name '*.log'", Explanation: "使用 find"},
+		{Command: "fd -e log", Explanation: "使用 fd"},
+		{Command: "rm -rf ./logs", Warning: "递归删除文件或目录"},
+	}
+}
+
+// TestReadSelection 测试方向键和数字键选择
+func TestReadSelection(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    string
+		want    int
+		wantErr bool
+	}{
+		{name: "直接回车选择第一个", keys: "\r", want: 0},
+		{name: "下箭头后回车", keys: "\x1b[B\r", want: 1},
+		{name: "上箭头循环到最后", keys: "\x1b[A\r", want: 2},
+		{name: "数字键直接选择", keys: "2", want: 1},
+		{name: "超出范围的数字被忽略", keys: "7\x1b[B\x1b[B\r", want: 2},
+		{name: "q 取消", keys: "q", wantErr: true},
+		{name: "Ctrl-C 取消", keys: "\x03", wantErr: true},
+		{name: "输入结束视为取消", keys: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := readSelection(bufio.NewReader(strings.NewReader(tt.keys)), &out, testCandidateItems())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readSelection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("readSelection() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPromptCandidateNumber 测试非终端环境下输入序号选择
+func TestPromptCandidateNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{name: "默认选择第一个", input: "\n", want: 0},
+		{name: "输入序号", input: "3\n", want: 2},
+		{name: "序号越界", input: "4\n", wantErr: true},
+		{name: "非数字", input: "abc\n", wantErr: true},
+		{name: "无输入", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			got, err := promptCandidateNumber(bufio.NewReader(strings.NewReader(tt.input)), &out, testCandidateItems())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("promptCandidateNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("promptCandidateNumber() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRenderCandidates 测试候选列表显示安全检查结果
+func TestRenderCandidates(t *testing.T) {
+	var out bytes.Buffer
+	lines := renderCandidates(&out, testCandidateItems(), 1, "\n")
+
+	// 标题 + 3 个命令 + 2 行说明
+	if lines != 6 {
+		t.Errorf("renderCandidates() = %d 行, 期望 6", lines)
+	}
+	if !strings.Contains(out.String(), "❯ 2. fd -e log") {
+		t.Errorf("选中项应带有标记, 实际输出:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "递归删除文件或目录") {
+		t.Errorf("危险候选应显示安全检查结果, 实际输出:\n%s", out.String())
+	}
+}
@@ -0,0 +1,226 @@
+// Package app 提供多步计划的审阅与执行功能
+package app
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/i18n"
+	"github.com/studyzy/aicli/pkg/llm"
+)
+
+// runPlan 请求 LLM 将任务拆分为多个步骤，经用户审阅后依次执行
+// 每一步都单独进行安全检查；任何一步失败时停止执行
+func (a *App) runPlan(input string, execCtx *llm.ExecutionContext, stdin string, flags *Flags) (string, error) {
+	ctx, cancel := a.llmContext()
+	plan, err := llm.TranslatePlan(ctx, a.llm, input, execCtx)
+	cancel()
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrTranslateFailed), err)
+	}
+	if plan.Provider == "" {
+		plan.Provider = a.llm.Name()
+	}
+
+	if flags.Verbose && plan.Usage.TotalTokens > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", i18n.T(i18n.VerboseUsage),
+			i18n.T(i18n.VerboseUsageFormat, plan.Usage.TotalTokens, plan.Usage.PromptTokens, plan.Usage.CompletionTokens))
+	}
+
+	steps := plan.Steps
+
+	// Dry-run 模式：只显示计划不执行
+	if flags.DryRun {
+		renderPlan(os.Stderr, steps, a.stepWarnings(steps))
+		a.saveUnexecuted(input, planResult(plan), history.StatusDryRun, nil, 0)
+		return "", nil
+	}
+
+	// 管道模式下 stdin 已被占用，无法交互审阅，只显示计划
+	if a.isPipeMode(stdin) || !isTerminal(os.Stdin) {
+		if !flags.Quiet {
+			renderPlan(os.Stderr, steps, a.stepWarnings(steps))
+		}
+	} else {
+		steps, err = a.reviewPlan(bufio.NewReader(os.Stdin), os.Stderr, steps)
+		if err != nil {
+			a.saveUnexecuted(input, planResult(plan), history.StatusCancelled, err, 0)
+			return "", err
+		}
+	}
+	if len(steps) == 0 {
+		err := fmt.Errorf("%s", i18n.T(i18n.ErrPlanEmpty))
+		a.saveUnexecuted(input, planResult(plan), history.StatusCancelled, err, 0)
+		return "", err
+	}
+	plan.Steps = steps
+
+	return a.executePlan(input, execCtx, plan, stdin, flags)
+}
+
+// executePlan 依次执行计划中的步骤
+// 标记为依赖前面输出的步骤会先根据已执行步骤的输出重新生成命令
+// 生成计划的 token 用量记录在第一步的历史记录中，重新生成命令的用量记录在对应步骤中
+func (a *App) executePlan(input string, execCtx *llm.ExecutionContext, plan *llm.Plan, stdin string, flags *Flags) (string, error) {
+	steps := plan.Steps
+	outputs := make([]string, 0, len(steps))
+
+	for i := range steps {
+		step := &steps[i]
+		result := &llm.TranslationResult{Provider: plan.Provider, Model: plan.Model}
+		if i == 0 {
+			result.Usage = plan.Usage
+		}
+
+		if step.UsesOutput && i > 0 {
+			ctx, cancel := a.llmContext()
+			refined, err := llm.RefineStep(ctx, a.llm, input, execCtx, steps, outputs, i)
+			cancel()
+			if err != nil {
+				return strings.Join(outputs, ""), fmt.Errorf("%s: %w", i18n.T(i18n.ErrTranslateFailed), err)
+			}
+			result.Usage = refined.Usage
+			if refined.Model != "" {
+				result.Model = refined.Model
+			}
+			if refined.Command != step.Command {
+				step.Command = refined.Command
+				fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgPlanRefined, i+1, step.Command))
+			}
+		}
+
+		if !flags.Quiet {
+			fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgPlanStep, i+1, len(steps), step.Command))
+		}
+
+		if a.safety != nil && a.safety.IsEnabled() {
+			if safetyErr := a.handleDangerousCommand(step.Command, stdin, flags); safetyErr != nil {
+				result.Command, result.Explanation = step.Command, step.Description
+				a.saveUnexecuted(input, result, history.StatusCancelled, safetyErr, 0)
+				return strings.Join(outputs, ""), safetyErr
+			}
+		}
+
+		// 只有第一步读取管道输入
+		stepStdin := ""
+		if i == 0 {
+			stepStdin = stdin
+		}
+
+		res, err := a.execute(step.Command, stepStdin, flags)
+		result.Command, result.Explanation = step.Command, step.Description
+		a.saveHistory(input, result, res, err, 0)
+		if err == nil {
+			outputs = append(outputs, res.Output())
+			err = res.Err()
+		}
+
+		if err != nil {
+			return strings.Join(outputs, ""), fmt.Errorf("%s: %w", i18n.T(i18n.ErrPlanStepFailed, i+1), err)
+		}
+	}
+
+	if !flags.Quiet {
+		fmt.Fprintf(os.Stderr, "%s\n", i18n.T(i18n.MsgPlanDone, len(steps)))
+	}
+
+	return strings.Join(outputs, ""), nil
+}
+
+// planResult 返回记录未执行计划的转换结果：命令为用 && 连接的所有步骤，用量为生成计划的用量
+func planResult(plan *llm.Plan) *llm.TranslationResult {
+	commands := make([]string, 0, len(plan.Steps))
+	for _, step := range plan.Steps {
+		commands = append(commands, step.Command)
+	}
+	return &llm.TranslationResult{
+		Command:  strings.Join(commands, " && "),
+		Provider: plan.Provider,
+		Model:    plan.Model,
+		Usage:    plan.Usage,
+	}
+}
+
+// reviewPlan 显示计划并让用户编辑、删除步骤，直到用户确认执行
+// 用户编辑过的步骤按原样执行，不再根据前面的输出重新生成
+func (a *App) reviewPlan(r *bufio.Reader, w io.Writer, steps []llm.PlanStep) ([]llm.PlanStep, error) {
+	for {
+		renderPlan(w, steps, a.stepWarnings(steps))
+		fmt.Fprintf(w, "%s", i18n.T(i18n.PromptPlanReview))
+
+		response, err := readLine(a.ctx, r)
+		if a.ctx.Err() != nil {
+			return nil, interruptedError(context.Cause(a.ctx))
+		}
+		response = strings.TrimSpace(response)
+		if err != nil && response == "" {
+			return nil, fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
+		}
+
+		if response == "" {
+			return steps, nil
+		}
+		if response == "q" || response == "Q" {
+			return nil, fmt.Errorf("%s", i18n.T(i18n.ErrUserCancelled))
+		}
+
+		action := strings.ToLower(response[:1])
+		idx, convErr := strconv.Atoi(strings.TrimSpace(response[1:]))
+		if (action != "e" && action != "d") || convErr != nil || idx < 1 || idx > len(steps) {
+			fmt.Fprintf(w, "%s\n", i18n.T(i18n.ErrInvalidPlanAction, response))
+			continue
+		}
+
+		if action == "d" {
+			steps = append(steps[:idx-1], steps[idx:]...)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s", i18n.T(i18n.PromptPlanEdit, idx))
+		command, _ := readLine(a.ctx, r)
+		if a.ctx.Err() != nil {
+			return nil, interruptedError(context.Cause(a.ctx))
+		}
+		if command = strings.TrimSpace(command); command != "" {
+			steps[idx-1].Command = command
+			steps[idx-1].UsesOutput = false
+		}
+	}
+}
+
+// stepWarnings 返回每个步骤的安全检查结果（为空表示未发现危险）
+func (a *App) stepWarnings(steps []llm.PlanStep) []string {
+	warnings := make([]string, len(steps))
+	if a.safety == nil || !a.safety.IsEnabled() {
+		return warnings
+	}
+
+	for i, step := range steps {
+		if isDangerous, description, riskLevel := a.safety.IsDangerous(step.Command); isDangerous {
+			warnings[i] = fmt.Sprintf("%s (%s: %s)", description, i18n.T(i18n.WarnRiskLevel), riskLevel.String())
+		}
+	}
+	return warnings
+}
+
+// renderPlan 显示计划的步骤列表
+func renderPlan(w io.Writer, steps []llm.PlanStep, warnings []string) {
+	fmt.Fprintf(w, "%s\n", i18n.T(i18n.MsgPlanHeader, len(steps)))
+	for i, step := range steps {
+		status := "✓"
+		if warnings[i] != "" {
+			status = "⚠️  " + warnings[i]
+		}
+
+		fmt.Fprintf(w, "  %d. %s  [%s]\n", i+1, step.Command, status)
+		if step.Description != "" {
+			fmt.Fprintf(w, "     %s\n", step.Description)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/studyzy/aicli/internal/history"
+	"github.com/studyzy/aicli/pkg/config"
+	"github.com/studyzy/aicli/pkg/executor"
+	"github.com/studyzy/aicli/pkg/llm"
+	"github.com/studyzy/aicli/pkg/safety"
+)
+
+// TestReviewPlan 测试审阅计划时编辑、删除步骤
+func TestReviewPlan(t *testing.T) {
+	application := NewApp(config.Default(), llm.NewMockProvider(), executor.NewExecutor(), safety.NewChecker(true))
+	steps := []llm.PlanStep{
+		{Description: "克隆", Command: "git clone x"},
+		{Description: "构建", Command: "make", UsesOutput: true},
+		{Description: "清理", Command: "rm -rf /"},
+	}
+
+	var w bytes.Buffer
+	r := bufio.NewReader(strings.NewReader("d3\nx9\ne 2\nmake build\n\n"))
+	got, err := application.reviewPlan(r, &w, steps)
+	if err != nil {
+		t.Fatalf("reviewPlan() error = %v", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("期望剩余 2 个步骤, 实际为 %d: %+v", len(got), got)
+	}
+	if got[1].Command != "make build" || got[1].UsesOutput {
+		t.Errorf("编辑后的步骤应按原样执行: %+v", got[1])
+	}
+	if !strings.Contains(w.String(), "rm -rf /  [⚠️") {
+		t.Errorf("危险步骤应显示警告:\n%s", w.String())
+	}
+	if !strings.Contains(w.String(), "x9") {
+		t.Errorf("无效输入应提示用户:\n%s", w.String())
+	}
+}
+
+// TestReviewPlan_Quit 测试审阅时退出
+func TestReviewPlan_Quit(t *testing.T) {
+	application := NewApp(config.Default(), llm.NewMockProvider(), executor.NewExecutor(), safety.NewChecker(false))
+
+	var w bytes.Buffer
+	_, err := application.reviewPlan(bufio.NewReader(strings.NewReader("q\n")), &w, []llm.PlanStep{{Command: "ls"}})
+	if err == nil {
+		t.Error("退出时应返回错误")
+	}
+}
+
+// TestExecutePlan 测试多步计划依次执行，并把前面步骤的输出提供给后续步骤
+func TestExecutePlan(t *testing.T) {
+	var refineMessages []llm.Message
+	mockProvider := &llm.MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			refineMessages = messages
+			return &llm.Completion{Content: `{"command":"echo using v1.2"}`}, nil
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(true))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	steps := []llm.PlanStep{
+		{Description: "输出版本", Command: "echo v1.2"},
+		{Description: "使用版本", Command: "echo VERSION", UsesOutput: true},
+		{Description: "失败", Command: "exit 4"},
+		{Description: "不会执行", Command: "echo never"},
+	}
+	plan := &llm.Plan{Steps: steps, Provider: "mock"}
+	output, err := application.executePlan("构建并使用版本", nil, plan, "", NewFlags())
+	if err == nil {
+		t.Fatal("失败的步骤应返回错误")
+	}
+
+	if !strings.Contains(output, "using v1.2") || strings.Contains(output, "never") {
+		t.Errorf("输出不正确: %q", output)
+	}
+	if len(refineMessages) == 0 || !strings.Contains(refineMessages[len(refineMessages)-1].Content, "v1.2") {
+		t.Errorf("重新生成步骤时应包含前面步骤的输出: %+v", refineMessages)
+	}
+
+	entries := hist.List()
+	if len(entries) != 3 {
+		t.Fatalf("期望 3 条历史记录, 实际为 %d", len(entries))
+	}
+	if entries[0].Success || entries[0].ExitCode != 4 {
+		t.Errorf("失败的步骤应记录退出码: %+v", entries[0])
+	}
+}
+
+// TestApp_PlanDryRun 测试 dry-run 模式只显示计划
+func TestApp_PlanDryRun(t *testing.T) {
+	mockProvider := &llm.MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []llm.Message) (*llm.Completion, error) {
+			return &llm.Completion{Content: `[{"command":"touch /tmp/aicli-plan-dry-run"}]`}, nil
+		},
+	}
+
+	application := NewApp(config.Default(), mockProvider, executor.NewExecutor(), safety.NewChecker(false))
+	hist := history.NewHistory()
+	application.SetHistory(hist)
+
+	flags := NewFlags()
+	flags.Plan = true
+	flags.DryRun = true
+	if _, err := application.Run("创建文件", "", flags); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	// 只记录生成计划的用量，不执行任何步骤
+	entries := hist.List()
+	if len(entries) != 1 || entries[0].Status != history.StatusDryRun || entries[0].Command != "touch /tmp/aicli-plan-dry-run" {
+		t.Errorf("dry-run 模式应只记录一条未执行的历史: %+v", entries)
+	}
+	if _, err := os.Stat("/tmp/aicli-plan-dry-run"); err == nil {
+		t.Error("dry-run 模式不应执行任何步骤")
+	}
+}
@@ -5,11 +5,36 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// 命令执行状态
+const (
+	// StatusSuccess 命令成功执行
+	StatusSuccess = "success"
+
+	// StatusFailed 命令无法启动或以非零退出码结束
+	StatusFailed = "failed"
+
+	// StatusTimeout 命令执行超时被终止
+	StatusTimeout = "timeout"
+
+	// StatusInterrupted 等待 LLM 响应或命令执行期间被中断信号（如 Ctrl-C）打断
+	StatusInterrupted = "interrupted"
+
+	// StatusDryRun Dry-run 模式，只生成命令不执行
+	StatusDryRun = "dry_run"
+
+	// StatusCancelled 用户取消或安全检查拒绝，命令未执行
+	StatusCancelled = "cancelled"
+
+	// StatusExplain 解释命令（aicli explain），不执行
+	StatusExplain = "explain"
+)
+
 // Entry 表示一条历史记录
 type Entry struct {
 	// ID 唯一标识符
@@ -21,20 +46,78 @@ type Entry struct {
 	// Command 转换后的命令
 	Command string `json:"command"`
 
+	// Explanation 命令的一行说明
+	Explanation string `json:"explanation,omitempty"`
+
+	// Provider 实际生成命令的 LLM 提供商
+	Provider string `json:"provider,omitempty"`
+
 	// Timestamp 执行时间
 	Timestamp time.Time `json:"timestamp"`
 
 	// Success 命令是否执行成功
 	Success bool `json:"success"`
 
-	// ExitCode 命令退出码
+	// Status 命令执行状态（StatusSuccess、StatusFailed、StatusTimeout、StatusInterrupted），
+	// 未执行的记录为 StatusDryRun、StatusCancelled 或 StatusExplain（仍记录 token 用量）
+	Status string `json:"status,omitempty"`
+
+	// ExitCode 命令退出码（被信号终止时为 128+信号值）
 	ExitCode int `json:"exit_code"`
 
-	// Output 命令输出（截断）
+	// Signal 终止命令的信号名称（正常退出时为空）
+	Signal string `json:"signal,omitempty"`
+
+	// DurationMs 命令执行耗时（毫秒）
+	DurationMs int64 `json:"duration_ms,omitempty"`
+
+	// Output 命令的标准输出（截断）
 	Output string `json:"output,omitempty"`
 
+	// Stderr 命令的标准错误输出（截断）
+	Stderr string `json:"stderr,omitempty"`
+
 	// Error 错误信息
 	Error string `json:"error,omitempty"`
+
+	// ParentID 修正尝试所对应的原始记录 ID（0 表示不是修正尝试）
+	ParentID int `json:"parent_id,omitempty"`
+
+	// Model 生成命令使用的模型
+	Model string `json:"model,omitempty"`
+
+	// PromptTokens 本次请求的输入 token 数
+	PromptTokens int `json:"prompt_tokens,omitempty"`
+
+	// CompletionTokens 本次请求的输出 token 数
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+
+	// Cost 按请求时的单价表计算的费用（未配置单价时为 0）
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// UsageSummary 表示按日期、提供商和模型汇总的 token 用量
+type UsageSummary struct {
+	// Day 日期（本地时间，格式 2006-01-02）
+	Day string
+
+	// Provider 提供商名称
+	Provider string
+
+	// Model 模型名称
+	Model string
+
+	// Requests 请求次数
+	Requests int
+
+	// PromptTokens 输入 token 总数
+	PromptTokens int
+
+	// CompletionTokens 输出 token 总数
+	CompletionTokens int
+
+	// Cost 费用合计
+	Cost float64
 }
 
 // History 管理历史记录
@@ -232,3 +315,46 @@ func (h *History) GetFilePath() string {
 
 	return h.filePath
 }
+
+// SummarizeUsage 按日期、提供商和模型汇总 since 之后的 token 用量
+// 没有 token 用量的记录（如命中缓存）不计入；结果按日期、提供商、模型排序
+func (h *History) SummarizeUsage(since time.Time) []UsageSummary {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	index := make(map[[3]string]*UsageSummary)
+	for _, entry := range h.entries {
+		if entry.PromptTokens+entry.CompletionTokens == 0 || entry.Timestamp.Before(since) {
+			continue
+		}
+
+		day := entry.Timestamp.Local().Format("2006-01-02")
+		key := [3]string{day, entry.Provider, entry.Model}
+		summary, ok := index[key]
+		if !ok {
+			summary = &UsageSummary{Day: day, Provider: entry.Provider, Model: entry.Model}
+			index[key] = summary
+		}
+
+		summary.Requests++
+		summary.PromptTokens += entry.PromptTokens
+		summary.CompletionTokens += entry.CompletionTokens
+		summary.Cost += entry.Cost
+	}
+
+	result := make([]UsageSummary, 0, len(index))
+	for _, summary := range index {
+		result = append(result, *summary)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Day != result[j].Day {
+			return result[i].Day < result[j].Day
+		}
+		if result[i].Provider != result[j].Provider {
+			return result[i].Provider < result[j].Provider
+		}
+		return result[i].Model < result[j].Model
+	})
+
+	return result
+}
@@ -341,3 +341,35 @@ func TestHistory_ConcurrentAccess(t *testing.T) {
 		t.Errorf("entries count = %d, want 10", len(history.entries))
 	}
 }
+
+// TestHistory_SummarizeUsage 测试按日期、提供商和模型汇总 token 用量
+func TestHistory_SummarizeUsage(t *testing.T) {
+	h := NewHistory()
+	day1 := time.Date(2026, 3, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+
+	h.Add(&Entry{Timestamp: day1, Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 100, CompletionTokens: 10, Cost: 0.01})
+	h.Add(&Entry{Timestamp: day1.Add(time.Hour), Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 200, CompletionTokens: 20, Cost: 0.02})
+	h.Add(&Entry{Timestamp: day1, Provider: "anthropic", Model: "claude", PromptTokens: 50, CompletionTokens: 5})
+	h.Add(&Entry{Timestamp: day2, Provider: "openai", Model: "gpt-4o-mini", PromptTokens: 10, CompletionTokens: 1})
+	// 命中缓存的记录没有 token 用量，不计入
+	h.Add(&Entry{Timestamp: day2, Provider: "openai", Model: "gpt-4o-mini"})
+
+	got := h.SummarizeUsage(time.Time{})
+	if len(got) != 3 {
+		t.Fatalf("期望 3 组汇总, 实际为 %d: %+v", len(got), got)
+	}
+	if got[0].Provider != "anthropic" || got[1].Provider != "openai" || got[2].Day != "2026-03-02" {
+		t.Errorf("汇总顺序不正确: %+v", got)
+	}
+	if got[1].Requests != 2 || got[1].PromptTokens != 300 || got[1].CompletionTokens != 30 {
+		t.Errorf("汇总结果不正确: %+v", got[1])
+	}
+	if got[1].Cost < 0.0299 || got[1].Cost > 0.0301 {
+		t.Errorf("费用合计 = %v, 期望 0.03", got[1].Cost)
+	}
+
+	if since := h.SummarizeUsage(day2); len(since) != 1 {
+		t.Errorf("期望 since 之后只有 1 组汇总, 实际为 %d", len(since))
+	}
+}
@@ -0,0 +1,171 @@
+// Package session 提供多轮对话会话管理功能
+// 会话记录最近几轮的用户输入和生成的命令，用于 --continue 追问时作为对话历史发送给 LLM
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/llm"
+)
+
+// DefaultMaxTurns 默认保留的最大对话轮数
+const DefaultMaxTurns = 5
+
+// Turn 表示会话中的一轮对话
+type Turn struct {
+	// Input 用户的自然语言输入
+	Input string `json:"input"`
+
+	// Command 生成的命令
+	Command string `json:"command"`
+
+	// Explanation 命令的一行说明
+	Explanation string `json:"explanation,omitempty"`
+
+	// Timestamp 生成时间
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Session 管理一个多轮对话会话
+type Session struct {
+	turns    []*Turn
+	maxTurns int
+	mu       sync.RWMutex
+}
+
+// NewSession 创建一个新的 Session 实例
+func NewSession() *Session {
+	return &Session{
+		turns:    make([]*Turn, 0),
+		maxTurns: DefaultMaxTurns,
+	}
+}
+
+// Add 添加一轮对话，超过最大轮数时丢弃最旧的记录
+func (s *Session) Add(turn *Turn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.turns = append(s.turns, turn)
+	if len(s.turns) > s.maxTurns {
+		s.turns = s.turns[len(s.turns)-s.maxTurns:]
+	}
+}
+
+// Turns 返回所有对话轮次（按时间顺序）
+func (s *Session) Turns() []*Turn {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]*Turn, len(s.turns))
+	copy(result, s.turns)
+	return result
+}
+
+// Len 返回对话轮数
+func (s *Session) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.turns)
+}
+
+// Reset 清空会话，开始新的对话
+func (s *Session) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.turns = make([]*Turn, 0)
+}
+
+// Messages 将会话转换为 LLM 对话历史
+// 每轮对话生成一条 user 消息和一条 assistant 消息，assistant 消息使用与系统提示词一致的 JSON 格式
+func (s *Session) Messages() []llm.Message {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	messages := make([]llm.Message, 0, len(s.turns)*2)
+	for _, turn := range s.turns {
+		reply, err := json.Marshal(struct {
+			Command     string `json:"command"`
+			Explanation string `json:"explanation,omitempty"`
+		}{turn.Command, turn.Explanation})
+		if err != nil {
+			continue
+		}
+
+		messages = append(messages,
+			llm.Message{Role: llm.RoleUser, Content: turn.Input},
+			llm.Message{Role: llm.RoleAssistant, Content: string(reply)},
+		)
+	}
+
+	return messages
+}
+
+// SetMaxTurns 设置最大对话轮数
+func (s *Session) SetMaxTurns(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxTurns = n
+	if len(s.turns) > n {
+		s.turns = s.turns[len(s.turns)-n:]
+	}
+}
+
+// Save 保存会话到文件
+func (s *Session) Save(filePath string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if dir := filepath.Dir(filePath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(s.turns, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, data, 0600); err != nil {
+		return fmt.Errorf("写入会话文件失败: %w", err)
+	}
+
+	return nil
+}
+
+// Load 从文件加载会话
+func (s *Session) Load(filePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 文件不存在不算错误，返回空会话
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("读取会话文件失败: %w", err)
+	}
+
+	var turns []*Turn
+	if err := json.Unmarshal(data, &turns); err != nil {
+		return fmt.Errorf("解析会话失败: %w", err)
+	}
+
+	if len(turns) > s.maxTurns {
+		turns = turns[len(turns)-s.maxTurns:]
+	}
+	s.turns = turns
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/studyzy/aicli/pkg/llm"
+)
+
+// TestSession_AddAndLimit 测试添加对话并限制轮数
+func TestSession_AddAndLimit(t *testing.T) {
+	s := NewSession()
+	s.SetMaxTurns(2)
+
+	s.Add(&Turn{Input: "one", Command: "echo 1"})
+	s.Add(&Turn{Input: "two", Command: "echo 2"})
+	s.Add(&Turn{Input: "three", Command: "echo 3"})
+
+	turns := s.Turns()
+	if len(turns) != 2 {
+		t.Fatalf("turns count = %d, want 2", len(turns))
+	}
+	if turns[0].Input != "two" || turns[1].Input != "three" {
+		t.Errorf("turns = [%s %s], want [two three]", turns[0].Input, turns[1].Input)
+	}
+
+	s.Reset()
+	if s.Len() != 0 {
+		t.Errorf("Len() after Reset = %d, want 0", s.Len())
+	}
+}
+
+// TestSession_Messages 测试会话转换为对话历史
+func TestSession_Messages(t *testing.T) {
+	s := NewSession()
+	s.Add(&Turn{Input: "list big files", Command: "du -ah . | sort -rh | head", Explanation: "largest files"})
+
+	messages := s.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("messages count = %d, want 2", len(messages))
+	}
+	if messages[0].Role != llm.RoleUser || messages[0].Content != "list big files" {
+		t.Errorf("messages[0] = %+v, want user input", messages[0])
+	}
+	if messages[1].Role != llm.RoleAssistant {
+		t.Errorf("messages[1].Role = %s, want assistant", messages[1].Role)
+	}
+	if !strings.Contains(messages[1].Content, `"command":"du -ah . | sort -rh | head"`) {
+		t.Errorf("messages[1].Content = %s, want JSON with command", messages[1].Content)
+	}
+}
+
+// TestSession_SaveLoad 测试会话持久化
+func TestSession_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	s := NewSession()
+	s.Add(&Turn{Input: "list files", Command: "ls -la"})
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded := NewSession()
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Len() != 1 || loaded.Turns()[0].Command != "ls -la" {
+		t.Errorf("loaded turns = %+v, want one turn with ls -la", loaded.Turns())
+	}
+
+	// 文件不存在时返回空会话
+	empty := NewSession()
+	if err := empty.Load(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("Load() missing file error = %v", err)
+	}
+	if empty.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", empty.Len())
+	}
+}
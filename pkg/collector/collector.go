@@ -0,0 +1,212 @@
+// Package collector 提供执行环境信息收集功能
+// 收集器在调用 LLM 之前运行，把可用工具、发行版、包管理器等信息加入提示词，
+// 避免模型猜测 jq、rg、gsed 等命令是否存在
+package collector
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/config"
+)
+
+const (
+	// DefaultBudget 收集结果写入提示词的默认最大字节数
+	DefaultBudget = 1024
+
+	// DefaultTimeout 单个收集器的默认超时时间
+	DefaultTimeout = 2 * time.Second
+
+	// truncatedMark 超出预算时追加的截断标记
+	truncatedMark = "..."
+)
+
+// Env 是收集器的输入环境
+type Env struct {
+	// OS 操作系统（runtime.GOOS）
+	OS string
+
+	// Shell 类型
+	Shell string
+
+	// WorkDir 当前工作目录
+	WorkDir string
+}
+
+// Collector 收集一类执行环境信息
+type Collector interface {
+	// Name 返回收集器名称（用于配置开关）
+	Name() string
+
+	// Collect 收集信息，返回写入提示词的标题和内容；内容为空表示没有可用信息
+	Collect(ctx context.Context, env *Env) (title, content string, err error)
+}
+
+// Fingerprinter 由能快速给出稳定摘要的收集器实现（可选）
+// 摘要计入转换缓存的键，因此只应包含不随每次编辑变化的信息
+type Fingerprinter interface {
+	// Fingerprint 返回收集结果的稳定摘要
+	Fingerprint(ctx context.Context, env *Env) string
+}
+
+// Section 是单个收集器的运行结果
+type Section struct {
+	// Name 收集器名称
+	Name string
+
+	// Title 提示词中显示的标题
+	Title string
+
+	// Content 收集到的内容
+	Content string
+
+	// Err 收集失败时的错误
+	Err error
+
+	// Duration 收集耗时
+	Duration time.Duration
+}
+
+// Runner 并发运行一组收集器
+type Runner struct {
+	collectors []Collector
+	budget     int
+	timeout    time.Duration
+}
+
+// NewRunner 创建收集器运行器
+// budget: 写入提示词的最大字节数，小于等于 0 时使用默认值
+func NewRunner(budget int, collectors ...Collector) *Runner {
+	if budget <= 0 {
+		budget = DefaultBudget
+	}
+	return &Runner{
+		collectors: collectors,
+		budget:     budget,
+		timeout:    DefaultTimeout,
+	}
+}
+
+// NewRunnerFromConfig 根据配置创建收集器运行器，未在配置中关闭的收集器都会启用
+func NewRunnerFromConfig(cfg *config.ContextConfig) *Runner {
+	all := []Collector{
+		NewProjectCollector(),
+		NewToolsCollector(cfg.ExtraTools...),
+		NewDistroCollector(),
+		NewPackageManagerCollector(),
+		NewUserlandCollector(),
+	}
+
+	var enabled []Collector
+	for _, c := range all {
+		if cfg.CollectorEnabled(c.Name()) {
+			enabled = append(enabled, c)
+		}
+	}
+
+	return NewRunner(cfg.MaxBytes, enabled...)
+}
+
+// SetTimeout 设置单个收集器的超时时间
+func (r *Runner) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// Budget 返回写入提示词的最大字节数
+func (r *Runner) Budget() int {
+	return r.budget
+}
+
+// Run 并发运行所有收集器，按注册顺序返回结果
+func (r *Runner) Run(ctx context.Context, env *Env) []Section {
+	sections := make([]Section, len(r.collectors))
+
+	var wg sync.WaitGroup
+	for i, c := range r.collectors {
+		wg.Add(1)
+		go func(i int, c Collector) {
+			defer wg.Done()
+
+			cctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			start := time.Now()
+			title, content, err := c.Collect(cctx, env)
+			sections[i] = Section{
+				Name:     c.Name(),
+				Title:    title,
+				Content:  strings.TrimSpace(content),
+				Err:      err,
+				Duration: time.Since(start),
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	return sections
+}
+
+// Fingerprint 返回实现了 Fingerprinter 的收集器的摘要（按注册顺序，每个收集器一行）
+// 工具、发行版等只与本机有关的信息不需要计入摘要：缓存本身保存在本机
+func (r *Runner) Fingerprint(ctx context.Context, env *Env) string {
+	var sb strings.Builder
+	for _, c := range r.collectors {
+		f, ok := c.(Fingerprinter)
+		if !ok {
+			continue
+		}
+
+		cctx, cancel := context.WithTimeout(ctx, r.timeout)
+		sb.WriteString(c.Name() + ": " + f.Fingerprint(cctx, env) + "\n")
+		cancel()
+	}
+	return sb.String()
+}
+
+// Format 将收集结果格式化为提示词文本（每个收集器一行），总长度不超过 budget 字节
+// 失败或为空的结果会被跳过；超出预算时截断最后一条并丢弃之后的结果
+func Format(sections []Section, budget int) string {
+	var sb strings.Builder
+	for _, s := range sections {
+		if s.Err != nil || s.Content == "" {
+			continue
+		}
+
+		line := "- " + s.Title + ": " + strings.ReplaceAll(s.Content, "\n", "\n  ") + "\n"
+		if sb.Len()+len(line) <= budget {
+			sb.WriteString(line)
+			continue
+		}
+
+		// 超出预算：截断到剩余空间（保证不截断多字节字符）
+		remain := budget - sb.Len() - len(truncatedMark) - 1
+		if remain > len("- "+s.Title+": ") {
+			sb.WriteString(truncateUTF8(line, remain) + truncatedMark + "\n")
+		}
+		break
+	}
+	return sb.String()
+}
+
+// truncateUTF8 将字符串截断为最多 n 个字节，不拆分多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && n < len(s) && s[n]&0xC0 == 0x80 {
+		n--
+	}
+	return s[:n]
+}
+
+// lookPath 查找可执行文件，测试中可替换
+var lookPath = exec.LookPath
+
+// runCommand 运行命令并返回合并后的输出，测试中可替换
+var runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return string(out), err
+}
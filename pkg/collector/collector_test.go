@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/config"
+)
+
+// fakeCollector 返回固定结果的收集器
+type fakeCollector struct {
+	name    string
+	content string
+	err     error
+	delay   time.Duration
+}
+
+func (f *fakeCollector) Name() string { return f.name }
+
+func (f *fakeCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return f.name, "", ctx.Err()
+		}
+	}
+	return f.name, f.content, f.err
+}
+
+// stubCommands 替换 lookPath 和 runCommand，测试结束后恢复
+func stubCommands(t *testing.T, paths map[string]string, outputs map[string]string) {
+	t.Helper()
+	origLook, origRun := lookPath, runCommand
+	t.Cleanup(func() { lookPath, runCommand = origLook, origRun })
+
+	lookPath = func(file string) (string, error) {
+		if p, ok := paths[file]; ok {
+			return p, nil
+		}
+		return "", exec.ErrNotFound
+	}
+	runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+		key := strings.Join(append([]string{name}, args...), " ")
+		if out, ok := outputs[key]; ok {
+			return out, nil
+		}
+		return "", errors.New("exit status 1")
+	}
+}
+
+// fingerprintCollector 实现了 Fingerprinter 的收集器
+type fingerprintCollector struct{ fakeCollector }
+
+func (f *fingerprintCollector) Fingerprint(ctx context.Context, env *Env) string {
+	return "fp " + env.WorkDir
+}
+
+// TestRunner_Fingerprint 测试只拼接实现了 Fingerprinter 的收集器的摘要
+func TestRunner_Fingerprint(t *testing.T) {
+	runner := NewRunner(0,
+		&fakeCollector{name: "tools", content: "jq"},
+		&fingerprintCollector{fakeCollector{name: "project"}},
+	)
+	if got := runner.Fingerprint(context.Background(), &Env{WorkDir: "/srv"}); got != "project: fp /srv\n" {
+		t.Errorf("Fingerprint() = %q", got)
+	}
+}
+
+// TestRunner_Run 测试按注册顺序返回结果并记录错误和超时
+func TestRunner_Run(t *testing.T) {
+	runner := NewRunner(0,
+		&fakeCollector{name: "slow", content: "late", delay: 50 * time.Millisecond},
+		&fakeCollector{name: "ok", content: " value \n"},
+		&fakeCollector{name: "fail", err: errors.New("boom")},
+		&fakeCollector{name: "hang", delay: time.Second},
+	)
+	runner.SetTimeout(200 * time.Millisecond)
+
+	sections := runner.Run(context.Background(), &Env{OS: "linux"})
+	if len(sections) != 4 {
+		t.Fatalf("期望 4 个结果, 实际为 %d", len(sections))
+	}
+	if sections[0].Name != "slow" || sections[0].Content != "late" {
+		t.Errorf("结果顺序错误: %+v", sections[0])
+	}
+	if sections[1].Content != "value" {
+		t.Errorf("期望去除首尾空白, 实际为 %q", sections[1].Content)
+	}
+	if sections[2].Err == nil {
+		t.Error("期望记录收集器错误")
+	}
+	if !errors.Is(sections[3].Err, context.DeadlineExceeded) {
+		t.Errorf("期望超时错误, 实际为 %v", sections[3].Err)
+	}
+	if runner.Budget() != DefaultBudget {
+		t.Errorf("期望默认预算 %d, 实际为 %d", DefaultBudget, runner.Budget())
+	}
+}
+
+// TestFormat 测试格式化和大小预算
+func TestFormat(t *testing.T) {
+	sections := []Section{
+		{Name: "a", Title: "Tools", Content: "jq 1.7"},
+		{Name: "b", Title: "Failed", Err: errors.New("x")},
+		{Name: "c", Title: "Empty"},
+		{Name: "d", Title: "Distro", Content: "Ubuntu 24.04"},
+	}
+
+	got := Format(sections, 1024)
+	want := "- Tools: jq 1.7\n- Distro: Ubuntu 24.04\n"
+	if got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+
+	// 预算不足时截断最后一条
+	got = Format(sections, 35)
+	if len(got) > 35 {
+		t.Errorf("输出超出预算: %d 字节", len(got))
+	}
+	if !strings.HasPrefix(got, "- Tools: jq 1.7\n- Distro") || !strings.HasSuffix(got, truncatedMark+"\n") {
+		t.Errorf("截断结果错误: %q", got)
+	}
+
+	// 多字节字符不会被截断成无效 UTF-8
+	got = Format([]Section{{Title: "工具", Content: strings.Repeat("中", 20)}}, 25)
+	if !strings.HasSuffix(got, truncatedMark+"\n") || strings.ContainsRune(got, '�') {
+		t.Errorf("截断结果错误: %q", got)
+	}
+}
+
+// TestNewRunnerFromConfig 测试配置开关
+func TestNewRunnerFromConfig(t *testing.T) {
+	cfg := &config.ContextConfig{
+		Collectors: map[string]bool{"tools": false, "distro": true},
+		MaxBytes:   256,
+	}
+
+	runner := NewRunnerFromConfig(cfg)
+	var names []string
+	for _, c := range runner.collectors {
+		names = append(names, c.Name())
+	}
+
+	if strings.Join(names, ",") != "project,distro,package_manager,userland" {
+		t.Errorf("启用的收集器 = %v", names)
+	}
+	if runner.Budget() != 256 {
+		t.Errorf("期望预算 256, 实际为 %d", runner.Budget())
+	}
+}
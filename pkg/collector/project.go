@@ -0,0 +1,321 @@
+// Package collector 提供当前项目信息的收集器
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+const (
+	// maxProjectDepth 向上查找项目标记文件的最大层数
+	maxProjectDepth = 8
+
+	// maxProjectItems 每类列表（Makefile 目标、npm 脚本等）最多列出的数量
+	maxProjectItems = 12
+)
+
+// projectMarker 描述一种项目标记文件及其解析方式
+type projectMarker struct {
+	// files 候选文件名，按优先级排列
+	files []string
+
+	// describe 解析文件并返回一行摘要，返回空字符串表示忽略
+	describe func(path string) string
+}
+
+// projectMarkers 支持识别的项目类型
+var projectMarkers = []projectMarker{
+	{files: []string{"go.mod"}, describe: describeGoMod},
+	{files: []string{"package.json"}, describe: describePackageJSON},
+	{files: []string{"Cargo.toml"}, describe: describeCargo},
+	{files: []string{"pyproject.toml", "setup.py", "requirements.txt"}, describe: describePython},
+	{files: []string{"Makefile", "makefile", "GNUmakefile"}, describe: describeMakefile},
+	{files: []string{"compose.yaml", "compose.yml", "docker-compose.yml", "docker-compose.yaml"}, describe: describeCompose},
+	{files: []string{"Dockerfile"}, describe: func(string) string { return "Dockerfile" }},
+}
+
+// ProjectCollector 从工作目录向上查找项目标记文件（go.mod、package.json、Makefile 等）和 git 状态
+// 让"运行这个包的测试"、"重新构建镜像"之类的请求生成正确的命令
+type ProjectCollector struct{}
+
+// NewProjectCollector 创建项目信息收集器
+func NewProjectCollector() *ProjectCollector {
+	return &ProjectCollector{}
+}
+
+// Name 返回收集器名称
+func (c *ProjectCollector) Name() string {
+	return "project"
+}
+
+// Collect 向上查找标记文件，每类标记使用距离工作目录最近的一个；到达 git 仓库根目录后停止
+func (c *ProjectCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	lines, gitRoot := scanProject(env.WorkDir)
+	if gitRoot != "" {
+		if state := gitState(ctx, env.WorkDir); state != "" {
+			lines = append(lines, state)
+		}
+	}
+	return i18n.T(i18n.LabelProject), strings.Join(lines, "\n"), nil
+}
+
+// Fingerprint 返回项目标记和 git 分支，用于缓存键
+// 不包含未提交修改的数量：它随每次编辑变化，计入缓存键会让缓存几乎不会命中
+func (c *ProjectCollector) Fingerprint(ctx context.Context, env *Env) string {
+	lines, gitRoot := scanProject(env.WorkDir)
+	if gitRoot != "" {
+		if branch := gitBranch(ctx, env.WorkDir); branch != "" {
+			lines = append(lines, "git branch "+branch)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// scanProject 从工作目录向上查找标记文件，返回各标记的摘要和 git 仓库根目录（不在仓库中时为空）
+func scanProject(workDir string) ([]string, string) {
+	if workDir == "" {
+		return nil, ""
+	}
+
+	var lines []string
+	found := make([]bool, len(projectMarkers))
+	gitRoot := ""
+
+	home, _ := os.UserHomeDir()
+	dir := filepath.Clean(workDir)
+	for depth := 0; depth < maxProjectDepth; depth++ {
+		for i, marker := range projectMarkers {
+			if found[i] {
+				continue
+			}
+			for _, name := range marker.files {
+				path := filepath.Join(dir, name)
+				if info, err := os.Stat(path); err != nil || info.IsDir() {
+					continue
+				}
+				found[i] = true
+				if summary := marker.describe(path); summary != "" {
+					lines = append(lines, summary+relativeSuffix(workDir, path))
+				}
+				break
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			gitRoot = dir
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir || dir == home {
+			break
+		}
+		dir = parent
+	}
+
+	return lines, gitRoot
+}
+
+// relativeSuffix 标记文件不在工作目录中时返回其相对路径，如 " (../go.mod)"
+func relativeSuffix(workDir, path string) string {
+	if filepath.Dir(path) == filepath.Clean(workDir) {
+		return ""
+	}
+	rel, err := filepath.Rel(workDir, path)
+	if err != nil {
+		return ""
+	}
+	return " (" + filepath.ToSlash(rel) + ")"
+}
+
+// describeGoMod 返回 Go 模块路径
+func describeGoMod(path string) string {
+	for _, line := range readLines(path, 50) {
+		if strings.HasPrefix(line, "module ") {
+			return "Go module " + strings.TrimSpace(strings.TrimPrefix(line, "module "))
+		}
+	}
+	return "Go module"
+}
+
+// describePackageJSON 返回 npm 包名和 scripts
+func describePackageJSON(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	var pkg struct {
+		Name    string            `json:"name"`
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return "Node.js package"
+	}
+
+	summary := "Node.js package"
+	if pkg.Name != "" {
+		summary += " " + pkg.Name
+	}
+	if len(pkg.Scripts) > 0 {
+		scripts := make([]string, 0, len(pkg.Scripts))
+		for name := range pkg.Scripts {
+			scripts = append(scripts, name)
+		}
+		sort.Strings(scripts)
+		summary += ", npm scripts: " + joinLimited(scripts)
+	}
+	return summary
+}
+
+// describeCargo 返回 Rust crate 名称或 workspace
+func describeCargo(path string) string {
+	section := ""
+	for _, line := range readLines(path, 200) {
+		if strings.HasPrefix(line, "[") {
+			section = strings.Trim(line, "[] ")
+			if section == "workspace" {
+				return "Rust workspace"
+			}
+			continue
+		}
+		if section == "package" && strings.HasPrefix(line, "name") {
+			if _, value, ok := strings.Cut(line, "="); ok {
+				return "Rust crate " + strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+	}
+	return "Rust crate"
+}
+
+// describePython 返回 Python 项目类型
+func describePython(path string) string {
+	return "Python project"
+}
+
+// makeTargetPattern 匹配 Makefile 中的目标定义（排除变量赋值 := 和模式规则）
+var makeTargetPattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9_./-]*)\s*:([^=]|$)`)
+
+// describeMakefile 返回 Makefile 中的目标
+func describeMakefile(path string) string {
+	var targets []string
+	seen := make(map[string]bool)
+	for _, line := range readLines(path, 2000) {
+		m := makeTargetPattern.FindStringSubmatch(line)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		targets = append(targets, m[1])
+	}
+
+	if len(targets) == 0 {
+		return "Makefile"
+	}
+	return "Makefile targets: " + joinLimited(targets)
+}
+
+// describeCompose 返回 docker compose 中定义的服务（只解析顶层 services 下的键）
+func describeCompose(path string) string {
+	var services []string
+	inServices := false
+	indent := -1
+	for _, line := range readLines(path, 2000) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		lineIndent := len(line) - len(strings.TrimLeft(line, " "))
+		if lineIndent == 0 {
+			inServices = strings.HasPrefix(trimmed, "services:")
+			indent = -1
+			continue
+		}
+		if !inServices {
+			continue
+		}
+		if indent < 0 {
+			indent = lineIndent
+		}
+		if lineIndent == indent && strings.HasSuffix(trimmed, ":") {
+			services = append(services, strings.Trim(strings.TrimSuffix(trimmed, ":"), `"'`))
+		}
+	}
+
+	if len(services) == 0 {
+		return "docker compose"
+	}
+	return "docker compose services: " + joinLimited(services)
+}
+
+// gitBranch 返回当前分支名，git 不可用或不在仓库中时返回空字符串
+func gitBranch(ctx context.Context, workDir string) string {
+	git, err := lookPath("git")
+	if err != nil {
+		return ""
+	}
+
+	branch, err := runCommand(ctx, git, "-C", workDir, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(branch)
+}
+
+// gitState 返回当前分支和未提交修改的数量
+func gitState(ctx context.Context, workDir string) string {
+	branch := gitBranch(ctx, workDir)
+	if branch == "" {
+		return ""
+	}
+
+	git, _ := lookPath("git")
+	status, err := runCommand(ctx, git, "-C", workDir, "status", "--porcelain")
+	if err != nil {
+		return i18n.T(i18n.LabelGitBranch, branch)
+	}
+
+	changes := 0
+	for _, line := range strings.Split(status, "\n") {
+		if strings.TrimSpace(line) != "" {
+			changes++
+		}
+	}
+	if changes == 0 {
+		return i18n.T(i18n.LabelGitClean, branch)
+	}
+	return i18n.T(i18n.LabelGitDirty, branch, changes)
+}
+
+// readLines 读取文件的前 max 行
+func readLines(path string, max int) []string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() && len(lines) < max {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+// joinLimited 用逗号连接列表，超过 maxProjectItems 时省略其余部分
+func joinLimited(items []string) string {
+	if len(items) <= maxProjectItems {
+		return strings.Join(items, ", ")
+	}
+	return strings.Join(items[:maxProjectItems], ", ") + ", " + truncatedMark
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles 在目录中创建文件
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestProjectCollector 测试向上查找标记文件和 git 状态
+func TestProjectCollector(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":        "ref: refs/heads/main\n",
+		"go.mod":           "module example.com/shop\n\ngo 1.22\n",
+		"Makefile":         "BIN := shop\n.PHONY: build test\nbuild:\n\tgo build\ntest: build\n\tgo test ./...\n%.o: %.c\n",
+		"compose.yaml":     "version: \"3\"\nservices:\n  api:\n    image: shop\n    ports:\n      - \"8080:8080\"\n  db:\n    image: postgres\nvolumes:\n  data:\n",
+		"web/package.json": `{"name":"shop-web","scripts":{"test":"vitest","build":"vite build"}}`,
+	})
+
+	stubCommands(t,
+		map[string]string{"git": "/usr/bin/git"},
+		map[string]string{
+			"/usr/bin/git -C " + filepath.Join(root, "web") + " rev-parse --abbrev-ref HEAD": "main\n",
+			"/usr/bin/git -C " + filepath.Join(root, "web") + " status --porcelain":          " M go.mod\n?? web/new.ts\n",
+		},
+	)
+
+	_, got, err := NewProjectCollector().Collect(context.Background(), &Env{WorkDir: filepath.Join(root, "web")})
+	if err != nil {
+		t.Fatalf("收集失败: %v", err)
+	}
+
+	want := []string{
+		"Node.js package shop-web, npm scripts: build, test",
+		"Go module example.com/shop (../go.mod)",
+		"Makefile targets: build, test (../Makefile)",
+		"docker compose services: api, db (../compose.yaml)",
+		"label.git_dirty",
+	}
+	if got != strings.Join(want, "\n") {
+		t.Errorf("Collect() =\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+// TestProjectCollector_Fingerprint 测试项目摘要包含标记文件和 git 分支，不包含未提交修改
+func TestProjectCollector_Fingerprint(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD": "ref: refs/heads/main\n",
+		"go.mod":    "module example.com/shop\n",
+	})
+
+	fingerprint := func(branch, status string) string {
+		stubCommands(t,
+			map[string]string{"git": "/usr/bin/git"},
+			map[string]string{
+				"/usr/bin/git -C " + root + " rev-parse --abbrev-ref HEAD": branch + "\n",
+				"/usr/bin/git -C " + root + " status --porcelain":          status,
+			},
+		)
+		return NewProjectCollector().Fingerprint(context.Background(), &Env{WorkDir: root})
+	}
+
+	clean := fingerprint("main", "")
+	if clean != "Go module example.com/shop\ngit branch main" {
+		t.Errorf("Fingerprint() = %q", clean)
+	}
+	if dirty := fingerprint("main", " M go.mod\n"); dirty != clean {
+		t.Errorf("未提交的修改不应改变摘要: %q != %q", dirty, clean)
+	}
+	if other := fingerprint("feature", ""); other == clean {
+		t.Error("切换分支后摘要应变化")
+	}
+
+	writeFiles(t, root, map[string]string{"package.json": `{"name":"shop-web"}`})
+	if withNode := fingerprint("main", ""); withNode == clean {
+		t.Error("新增标记文件后摘要应变化")
+	}
+}
+
+// TestProjectCollector_StopsAtGitRoot 测试不会越过 git 仓库根目录
+func TestProjectCollector_StopsAtGitRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Makefile":            "outer:\n",
+		"repo/.git/HEAD":      "ref: refs/heads/main\n",
+		"repo/Cargo.toml":     "[package]\nname = \"cli\"\nversion = \"0.1.0\"\n",
+		"repo/src/main.rs":    "fn main() {}\n",
+		"repo/pyproject.toml": "[project]\nname = \"x\"\n",
+	})
+	stubCommands(t, nil, nil)
+
+	_, got, _ := NewProjectCollector().Collect(context.Background(), &Env{WorkDir: filepath.Join(root, "repo", "src")})
+	want := "Rust crate cli (../Cargo.toml)\nPython project (../pyproject.toml)"
+	if got != want {
+		t.Errorf("Collect() = %q, want %q", got, want)
+	}
+}
+
+// TestDescribeMakefile_Limit 测试目标数量限制
+func TestDescribeMakefile_Limit(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < maxProjectItems+5; i++ {
+		sb.WriteString("t" + strings.Repeat("x", i) + ":\n")
+	}
+	path := filepath.Join(t.TempDir(), "Makefile")
+	writeFiles(t, filepath.Dir(path), map[string]string{"Makefile": sb.String()})
+
+	got := describeMakefile(path)
+	if !strings.HasSuffix(got, truncatedMark) || strings.Count(got, ",") != maxProjectItems {
+		t.Errorf("describeMakefile() = %q", got)
+	}
+}
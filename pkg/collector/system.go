@@ -0,0 +1,161 @@
+// Package collector 提供操作系统相关信息的收集器
+package collector
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+const (
+	osWindows = "windows"
+	osDarwin  = "darwin"
+	osLinux   = "linux"
+)
+
+// osReleasePath Linux 发行版信息文件，测试中可替换
+var osReleasePath = "/etc/os-release"
+
+// DistroCollector 检测 Linux 发行版或 macOS 版本
+type DistroCollector struct{}
+
+// NewDistroCollector 创建发行版收集器
+func NewDistroCollector() *DistroCollector {
+	return &DistroCollector{}
+}
+
+// Name 返回收集器名称
+func (c *DistroCollector) Name() string {
+	return "distro"
+}
+
+// Collect 读取 /etc/os-release（Linux）或 sw_vers（macOS）
+func (c *DistroCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	title := i18n.T(i18n.LabelDistro)
+
+	switch env.OS {
+	case osLinux:
+		info, err := parseOSRelease(osReleasePath)
+		if err != nil {
+			return title, "", err
+		}
+		if name := info["PRETTY_NAME"]; name != "" {
+			return title, name, nil
+		}
+		return title, strings.TrimSpace(info["NAME"] + " " + info["VERSION_ID"]), nil
+	case osDarwin:
+		out, err := runCommand(ctx, "sw_vers", "-productVersion")
+		if err != nil {
+			return title, "", err
+		}
+		return title, "macOS " + strings.TrimSpace(out), nil
+	}
+
+	return title, "", nil
+}
+
+// parseOSRelease 解析 os-release 格式的文件（KEY=value，值可以带引号）
+func parseOSRelease(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		info[key] = strings.Trim(value, `"'`)
+	}
+	return info, scanner.Err()
+}
+
+// packageManagers 按优先级排列的包管理器
+var packageManagers = []string{
+	"apt", "dnf", "yum", "pacman", "zypper", "apk", "emerge", "nix",
+	"brew", "port",
+	"winget", "choco", "scoop",
+}
+
+// PackageManagerCollector 检测系统中可用的包管理器
+type PackageManagerCollector struct{}
+
+// NewPackageManagerCollector 创建包管理器收集器
+func NewPackageManagerCollector() *PackageManagerCollector {
+	return &PackageManagerCollector{}
+}
+
+// Name 返回收集器名称
+func (c *PackageManagerCollector) Name() string {
+	return "package_manager"
+}
+
+// Collect 在 PATH 中查找已知的包管理器
+func (c *PackageManagerCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	var found []string
+	for _, pm := range packageManagers {
+		if _, err := lookPath(pm); err == nil {
+			found = append(found, pm)
+		}
+	}
+	return i18n.T(i18n.LabelPackageManager), strings.Join(found, ", "), nil
+}
+
+// UserlandCollector 检测 coreutils 和 sed 是 GNU、BSD 还是 BusyBox 版本
+// 两者的参数差异较大（如 sed -i、date -d、stat -c）
+type UserlandCollector struct{}
+
+// NewUserlandCollector 创建用户空间工具收集器
+func NewUserlandCollector() *UserlandCollector {
+	return &UserlandCollector{}
+}
+
+// Name 返回收集器名称
+func (c *UserlandCollector) Name() string {
+	return "userland"
+}
+
+// Collect 通过 --version 的输出判断工具来源（BSD 版本不支持 --version）
+func (c *UserlandCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	title := i18n.T(i18n.LabelUserland)
+	if env.OS == osWindows {
+		return title, "", nil
+	}
+
+	var parts []string
+	for _, tool := range []string{"ls", "sed"} {
+		if flavor := userlandFlavor(ctx, tool); flavor != "" {
+			parts = append(parts, tool+": "+flavor)
+		}
+	}
+	return title, strings.Join(parts, ", "), nil
+}
+
+// userlandFlavor 返回工具的实现类型（GNU/BusyBox/BSD），工具不存在时返回空字符串
+func userlandFlavor(ctx context.Context, tool string) string {
+	path, err := lookPath(tool)
+	if err != nil {
+		return ""
+	}
+
+	out, _ := runCommand(ctx, path, "--version")
+	switch {
+	case strings.Contains(out, "GNU"):
+		return "GNU"
+	case strings.Contains(out, "BusyBox"):
+		return "BusyBox"
+	default:
+		return "BSD"
+	}
+}
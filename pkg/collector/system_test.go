@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestDistroCollector 测试解析 /etc/os-release
+func TestDistroCollector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "os-release")
+	content := "# comment\nNAME=\"Ubuntu\"\nVERSION_ID=\"24.04\"\nPRETTY_NAME=\"Ubuntu 24.04 LTS\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := osReleasePath
+	osReleasePath = path
+	defer func() { osReleasePath = orig }()
+
+	_, got, err := NewDistroCollector().Collect(context.Background(), &Env{OS: osLinux})
+	if err != nil {
+		t.Fatalf("收集失败: %v", err)
+	}
+	if got != "Ubuntu 24.04 LTS" {
+		t.Errorf("期望 'Ubuntu 24.04 LTS', 实际为 %q", got)
+	}
+
+	// 没有 PRETTY_NAME 时使用 NAME + VERSION_ID
+	if err := os.WriteFile(path, []byte("NAME=Alpine Linux\nVERSION_ID=3.20.0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, got, _ = NewDistroCollector().Collect(context.Background(), &Env{OS: osLinux})
+	if got != "Alpine Linux 3.20.0" {
+		t.Errorf("期望 'Alpine Linux 3.20.0', 实际为 %q", got)
+	}
+
+	// 其他系统不返回内容
+	if _, got, _ := NewDistroCollector().Collect(context.Background(), &Env{OS: osWindows}); got != "" {
+		t.Errorf("windows 不应返回发行版, 实际为 %q", got)
+	}
+}
+
+// TestPackageManagerCollector 测试检测包管理器
+func TestPackageManagerCollector(t *testing.T) {
+	stubCommands(t, map[string]string{"dnf": "/usr/bin/dnf", "brew": "/opt/homebrew/bin/brew"}, nil)
+
+	_, got, err := NewPackageManagerCollector().Collect(context.Background(), &Env{OS: osLinux})
+	if err != nil {
+		t.Fatalf("收集失败: %v", err)
+	}
+	if got != "dnf, brew" {
+		t.Errorf("期望 'dnf, brew', 实际为 %q", got)
+	}
+}
+
+// TestUserlandCollector 测试区分 GNU、BSD 和 BusyBox
+func TestUserlandCollector(t *testing.T) {
+	stubCommands(t,
+		map[string]string{"ls": "/bin/ls", "sed": "/usr/bin/sed"},
+		map[string]string{"/bin/ls --version": "ls (GNU coreutils) 9.4\n"},
+	)
+
+	_, got, _ := NewUserlandCollector().Collect(context.Background(), &Env{OS: osLinux})
+	if got != "ls: GNU, sed: BSD" {
+		t.Errorf("期望 'ls: GNU, sed: BSD', 实际为 %q", got)
+	}
+
+	stubCommands(t,
+		map[string]string{"ls": "/bin/ls"},
+		map[string]string{"/bin/ls --version": "BusyBox v1.36.1 multi-call binary."},
+	)
+	_, got, _ = NewUserlandCollector().Collect(context.Background(), &Env{OS: osLinux})
+	if got != "ls: BusyBox" {
+		t.Errorf("期望 'ls: BusyBox', 实际为 %q", got)
+	}
+}
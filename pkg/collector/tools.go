@@ -0,0 +1,200 @@
+// Package collector 提供可用命令行工具的收集器
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// defaultTools 默认检测的命令行工具（模型常用但不一定安装的工具）
+var defaultTools = []string{
+	"git", "jq", "yq", "rg", "fd", "fdfind", "fzf", "bat", "eza",
+	"gsed", "gawk", "gfind", "curl", "wget",
+	"docker", "podman", "kubectl", "helm",
+	"python3", "node", "go", "make",
+}
+
+// toolVersionArgs 不支持 --version 参数的工具
+var toolVersionArgs = map[string][]string{
+	"go":      {"version"},
+	"kubectl": {"version", "--client"},
+	"helm":    {"version", "--short"},
+}
+
+// versionPattern 匹配版本号
+var versionPattern = regexp.MustCompile(`\d+\.\d+(\.\d+)?`)
+
+// ToolsCollector 检测 PATH 中可用的工具及其版本
+// 版本号按可执行文件的路径、大小和修改时间缓存到磁盘，避免每次都启动所有工具
+type ToolsCollector struct {
+	tools     []string
+	cacheFile string
+}
+
+// toolVersionEntry 是版本缓存中的一条记录
+type toolVersionEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Version string `json:"version"`
+}
+
+// NewToolsCollector 创建工具收集器，extra 为额外检测的工具
+func NewToolsCollector(extra ...string) *ToolsCollector {
+	tools := append([]string{}, defaultTools...)
+	for _, t := range extra {
+		if t = strings.TrimSpace(t); t != "" && !containsString(tools, t) {
+			tools = append(tools, t)
+		}
+	}
+
+	c := &ToolsCollector{tools: tools}
+	if dir, err := os.UserCacheDir(); err == nil {
+		c.cacheFile = filepath.Join(dir, "aicli", "tools.json")
+	}
+	return c
+}
+
+// SetCacheFile 设置版本缓存文件路径，空字符串表示不缓存
+func (c *ToolsCollector) SetCacheFile(path string) {
+	c.cacheFile = path
+}
+
+// Name 返回收集器名称
+func (c *ToolsCollector) Name() string {
+	return "tools"
+}
+
+// Collect 检测工具是否存在并读取版本号
+// 输出格式: "jq 1.7.1, rg 14.1.0; 未安装: fd, gsed"
+func (c *ToolsCollector) Collect(ctx context.Context, env *Env) (string, string, error) {
+	versions := make([]string, len(c.tools))
+	found := make([]bool, len(c.tools))
+
+	cache := c.loadCache()
+	updated := make(map[string]toolVersionEntry)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, tool := range c.tools {
+		path, err := lookPath(tool)
+		if err != nil {
+			continue
+		}
+		found[i] = true
+
+		info, statErr := os.Stat(path)
+		if statErr == nil {
+			if entry, ok := cache[path]; ok && entry.Size == info.Size() && entry.ModTime == info.ModTime().UnixNano() {
+				versions[i] = entry.Version
+				continue
+			}
+		}
+
+		wg.Add(1)
+		go func(i int, tool, path string) {
+			defer wg.Done()
+			versions[i] = toolVersion(ctx, tool, path)
+			if statErr == nil && ctx.Err() == nil {
+				mu.Lock()
+				updated[path] = toolVersionEntry{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Version: versions[i]}
+				mu.Unlock()
+			}
+		}(i, tool, path)
+	}
+	wg.Wait()
+
+	if len(updated) > 0 {
+		for path, entry := range updated {
+			cache[path] = entry
+		}
+		c.saveCache(cache)
+	}
+
+	var available, missing []string
+	for i, tool := range c.tools {
+		switch {
+		case !found[i]:
+			missing = append(missing, tool)
+		case versions[i] != "":
+			available = append(available, tool+" "+versions[i])
+		default:
+			available = append(available, tool)
+		}
+	}
+
+	content := strings.Join(available, ", ")
+	if len(missing) > 0 {
+		if content != "" {
+			content += "; "
+		}
+		content += i18n.T(i18n.LabelToolsMissing) + ": " + strings.Join(missing, ", ")
+	}
+
+	return i18n.T(i18n.LabelTools), content, nil
+}
+
+// loadCache 读取版本缓存，文件不存在或损坏时返回空缓存
+func (c *ToolsCollector) loadCache() map[string]toolVersionEntry {
+	cache := make(map[string]toolVersionEntry)
+	if c.cacheFile == "" {
+		return cache
+	}
+
+	data, err := os.ReadFile(c.cacheFile)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(map[string]toolVersionEntry)
+	}
+	return cache
+}
+
+// saveCache 保存版本缓存，失败时忽略（下次重新检测）
+func (c *ToolsCollector) saveCache(cache map[string]toolVersionEntry) {
+	if c.cacheFile == "" {
+		return
+	}
+
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(c.cacheFile), 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.cacheFile, data, 0600)
+}
+
+// toolVersion 运行工具的版本命令并提取版本号，失败时返回空字符串
+func toolVersion(ctx context.Context, tool, path string) string {
+	args, ok := toolVersionArgs[tool]
+	if !ok {
+		args = []string{"--version"}
+	}
+
+	out, err := runCommand(ctx, path, args...)
+	if err != nil && out == "" {
+		return ""
+	}
+
+	firstLine := strings.SplitN(strings.TrimSpace(out), "\n", 2)[0]
+	return versionPattern.FindString(firstLine)
+}
+
+// containsString 判断切片中是否包含指定字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestToolsCollector 测试工具检测、版本解析和版本缓存
+func TestToolsCollector(t *testing.T) {
+	dir := t.TempDir()
+	jqPath := filepath.Join(dir, "jq")
+	goPath := filepath.Join(dir, "go")
+	for _, p := range []string{jqPath, goPath} {
+		if err := os.WriteFile(p, []byte("#!/bin/sh\n"), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stubCommands(t,
+		map[string]string{"jq": jqPath, "go": goPath, "mytool": "/nonexistent/mytool"},
+		map[string]string{
+			jqPath + " --version": "jq-1.7.1\n",
+			goPath + " version":   "go version go1.22.3 linux/amd64\n",
+		},
+	)
+
+	c := &ToolsCollector{tools: []string{"jq", "rg", "go", "mytool"}}
+	c.SetCacheFile(filepath.Join(dir, "cache", "tools.json"))
+
+	_, got, err := c.Collect(context.Background(), &Env{OS: osLinux})
+	if err != nil {
+		t.Fatalf("收集失败: %v", err)
+	}
+	// i18n 未初始化时标签返回键名
+	want := "jq 1.7.1, go 1.22.3, mytool; label.tools_missing: rg"
+	if got != want {
+		t.Errorf("Collect() = %q, want %q", got, want)
+	}
+
+	// 第二次从缓存读取版本，不再运行命令
+	runs := 0
+	origRun := runCommand
+	runCommand = func(ctx context.Context, name string, args ...string) (string, error) {
+		runs++
+		return origRun(ctx, name, args...)
+	}
+
+	_, got2, _ := c.Collect(context.Background(), &Env{OS: osLinux})
+	if got2 != want {
+		t.Errorf("缓存结果 = %q, want %q", got2, want)
+	}
+	if runs != 1 {
+		// mytool 无法 stat，不会被缓存
+		t.Errorf("期望只运行 1 次版本命令, 实际为 %d", runs)
+	}
+}
+
+// TestNewToolsCollector_Extra 测试额外工具去重
+func TestNewToolsCollector_Extra(t *testing.T) {
+	c := NewToolsCollector("jq", " terraform ", "")
+	if strings.Count(strings.Join(c.tools, ","), "jq") != 1 {
+		t.Error("额外工具不应重复")
+	}
+	if c.tools[len(c.tools)-1] != "terraform" {
+		t.Errorf("期望追加 terraform, 实际为 %v", c.tools)
+	}
+}
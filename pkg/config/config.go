@@ -6,14 +6,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 )
 
 const (
 	providerLocal   = "local"
 	providerBuiltin = "builtin"
+	providerAzure   = "azure"
 )
 
+// azureAPIVersionPattern Azure OpenAI API 版本格式（如 2024-06-01 或 2024-08-01-preview）
+var azureAPIVersionPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(-preview)?$`)
+
 // Config 是应用程序的主配置结构体
 type Config struct {
 	Version   string          `json:"version"`
@@ -23,6 +28,12 @@ type Config struct {
 	Safety    SafetyConfig    `json:"safety"`
 	History   HistoryConfig   `json:"history"`
 	Logging   LoggingConfig   `json:"logging"`
+	Cache     CacheConfig     `json:"cache"`
+	Prompt    PromptConfig    `json:"prompt"`
+	Context   ContextConfig   `json:"context"`
+
+	// Pricing 模型单价表，键为模型名称或 "提供商/模型名称"，用于计算请求费用
+	Pricing map[string]ModelPrice `json:"pricing,omitempty"`
 }
 
 // LLMConfig 包含 LLM 服务的配置
@@ -33,14 +44,64 @@ type LLMConfig struct {
 	Model     string `json:"model"`      // 模型名称
 	Timeout   int    `json:"timeout"`    // 超时时间（秒）
 	MaxTokens int    `json:"max_tokens"` // 最大 token 数
+
+	Deployment string `json:"deployment,omitempty"`  // Azure OpenAI 部署名称
+	APIVersion string `json:"api_version,omitempty"` // Azure OpenAI API 版本（如 2024-06-01）
+
+	// DisableTools 不使用工具调用（function calling）返回命令，用于不支持 tools 参数的 OpenAI 兼容服务
+	DisableTools bool `json:"disable_tools,omitempty"`
+
+	MaxRetries   int `json:"max_retries"`   // 429/5xx/网络错误时的最大重试次数（负数表示不重试）
+	RetryBackoff int `json:"retry_backoff"` // 重试的初始退避时间（毫秒），每次重试翻倍
+
+	// Fallbacks 备用提供商，主提供商连接失败、超时、返回 5xx 或空响应时按顺序尝试
+	Fallbacks []LLMConfig `json:"fallbacks,omitempty"`
+}
+
+// Chain 返回按顺序尝试的提供商配置（主提供商在前，随后是备用提供商）
+// 备用提供商未设置超时和重试参数时继承主提供商的配置
+func (l *LLMConfig) Chain() []LLMConfig {
+	chain := make([]LLMConfig, 0, len(l.Fallbacks)+1)
+
+	primary := *l
+	primary.Fallbacks = nil
+	chain = append(chain, primary)
+
+	for _, fb := range l.Fallbacks {
+		fb.Fallbacks = nil
+		if fb.Timeout <= 0 {
+			fb.Timeout = l.Timeout
+		}
+		if fb.MaxRetries == 0 {
+			fb.MaxRetries = l.MaxRetries
+		}
+		if fb.RetryBackoff == 0 {
+			fb.RetryBackoff = l.RetryBackoff
+		}
+		chain = append(chain, fb)
+	}
+
+	return chain
+}
+
+// TotalTimeout 返回依次尝试所有提供商所需的总超时时间（秒）
+func (l *LLMConfig) TotalTimeout() int {
+	total := 0
+	for _, entry := range l.Chain() {
+		total += entry.Timeout
+	}
+	return total
 }
 
 // ExecutionConfig 包含命令执行的配置
 type ExecutionConfig struct {
 	AutoConfirm   bool   `json:"auto_confirm"`    // 是否自动确认命令
 	DryRunDefault bool   `json:"dry_run_default"` // 默认是否只显示命令不执行
-	Timeout       int    `json:"timeout"`         // 命令执行超时（秒）
-	Shell         string `json:"shell"`           // Shell 类型 (auto, bash, zsh, powershell, cmd)
+	Timeout       int    `json:"timeout"`         // 命令执行超时（秒），0 表示不限制
+	Shell         string `json:"shell"`           // Shell 类型 (auto, bash, zsh, sh, fish, nu, powershell, cmd) 或可执行文件路径
+
+	// FixAttempts 命令失败时请求 LLM 修正的最大次数，负数表示禁用
+	FixAttempts int `json:"fix_attempts"`
 }
 
 // SafetyConfig 包含安全检查的配置
@@ -57,23 +118,78 @@ type HistoryConfig struct {
 	File       string `json:"file"`        // 历史记录文件路径
 }
 
+// CacheConfig 包含命令转换结果缓存的配置
+type CacheConfig struct {
+	Enabled       *bool  `json:"enabled,omitempty"` // 是否启用缓存（未配置时启用，使用 IsEnabled 读取）
+	Dir           string `json:"dir"`               // 缓存目录（空表示用户缓存目录下的 aicli/translations）
+	TTL           int    `json:"ttl"`               // 缓存有效期（秒）
+	MaxEntries    int    `json:"max_entries"`       // 最大缓存条目数
+	IgnoreWorkDir bool   `json:"ignore_work_dir"`   // 缓存键是否忽略工作目录
+}
+
+// IsEnabled 返回是否启用缓存，配置文件中没有 cache.enabled 时默认启用
+func (c CacheConfig) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// PromptConfig 包含自定义提示词模板的配置
+type PromptConfig struct {
+	System string `json:"system,omitempty"` // 系统提示词模板文件路径（text/template 语法，空表示使用内置提示词）
+	User   string `json:"user,omitempty"`   // 用户提示词模板文件路径（空表示使用内置提示词）
+}
+
+// ContextConfig 包含执行环境信息收集器的配置
+type ContextConfig struct {
+	// Collectors 收集器开关（tools, distro, package_manager, userland），未列出的收集器默认启用
+	Collectors map[string]bool `json:"collectors,omitempty"`
+	MaxBytes   int             `json:"max_bytes"`             // 写入提示词的最大字节数
+	ExtraTools []string        `json:"extra_tools,omitempty"` // tools 收集器额外检测的工具
+	StdinBytes int             `json:"stdin_bytes"`           // 标准输入写入提示词的最大字节数，超出时只发送结构摘要
+}
+
+// CollectorEnabled 返回指定名称的收集器是否启用
+func (c *ContextConfig) CollectorEnabled(name string) bool {
+	enabled, ok := c.Collectors[name]
+	return !ok || enabled
+}
+
+// ModelPrice 表示一个模型的 token 单价（每百万 token 的价格，货币单位由用户决定）
+type ModelPrice struct {
+	Input  float64 `json:"input"`  // 每百万输入 token 的价格
+	Output float64 `json:"output"` // 每百万输出 token 的价格
+}
+
+// Cost 计算指定 token 数的费用
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Input + float64(completionTokens)*p.Output) / 1e6
+}
+
+// PriceFor 查找模型单价，优先匹配 "提供商/模型名称"，其次匹配模型名称
+func (c *Config) PriceFor(provider, model string) (ModelPrice, bool) {
+	if model == "" {
+		return ModelPrice{}, false
+	}
+	if price, ok := c.Pricing[provider+"/"+model]; ok {
+		return price, true
+	}
+	price, ok := c.Pricing[model]
+	return price, ok
+}
+
 // LoggingConfig 包含日志的配置
 type LoggingConfig struct {
 	Enabled bool   `json:"enabled"` // 是否启用日志
 	Level   string `json:"level"`   // 日志级别 (debug, info, warn, error)
-	File    string `json:"file"`    // 日志文件路径（空表示标准输出）
+	File    string `json:"file"`    // 日志文件路径（空表示标准错误）
 }
 
 // Load 从指定路径加载配置文件
 // 如果文件不存在，返回默认配置
 func Load(path string) (*Config, error) {
 	// 展开 ~ 路径
-	if strings.HasPrefix(path, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("获取用户主目录失败: %w", err)
-		}
-		path = filepath.Join(homeDir, path[2:])
+	path, err := ExpandPath(path)
+	if err != nil {
+		return nil, err
 	}
 
 	// 如果文件不存在，返回默认配置
@@ -109,15 +225,25 @@ func Load(path string) (*Config, error) {
 	return &config, nil
 }
 
+// ExpandPath 将以 ~/ 开头的路径展开为用户主目录下的路径
+func ExpandPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	return filepath.Join(homeDir, path[2:]), nil
+}
+
 // Save 保存配置到指定路径
 func (c *Config) Save(path string) error {
 	// 展开 ~ 路径
-	if strings.HasPrefix(path, "~/") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("获取用户主目录失败: %w", err)
-		}
-		path = filepath.Join(homeDir, path[2:])
+	path, err := ExpandPath(path)
+	if err != nil {
+		return err
 	}
 
 	// 序列化为 JSON
@@ -150,13 +276,62 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("LLM 模型不能为空")
 	}
 
+	if err := c.LLM.validateAzure(); err != nil {
+		return err
+	}
+
 	// 验证超时配置
 	if c.LLM.Timeout <= 0 {
 		return fmt.Errorf("LLM 超时时间必须大于 0")
 	}
 
-	if c.Execution.Timeout <= 0 {
-		return fmt.Errorf("命令执行超时时间必须大于 0")
+	if c.Execution.Timeout < 0 {
+		return fmt.Errorf("命令执行超时时间不能为负数")
+	}
+
+	// 验证模型单价
+	for model, price := range c.Pricing {
+		if price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("模型 %s 的单价不能为负数", model)
+		}
+	}
+
+	// 验证备用提供商
+	for i, fb := range c.LLM.Fallbacks {
+		if fb.Provider == "" {
+			return fmt.Errorf("备用 LLM 提供商 #%d 的名称不能为空", i+1)
+		}
+		if fb.Provider != providerLocal && fb.Provider != providerBuiltin && fb.APIKey == "" {
+			return fmt.Errorf("备用 LLM 提供商 #%d (%s) 的 API 密钥不能为空", i+1, fb.Provider)
+		}
+		if err := fb.validateAzure(); err != nil {
+			return fmt.Errorf("备用 LLM 提供商 #%d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// validateAzure 验证 Azure OpenAI 特有的配置
+func (l *LLMConfig) validateAzure() error {
+	if l.Provider != providerAzure {
+		return nil
+	}
+
+	if l.APIBase == "" {
+		return fmt.Errorf("azure OpenAI 需要配置资源地址 api_base（如 https://my-resource.openai.azure.com）")
+	}
+	if !strings.HasPrefix(l.APIBase, "https://") {
+		return fmt.Errorf("azure OpenAI 资源地址 api_base 必须以 https:// 开头: %s", l.APIBase)
+	}
+	if strings.Contains(l.APIBase, "://your-resource.") {
+		return fmt.Errorf("azure OpenAI 资源地址 api_base 仍是示例地址，请改为实际的资源地址: %s", l.APIBase)
+	}
+	if l.Deployment == "" {
+		return fmt.Errorf("azure OpenAI 需要配置部署名称 deployment")
+	}
+	if l.APIVersion != "" && !azureAPIVersionPattern.MatchString(l.APIVersion) {
+		return fmt.Errorf("azure OpenAI api_version 格式无效（应类似 2024-06-01）: %s", l.APIVersion)
 	}
 
 	return nil
@@ -180,14 +355,20 @@ func (c *Config) applyDefaults() {
 	if c.LLM.APIBase == "" {
 		c.LLM.APIBase = defaults.LLM.APIBase
 	}
-
-	// Execution 默认值
-	if c.Execution.Timeout == 0 {
-		c.Execution.Timeout = defaults.Execution.Timeout
+	if c.LLM.MaxRetries == 0 {
+		c.LLM.MaxRetries = defaults.LLM.MaxRetries
 	}
+	if c.LLM.RetryBackoff == 0 {
+		c.LLM.RetryBackoff = defaults.LLM.RetryBackoff
+	}
+
+	// Execution 默认值（Timeout 为 0 表示不限制，不需要填充）
 	if c.Execution.Shell == "" {
 		c.Execution.Shell = defaults.Execution.Shell
 	}
+	if c.Execution.FixAttempts == 0 {
+		c.Execution.FixAttempts = defaults.Execution.FixAttempts
+	}
 
 	// History 默认值
 	if c.History.MaxEntries == 0 {
@@ -197,6 +378,22 @@ func (c *Config) applyDefaults() {
 		c.History.File = defaults.History.File
 	}
 
+	// Cache 默认值
+	if c.Cache.TTL == 0 {
+		c.Cache.TTL = defaults.Cache.TTL
+	}
+	if c.Cache.MaxEntries == 0 {
+		c.Cache.MaxEntries = defaults.Cache.MaxEntries
+	}
+
+	// Context 默认值
+	if c.Context.MaxBytes == 0 {
+		c.Context.MaxBytes = defaults.Context.MaxBytes
+	}
+	if c.Context.StdinBytes == 0 {
+		c.Context.StdinBytes = defaults.Context.StdinBytes
+	}
+
 	// Logging 默认值
 	if c.Logging.Level == "" {
 		c.Logging.Level = defaults.Logging.Level
@@ -26,8 +26,8 @@ func TestDefault(t *testing.T) {
 		t.Errorf("期望 LLM 超时为 30, 实际为 %d", cfg.LLM.Timeout)
 	}
 
-	if cfg.Execution.Timeout != 30 {
-		t.Errorf("期望执行超时为 30, 实际为 %d", cfg.Execution.Timeout)
+	if cfg.Execution.Timeout != 0 {
+		t.Errorf("期望默认不限制执行时长, 实际为 %d", cfg.Execution.Timeout)
 	}
 
 	if !cfg.Safety.EnableChecks {
@@ -37,6 +37,41 @@ func TestDefault(t *testing.T) {
 	if !cfg.History.Enabled {
 		t.Error("期望历史记录默认启用")
 	}
+
+	if !cfg.Cache.IsEnabled() {
+		t.Error("期望缓存默认启用")
+	}
+}
+
+// TestLoadCacheEnabled 测试配置文件中没有 cache.enabled 时缓存与 Default() 一致地启用
+func TestLoadCacheEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"没有 cache 配置", `{"llm": {"provider": "builtin", "model": "m"}}`, true},
+		{"没有 enabled", `{"llm": {"provider": "builtin", "model": "m"}, "cache": {"ttl": 60}}`, true},
+		{"显式启用", `{"llm": {"provider": "builtin", "model": "m"}, "cache": {"enabled": true}}`, true},
+		{"显式禁用", `{"llm": {"provider": "builtin", "model": "m"}, "cache": {"enabled": false}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("创建测试配置文件失败: %v", err)
+			}
+
+			cfg, err := Load(configPath)
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if got := cfg.Cache.IsEnabled(); got != tt.want {
+				t.Errorf("Cache.IsEnabled() = %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
 }
 
 func TestLoadNonExistentFile(t *testing.T) {
@@ -248,6 +283,152 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "执行超时为 0 表示不限制",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider: "openai",
+					APIKey:   "test-key",
+					Model:    "gpt-4",
+					Timeout:  10,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "执行超时为负数应该无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider: "openai",
+					APIKey:   "test-key",
+					Model:    "gpt-4",
+					Timeout:  10,
+				},
+				Execution: ExecutionConfig{
+					Timeout: -1,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Azure 配置有效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:   "azure",
+					APIKey:     "test-key",
+					APIBase:    "https://my-resource.openai.azure.com",
+					Model:      "gpt-4o",
+					Deployment: "gpt-4o-prod",
+					APIVersion: "2024-08-01-preview",
+					Timeout:    10,
+				},
+				Execution: ExecutionConfig{
+					Timeout: 30,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "Azure 缺少部署名称应该无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider: "azure",
+					APIKey:   "test-key",
+					APIBase:  "https://my-resource.openai.azure.com",
+					Model:    "gpt-4o",
+					Timeout:  10,
+				},
+				Execution: ExecutionConfig{
+					Timeout: 30,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Azure 资源地址不是 https 应该无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:   "azure",
+					APIKey:     "test-key",
+					APIBase:    "http://my-resource.openai.azure.com",
+					Model:      "gpt-4o",
+					Deployment: "gpt-4o-prod",
+					Timeout:    10,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Azure 资源地址仍是示例地址应该无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:   "azure",
+					APIKey:     "test-key",
+					APIBase:    "https://your-resource.openai.azure.com",
+					Model:      "gpt-4o",
+					Deployment: "gpt-4o-prod",
+					Timeout:    10,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Azure API 版本格式无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:   "azure",
+					APIKey:     "test-key",
+					APIBase:    "https://my-resource.openai.azure.com",
+					Model:      "gpt-4o",
+					Deployment: "gpt-4o-prod",
+					APIVersion: "latest",
+					Timeout:    10,
+				},
+				Execution: ExecutionConfig{
+					Timeout: 30,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "备用提供商缺少 API 密钥应该无效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:  "local",
+					Model:     "llama2",
+					Timeout:   10,
+					Fallbacks: []LLMConfig{{Provider: "openai"}},
+				},
+				Execution: ExecutionConfig{
+					Timeout: 30,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "备用提供商配置有效",
+			config: &Config{
+				Version: "1.0",
+				LLM: LLMConfig{
+					Provider:  "local",
+					Model:     "llama2",
+					Timeout:   10,
+					Fallbacks: []LLMConfig{{Provider: "openai", APIKey: "test-key"}, {Provider: "builtin"}},
+				},
+				Execution: ExecutionConfig{
+					Timeout: 30,
+				},
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -320,7 +501,68 @@ func TestApplyDefaults(t *testing.T) {
 		t.Errorf("期望默认 MaxTokens 为 500, 实际为 %d", cfg.LLM.MaxTokens)
 	}
 
-	if cfg.Execution.Timeout != 30 {
-		t.Errorf("期望默认执行超时为 30, 实际为 %d", cfg.Execution.Timeout)
+	if cfg.Execution.Timeout != 0 {
+		t.Errorf("未配置执行超时时应保持 0（不限制）, 实际为 %d", cfg.Execution.Timeout)
+	}
+}
+
+// TestLLMConfigChain 测试提供商链和总超时
+func TestLLMConfigChain(t *testing.T) {
+	llmCfg := LLMConfig{
+		Provider: "local",
+		Timeout:  10,
+		Fallbacks: []LLMConfig{
+			{Provider: "openai", APIKey: "test-key", Timeout: 20},
+			{Provider: "builtin"},
+		},
+	}
+
+	chain := llmCfg.Chain()
+	if len(chain) != 3 {
+		t.Fatalf("Chain() length = %d, want 3", len(chain))
+	}
+	if chain[0].Provider != "local" || chain[1].Provider != "openai" || chain[2].Provider != "builtin" {
+		t.Errorf("Chain() order = [%s %s %s], want [local openai builtin]", chain[0].Provider, chain[1].Provider, chain[2].Provider)
+	}
+	if chain[0].Fallbacks != nil {
+		t.Error("Chain() entries should not contain nested fallbacks")
+	}
+	if chain[2].Timeout != 10 {
+		t.Errorf("fallback timeout = %d, want inherited 10", chain[2].Timeout)
+	}
+	if got := llmCfg.TotalTimeout(); got != 40 {
+		t.Errorf("TotalTimeout() = %d, want 40", got)
+	}
+}
+
+// TestPriceFor 测试模型单价查找与费用计算
+func TestPriceFor(t *testing.T) {
+	cfg := Default()
+	cfg.Pricing = map[string]ModelPrice{
+		"gpt-4o-mini":       {Input: 0.15, Output: 0.6},
+		"azure/gpt-4o-mini": {Input: 0.2, Output: 0.8},
+	}
+
+	price, ok := cfg.PriceFor("openai", "gpt-4o-mini")
+	if !ok || price.Input != 0.15 {
+		t.Errorf("PriceFor(openai) = %+v, %v", price, ok)
+	}
+	if price, ok = cfg.PriceFor("azure", "gpt-4o-mini"); !ok || price.Input != 0.2 {
+		t.Errorf("PriceFor(azure) 应优先匹配提供商前缀, got %+v", price)
+	}
+	if _, ok = cfg.PriceFor("openai", "unknown"); ok {
+		t.Error("未配置的模型不应找到单价")
+	}
+
+	if got := (ModelPrice{Input: 0.15, Output: 0.6}).Cost(1000000, 500000); got != 0.45 {
+		t.Errorf("Cost() = %v, want 0.45", got)
+	}
+
+	cfg.LLM.Provider = "openai"
+	cfg.LLM.APIKey = "test-key"
+	cfg.LLM.Model = "gpt-4o-mini"
+	cfg.Pricing["bad"] = ModelPrice{Input: -1}
+	if err := cfg.Validate(); err == nil {
+		t.Error("负数单价应验证失败")
 	}
 }
@@ -11,12 +11,16 @@ func Default() *Config {
 			Model:     "",
 			Timeout:   30, // 增加超时时间以适应网络延迟
 			MaxTokens: 500,
+
+			MaxRetries:   2,   // 429/5xx/网络错误时重试 2 次
+			RetryBackoff: 500, // 初始退避 500 毫秒，每次翻倍
 		},
 		Execution: ExecutionConfig{
 			AutoConfirm:   false,
 			DryRunDefault: false,
-			Timeout:       30,
+			Timeout:       0, // 不限制执行时长，长时间运行的构建、同步命令不会被中断
 			Shell:         "auto",
+			FixAttempts:   2, // 命令失败时最多请求 2 次修正
 		},
 		Safety: SafetyConfig{
 			EnableChecks:        true,
@@ -33,6 +37,17 @@ func Default() *Config {
 			Level:   "info",
 			File:    "",
 		},
+		Cache: CacheConfig{
+			Enabled:       nil, // 未配置时启用
+			Dir:           "",
+			TTL:           7 * 24 * 3600, // 7 天
+			MaxEntries:    1000,
+			IgnoreWorkDir: false,
+		},
+		Context: ContextConfig{
+			MaxBytes:   1024,
+			StdinBytes: 2048,
+		},
 	}
 }
 
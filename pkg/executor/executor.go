@@ -3,16 +3,139 @@ package executor
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 )
 
+// DefaultKillGrace 命令超时后从发送 SIGTERM 到发送 SIGKILL 的默认宽限时间
+const DefaultKillGrace = 3 * time.Second
+
+// timeoutExitCode 命令超时被终止时的退出码（与 timeout(1) 一致）
+const timeoutExitCode = 124
+
+// ErrTimeout 表示命令执行超时被终止，可通过 errors.Is 判断
+var ErrTimeout = errors.New("command timed out")
+
+// ErrInterrupted 表示命令被中断信号（如 Ctrl-C）终止，可通过 errors.Is 判断
+var ErrInterrupted = errors.New("command interrupted")
+
+// InterruptSignals 中断 aicli 的信号；命令执行期间收到时转发给命令的整个进程组
+var InterruptSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP}
+
 // Executor 负责执行 shell 命令
 type Executor struct {
 	shell *ShellAdapter
+
+	// timeout 命令执行超时，0 表示不限制
+	timeout time.Duration
+
+	// killGrace 超时后从 SIGTERM 到 SIGKILL 的宽限时间
+	killGrace time.Duration
+}
+
+// ExecResult 表示一次命令执行的结果
+type ExecResult struct {
+	// Stdout 命令的标准输出
+	Stdout string
+
+	// Stderr 命令的标准错误输出
+	Stderr string
+
+	// ExitCode 命令退出码；被信号终止时为 128+信号值（与 shell 的约定一致），
+	// 超时被终止时为 124（与 timeout(1) 一致）
+	ExitCode int
+
+	// Signal 终止命令的信号名称（如 "killed"），正常退出时为空
+	Signal string
+
+	// StartTime 命令开始执行的时间
+	StartTime time.Time
+
+	// Duration 命令执行耗时
+	Duration time.Duration
+
+	// TimedOut 命令是否因超时被终止
+	TimedOut bool
+
+	// Interrupted 命令是否被中断信号（Ctrl-C、SIGTERM、SIGHUP）终止
+	Interrupted bool
+
+	// Timeout 本次执行的超时时间（0 表示不限制）
+	Timeout time.Duration
+}
+
+// Success 返回命令是否以退出码 0 正常结束
+func (r *ExecResult) Success() bool {
+	return r.ExitCode == 0 && r.Signal == "" && !r.TimedOut && !r.Interrupted
+}
+
+// Output 返回命令输出：stdout 为空时使用 stderr（某些命令会将正常信息输出到 stderr）
+func (r *ExecResult) Output() string {
+	if r.Stdout == "" {
+		return r.Stderr
+	}
+	return r.Stdout
+}
+
+// Err 命令没有成功结束时返回 *ExitError，否则返回 nil
+func (r *ExecResult) Err() error {
+	if r.Success() {
+		return nil
+	}
+	err := &ExitError{Code: r.ExitCode, Signal: r.Signal, Stderr: r.Stderr, Interrupted: r.Interrupted}
+	if r.TimedOut {
+		err.Timeout = r.Timeout
+	}
+	return err
+}
+
+// ExitError 表示命令已执行但以非零退出码结束、被信号终止或超时
+type ExitError struct {
+	// Code 命令退出码
+	Code int
+
+	// Signal 终止命令的信号名称（正常退出时为空）
+	Signal string
+
+	// Stderr 命令的标准错误输出
+	Stderr string
+
+	// Timeout 命令超时被终止时为超时时间，否则为 0
+	Timeout time.Duration
+
+	// Interrupted 命令是否被中断信号终止
+	Interrupted bool
+}
+
+func (e *ExitError) Error() string {
+	if e.Timeout > 0 {
+		return fmt.Sprintf("timed out after %s", e.Timeout)
+	}
+	if e.Signal != "" {
+		return "signal: " + e.Signal
+	}
+	if e.Interrupted {
+		return "interrupted"
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Is 使超时和被中断的 ExitError 分别满足 errors.Is(err, ErrTimeout) 和 errors.Is(err, ErrInterrupted)
+func (e *ExitError) Is(target error) bool {
+	switch target {
+	case ErrTimeout:
+		return e.Timeout > 0
+	case ErrInterrupted:
+		return e.Interrupted
+	}
+	return false
 }
 
 // NewExecutor 创建一个新的 Executor 实例
@@ -28,75 +151,44 @@ func NewExecutor() *Executor {
 	}
 
 	return &Executor{
-		shell: shell,
+		shell:     shell,
+		killGrace: DefaultKillGrace,
 	}
 }
 
-// Execute 执行命令并返回输出
-// command: 要执行的命令字符串
-// stdin: 标准输入数据（可选）
-// 返回: 命令输出和错误
-func (e *Executor) Execute(command string, stdin string) (string, error) {
-	if command == "" {
-		return "", fmt.Errorf("命令不能为空")
-	}
-
-	// 构建命令
-	args := make([]string, len(e.shell.Args))
-	copy(args, e.shell.Args)
-	args = append(args, command)
-	cmd := exec.Command(e.shell.Path, args...)
-
-	// 设置标准输入
-	if stdin != "" {
-		cmd.Stdin = strings.NewReader(stdin)
-	}
-
-	// 捕获输出
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+// SetShell 设置执行命令使用的 Shell（见 NewShell）
+func (e *Executor) SetShell(shell *ShellAdapter) {
+	e.shell = shell
+}
 
-	// 执行命令
-	err := cmd.Run()
-
-	// 获取输出
-	output := stdout.String()
-	errOutput := stderr.String()
-
-	// 注意：我们不将非零退出码视为错误，因为很多命令（如 pkill、grep 等）
-	// 在某些情况下返回非零退出码是正常行为。我们只在命令无法执行时返回错误。
-	// 非零退出码的情况下，仍然返回 stdout 和 stderr 的内容。
-	// 如果 stderr 有内容，将其合并到输出中（某些命令会将正常信息输出到 stderr）
-	if errOutput != "" {
-		// 如果 stdout 为空，使用 stderr 的内容
-		if output == "" {
-			output = errOutput
-		}
-	}
+// SetTimeout 设置命令执行超时，超时后终止命令的整个进程组；0 表示不限制
+func (e *Executor) SetTimeout(timeout time.Duration) {
+	e.timeout = timeout
+}
 
-	// 只有在命令无法执行时才返回错误（如命令不存在）
-	// 非零退出码不应该被视为错误
-	_ = err // 忽略退出码错误
+// SetKillGrace 设置超时后从 SIGTERM 到 SIGKILL 的宽限时间
+func (e *Executor) SetKillGrace(grace time.Duration) {
+	e.killGrace = grace
+}
 
-	return output, nil
+// Execute 执行命令并捕获输出
+// command: 要执行的命令字符串
+// stdin: 标准输入数据（可选）
+// 返回: 执行结果；非零退出码记录在结果中，只有命令无法启动时才返回错误
+func (e *Executor) Execute(command string, stdin string) (*ExecResult, error) {
+	return e.run(command, stdin, e.shell, nil, nil)
 }
 
 // ExecuteInteractive 以交互模式执行命令，实时显示输出
 // command: 要执行的命令字符串
-// stdin: 标准输入数据（可选）
-// 返回: 错误信息（输出会直接打印到终端）
-func (e *Executor) ExecuteInteractive(command string, stdin string) error {
+// stdin: 标准输入数据（可选，为空时连接到当前进程的标准输入）
+// 返回: 执行结果（不捕获输出，输出会直接打印到终端）和错误
+func (e *Executor) ExecuteInteractive(command string, stdin string) (*ExecResult, error) {
 	if command == "" {
-		return fmt.Errorf("命令不能为空")
+		return nil, fmt.Errorf("命令不能为空")
 	}
 
-	// 构建命令
-	args := make([]string, len(e.shell.Args))
-	copy(args, e.shell.Args)
-	args = append(args, command)
-	cmd := exec.Command(e.shell.Path, args...)
+	cmd := e.shell.command(command)
 
 	// 设置标准输入
 	if stdin != "" {
@@ -109,64 +201,17 @@ func (e *Executor) ExecuteInteractive(command string, stdin string) error {
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	// 执行命令
-	// 注意：我们不将非零退出码视为错误，因为很多命令在某些情况下
-	// 返回非零退出码是正常行为（如 pkill 没找到进程、grep 没匹配到内容等）
-	err := cmd.Run()
-	// 忽略退出码错误，只在命令无法执行时返回错误
-	_ = err
-	return nil
+	result := &ExecResult{}
+	return result, e.wait(cmd, newProcGroup(cmd), result)
 }
 
 // ExecuteWithOutput 执行命令并同时返回输出和实时显示
 // command: 要执行的命令字符串
 // stdin: 标准输入数据（可选）
-// 返回: 命令输出和错误
-func (e *Executor) ExecuteWithOutput(command string, stdin string) (string, error) {
-	if command == "" {
-		return "", fmt.Errorf("命令不能为空")
-	}
-
-	// 构建命令
-	args := make([]string, len(e.shell.Args))
-	copy(args, e.shell.Args)
-	args = append(args, command)
-	cmd := exec.Command(e.shell.Path, args...)
-
-	// 设置标准输入
-	if stdin != "" {
-		cmd.Stdin = strings.NewReader(stdin)
-	}
-
-	// 创建输出缓冲区
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-
-	// 使用 MultiWriter 同时写入缓冲区和终端
-	// 这样既能捕获输出用于返回,又能实时显示到终端
-	cmd.Stdout = io.MultiWriter(&stdout, os.Stdout)
-	cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
-
-	// 执行命令
-	err := cmd.Run()
-
-	// 获取输出
-	output := stdout.String()
-	errOutput := stderr.String()
-
-	// 注意：我们不将非零退出码视为错误，因为很多命令（如 pkill、grep 等）
-	// 在某些情况下返回非零退出码是正常行为。
-	// 如果 stderr 有内容，将其合并到输出中
-	if errOutput != "" {
-		if output == "" {
-			output = errOutput
-		}
-	}
-
-	// 只有在命令无法执行时才返回错误，非零退出码不视为错误
-	_ = err
-
-	return output, nil
+// 返回: 执行结果；非零退出码记录在结果中，只有命令无法启动时才返回错误
+func (e *Executor) ExecuteWithOutput(command string, stdin string) (*ExecResult, error) {
+	// 同时写入缓冲区和终端，既能捕获输出用于历史记录，又能实时显示
+	return e.run(command, stdin, e.shell, os.Stdout, os.Stderr)
 }
 
 // GetShell 返回当前使用的 Shell 信息
@@ -174,21 +219,21 @@ func (e *Executor) GetShell() *ShellAdapter {
 	return e.shell
 }
 
-// ExecuteWithContext 使用自定义 Shell 执行命令（高级功能）
-func (e *Executor) ExecuteWithContext(command string, stdin string, shell *ShellAdapter) (string, error) {
+// ExecuteWithContext 使用自定义 Shell 执行命令并捕获输出（高级功能）
+func (e *Executor) ExecuteWithContext(command string, stdin string, shell *ShellAdapter) (*ExecResult, error) {
 	if shell == nil {
-		return e.Execute(command, stdin)
+		shell = e.shell
 	}
+	return e.run(command, stdin, shell, nil, nil)
+}
 
+// run 使用指定的 Shell 执行命令，捕获 stdout 和 stderr，并在 echoOut/echoErr 非空时同时写入
+func (e *Executor) run(command string, stdin string, shell *ShellAdapter, echoOut, echoErr io.Writer) (*ExecResult, error) {
 	if command == "" {
-		return "", fmt.Errorf("命令不能为空")
+		return nil, fmt.Errorf("命令不能为空")
 	}
 
-	// 构建命令
-	args := make([]string, len(shell.Args))
-	copy(args, shell.Args)
-	args = append(args, command)
-	cmd := exec.Command(shell.Path, args...)
+	cmd := shell.command(command)
 
 	// 设置标准输入
 	if stdin != "" {
@@ -200,25 +245,123 @@ func (e *Executor) ExecuteWithContext(command string, stdin string, shell *Shell
 	var stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	if echoOut != nil {
+		cmd.Stdout = io.MultiWriter(&stdout, echoOut)
+	}
+	if echoErr != nil {
+		cmd.Stderr = io.MultiWriter(&stderr, echoErr)
+	}
 
-	// 执行命令
-	err := cmd.Run()
+	result := &ExecResult{}
+	err := e.wait(cmd, newProcGroup(cmd), result)
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
 
-	// 获取输出
-	output := stdout.String()
-	errOutput := stderr.String()
+	return result, err
+}
+
+// wait 在 group 管理的独立进程组中启动 cmd 并等待其结束，结果写入 result
+// 执行期间 aicli 收到的中断信号（见 InterruptSignals）转发给整个进程组；
+// 超过 e.timeout 时向整个进程组发送 SIGTERM，宽限时间后仍未结束则发送 SIGKILL，
+// 这样命令派生的子进程也会一并终止，不会因为继续占用输出管道而使 aicli 挂起
+func (e *Executor) wait(cmd *exec.Cmd, group *procGroup, result *ExecResult) error {
+	defer group.release()
+
+	result.Timeout = e.timeout
+	result.StartTime = time.Now()
+	if err := cmd.Start(); err != nil {
+		result.ExitCode = -1
+		return err
+	}
 
-	// 注意：我们不将非零退出码视为错误，因为很多命令在某些情况下
-	// 返回非零退出码是正常行为。
-	// 如果 stderr 有内容，将其合并到输出中
-	if errOutput != "" {
-		if output == "" {
-			output = errOutput
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, InterruptSignals...)
+	defer signal.Stop(signals)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	var timedOut bool
+	var interrupt syscall.Signal
+	go func() {
+		defer close(stopped)
+
+		var deadline, kill <-chan time.Time
+		if e.timeout > 0 {
+			timer := time.NewTimer(e.timeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		for {
+			select {
+			case <-done:
+				// 命令已结束时也清理进程组中忽略 SIGTERM 的残留进程
+				if timedOut {
+					_ = group.signal(syscall.SIGKILL)
+				}
+				return
+			case sig := <-signals:
+				if s, ok := sig.(syscall.Signal); ok {
+					interrupt = s
+					_ = group.signal(s)
+				}
+			case <-deadline:
+				timedOut = true
+				_ = group.signal(syscall.SIGTERM)
+				kill = time.After(e.killGrace)
+			case <-kill:
+				_ = group.signal(syscall.SIGKILL)
+				kill = nil
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	close(done)
+	<-stopped
+	result.Duration = time.Since(result.StartTime)
+
+	err = collectExit(result, err)
+	switch {
+	case timedOut:
+		result.TimedOut = true
+		result.ExitCode = timeoutExitCode
+	case interrupt != 0 || isInterruptSignal(result.Signal):
+		// 终端直接把 Ctrl-C 发给前台的命令进程组时，aicli 本身收不到信号，根据终止命令的信号判断
+		result.Interrupted = true
+		if result.ExitCode == 0 {
+			result.ExitCode = 128 + int(interrupt)
+		}
+	}
+	return err
+}
+
+// isInterruptSignal 返回终止命令的信号名称是否属于 InterruptSignals
+func isInterruptSignal(name string) bool {
+	for _, sig := range InterruptSignals {
+		if name != "" && sig.String() == name {
+			return true
 		}
 	}
+	return false
+}
 
-	// 只有在命令无法执行时才返回错误，非零退出码不视为错误
-	_ = err
+// collectExit 把 cmd.Run 的错误转换为结果中的退出码和信号
+// 非零退出码不视为错误（很多命令如 pkill、grep 在某些情况下返回非零退出码是正常行为），
+// 由调用方通过 ExecResult.Success 判断；只有命令无法启动时才返回错误
+func collectExit(result *ExecResult, err error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		if err != nil {
+			result.ExitCode = -1
+		}
+		return err
+	}
 
-	return output, nil
+	result.ExitCode = exitErr.ExitCode()
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Signal = status.Signal().String()
+		result.ExitCode = 128 + int(status.Signal())
+	}
+	return nil
 }
@@ -1,9 +1,11 @@
 package executor
 
 import (
+	"errors"
 	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestDetectShell(t *testing.T) {
@@ -99,6 +101,9 @@ func TestDetectUnixShell(t *testing.T) {
 		{"Zsh 大写", "/usr/bin/ZSH", ShellZsh},
 		{"Bash 大写", "/BIN/BASH", ShellBash},
 		{"其他 Shell", "/bin/ksh", ShellSh},
+		{"Fish Shell", "/usr/local/bin/fish", ShellFish},
+		{"Nushell", "/home/user/.cargo/bin/nu", ShellNushell},
+		{"名称包含 nu 的其他 Shell", "/usr/bin/gnu-sh", ShellSh},
 	}
 
 	for _, tt := range tests {
@@ -112,11 +117,8 @@ func TestDetectUnixShell(t *testing.T) {
 				t.Errorf("期望类型 %s, 实际为 %s", tt.expected, shell.Type)
 			}
 
-			// 路径会被转换为小写，所以比较时应该忽略大小写
-			expectedPath := strings.ToLower(tt.path)
-			actualPath := strings.ToLower(shell.Path)
-			if actualPath != expectedPath {
-				t.Errorf("期望路径 %s, 实际为 %s", expectedPath, actualPath)
+			if shell.Path != tt.path {
+				t.Errorf("期望路径 %s, 实际为 %s", tt.path, shell.Path)
 			}
 		})
 	}
@@ -130,6 +132,8 @@ func TestShellTypes(t *testing.T) {
 		ShellPowerShell,
 		ShellCmd,
 		ShellSh,
+		ShellFish,
+		ShellNushell,
 	}
 
 	for _, st := range types {
@@ -163,10 +167,10 @@ func TestDetectShellCrossPlatform(t *testing.T) {
 		if len(shell.Args) != 2 || shell.Args[0] != "-i" || shell.Args[1] != "-c" {
 			t.Errorf("Bash/Zsh 参数应为 ['-i', '-c'], 实际为 %v", shell.Args)
 		}
-	case ShellSh:
-		// sh 不使用交互模式
+	case ShellSh, ShellFish, ShellNushell:
+		// sh、fish 和 nushell 不使用交互模式
 		if len(shell.Args) != 1 || shell.Args[0] != "-c" {
-			t.Errorf("sh 参数应为 ['-c'], 实际为 %v", shell.Args)
+			t.Errorf("%s 参数应为 ['-c'], 实际为 %v", shell.Type, shell.Args)
 		}
 	case ShellPowerShell:
 		if len(shell.Args) < 2 {
@@ -179,6 +183,40 @@ func TestDetectShellCrossPlatform(t *testing.T) {
 	}
 }
 
+// TestNewShell 测试按配置的名称或路径选择 Shell
+func TestNewShell(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("跳过 Windows 测试，因为 Shell 不同")
+	}
+
+	shell, err := NewShell("sh")
+	if err != nil {
+		t.Fatalf("NewShell(sh) 失败: %v", err)
+	}
+	if shell.Type != ShellSh || len(shell.Args) != 1 || shell.Args[0] != "-c" {
+		t.Errorf("NewShell(sh) = %+v", shell)
+	}
+
+	// 可执行文件路径
+	if shell, err := NewShell("/bin/sh"); err != nil || shell.Path != "/bin/sh" || shell.Type != ShellSh {
+		t.Errorf("NewShell(/bin/sh) = %+v, %v", shell, err)
+	}
+
+	for _, name := range []string{"tcsh-unknown", "/nonexistent/bin/fish"} {
+		if _, err := NewShell(name); err == nil {
+			t.Errorf("NewShell(%q) 应该返回错误", name)
+		}
+	}
+
+	// 使用选择的 Shell 执行命令
+	exec := NewExecutor()
+	exec.SetShell(shell)
+	result, err := exec.Execute("echo $0", "")
+	if err != nil || !strings.Contains(result.Stdout, "sh") {
+		t.Errorf("Execute() = %+v, %v", result, err)
+	}
+}
+
 // TestExecutor_Execute_Success 测试成功执行命令
 func TestExecutor_Execute_Success(t *testing.T) {
 	executor := NewExecutor()
@@ -187,13 +225,16 @@ func TestExecutor_Execute_Success(t *testing.T) {
 	const cmdHello = "echo hello"
 	cmd := cmdHello
 
-	output, err := executor.Execute(cmd, "")
+	result, err := executor.Execute(cmd, "")
 	if err != nil {
 		t.Fatalf("执行命令失败: %v", err)
 	}
 
-	if !strings.Contains(output, "hello") {
-		t.Errorf("期望输出包含 'hello', 实际为: %s", output)
+	if !strings.Contains(result.Stdout, "hello") {
+		t.Errorf("期望输出包含 'hello', 实际为: %s", result.Stdout)
+	}
+	if !result.Success() || result.ExitCode != 0 {
+		t.Errorf("期望命令成功, 实际退出码为 %d", result.ExitCode)
 	}
 }
 
@@ -212,13 +253,13 @@ func TestExecutor_Execute_WithStdin(t *testing.T) {
 	}
 
 	stdin := "test input data\nline 2\nline 3"
-	output, err := executor.Execute(cmd, stdin)
+	result, err := executor.Execute(cmd, stdin)
 	if err != nil {
 		t.Fatalf("执行命令失败: %v", err)
 	}
 
-	if !strings.Contains(output, "test input data") {
-		t.Errorf("期望输出包含 'test input data', 实际为: %s", output)
+	if !strings.Contains(result.Stdout, "test input data") {
+		t.Errorf("期望输出包含 'test input data', 实际为: %s", result.Stdout)
 	}
 }
 
@@ -226,18 +267,17 @@ func TestExecutor_Execute_WithStdin(t *testing.T) {
 func TestExecutor_Execute_CommandFailed(t *testing.T) {
 	executor := NewExecutor()
 
-	// 执行不存在的命令
-	// 注意：现在非零退出码不再返回错误，所以这个测试验证命令执行后的输出
-	output, err := executor.Execute("nonexistent-command-12345", "")
+	// 执行不存在的命令：Shell 可以启动，因此不返回错误，失败记录在退出码中
+	result, err := executor.Execute("nonexistent-command-12345", "")
 	if err != nil {
-		t.Logf("命令执行返回错误: %v", err)
+		t.Fatalf("Shell 启动失败: %v", err)
 	}
 
-	// 命令不存在时，stderr 会有错误信息，应该被合并到 output 中
-	if output == "" {
-		t.Log("命令不存在，输出为空（这是正常的）")
-	} else {
-		t.Logf("输出: %s", output)
+	if result.Success() || result.ExitCode == 0 {
+		t.Errorf("期望非零退出码, 实际为 %d", result.ExitCode)
+	}
+	if result.Err() == nil {
+		t.Error("失败的命令 Err() 应返回 *ExitError")
 	}
 }
 
@@ -280,19 +320,22 @@ func TestExecutor_Execute_IgnoreStderr(t *testing.T) {
 	// 这个命令会输出 "stdout output" 到 stdout，输出 "stderr output" 到 stderr
 	cmd := "echo stdout output; echo stderr output >&2"
 
-	output, err := executor.Execute(cmd, "")
+	result, err := executor.Execute(cmd, "")
 	if err != nil {
 		t.Fatalf("执行命令失败: %v", err)
 	}
 
-	// 验证 stdout 包含期望的内容
-	if !strings.Contains(output, "stdout output") {
-		t.Errorf("期望输出包含 'stdout output', 实际为: %q", output)
+	// 验证 stdout 和 stderr 分别捕获
+	if !strings.Contains(result.Stdout, "stdout output") {
+		t.Errorf("期望 stdout 包含 'stdout output', 实际为: %q", result.Stdout)
+	}
+	if !strings.Contains(result.Stderr, "stderr output") {
+		t.Errorf("期望 stderr 包含 'stderr output', 实际为: %q", result.Stderr)
 	}
 
-	// 验证 stderr 不包含在 output 中
-	if strings.Contains(output, "stderr output") {
-		t.Errorf("期望输出不包含 'stderr output', 实际为: %q", output)
+	// 验证 stderr 不包含在 Output() 中
+	if strings.Contains(result.Output(), "stderr output") {
+		t.Errorf("期望输出不包含 'stderr output', 实际为: %q", result.Output())
 	}
 }
 
@@ -307,14 +350,150 @@ func TestExecutor_Execute_IncludeStderrOnError(t *testing.T) {
 	// 这个命令会输出 "stderr output" 到 stderr，并以非零状态退出
 	cmd := "echo stderr output >&2; exit 1"
 
-	output, err := executor.Execute(cmd, "")
-	// 现在非零退出码不再返回错误
+	result, err := executor.Execute(cmd, "")
+	// 非零退出码不返回错误，记录在结果中
 	if err != nil {
 		t.Errorf("不应该返回错误，但返回了: %v", err)
 	}
+	if result.ExitCode != 1 {
+		t.Errorf("期望退出码为 1, 实际为 %d", result.ExitCode)
+	}
 
 	// 验证 stderr 内容被合并到输出中（因为 stdout 为空）
-	if !strings.Contains(output, "stderr output") {
-		t.Errorf("期望输出包含 'stderr output', 实际为: %q", output)
+	if !strings.Contains(result.Output(), "stderr output") {
+		t.Errorf("期望输出包含 'stderr output', 实际为: %q", result.Output())
+	}
+}
+
+// TestExecutor_ExecuteWithOutput_ExitCode 测试非零退出码、分离的输出和耗时记录在结果中
+func TestExecutor_ExecuteWithOutput_ExitCode(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("跳过 Windows 测试，因为命令语法不同")
+	}
+
+	executor := NewExecutor()
+
+	result, err := executor.ExecuteWithOutput("echo partial; echo boom >&2; exit 3", "")
+	if err != nil {
+		t.Fatalf("非零退出码不应返回错误: %v", err)
+	}
+	if result.ExitCode != 3 || result.Success() {
+		t.Errorf("期望退出码为 3, 实际为 %d", result.ExitCode)
+	}
+	if !strings.Contains(result.Stderr, "boom") || strings.Contains(result.Stdout, "boom") {
+		t.Errorf("stdout/stderr 未分离: stdout=%q stderr=%q", result.Stdout, result.Stderr)
+	}
+	if !strings.Contains(result.Stdout, "partial") {
+		t.Errorf("期望输出包含 'partial', 实际为: %q", result.Stdout)
+	}
+	if result.StartTime.IsZero() || result.Duration <= 0 {
+		t.Errorf("期望记录开始时间和耗时: %+v", result)
+	}
+
+	var exitErr *ExitError
+	if !errors.As(result.Err(), &exitErr) || exitErr.Code != 3 || !strings.Contains(exitErr.Stderr, "boom") {
+		t.Errorf("Err() = %v, 期望 *ExitError", result.Err())
+	}
+
+	result, err = executor.ExecuteWithOutput("true", "")
+	if err != nil || !result.Success() || result.Err() != nil {
+		t.Errorf("成功的命令不应返回错误: %v, %+v", err, result)
+	}
+}
+
+// TestExecutor_Execute_Signal 测试被信号终止的命令记录信号和 128+N 退出码
+func TestExecutor_Execute_Signal(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("Windows 不支持信号")
+	}
+
+	result, err := NewExecutor().Execute("kill -9 $$", "")
+	if err != nil {
+		t.Fatalf("执行命令失败: %v", err)
+	}
+	if result.Signal != "killed" || result.ExitCode != 128+9 {
+		t.Errorf("期望被 SIGKILL 终止 (137), 实际 signal=%q code=%d", result.Signal, result.ExitCode)
+	}
+	if result.Err() == nil || result.Err().Error() != "signal: killed" {
+		t.Errorf("Err() = %v", result.Err())
+	}
+}
+
+// TestExecutor_Execute_Timeout 测试超时后终止整个进程组（包括仍占用输出管道的子进程）
+func TestExecutor_Execute_Timeout(t *testing.T) {
+	if runtime.GOOS == osWindows {
+		t.Skip("Windows 不支持进程组信号")
+	}
+
+	// 使用非交互的 sh，避免加载 shell 配置文件的耗时影响超时判断
+	sh := &ShellAdapter{Type: ShellSh, Path: "/bin/sh", Args: []string{"-c"}}
+	exec := NewExecutor()
+	exec.SetTimeout(300 * time.Millisecond)
+	exec.SetKillGrace(200 * time.Millisecond)
+
+	tests := []struct {
+		name    string
+		command string
+	}{
+		{"子进程占用输出", "sleep 5 & sleep 5; wait"},
+		{"忽略 SIGTERM", "trap '' TERM; sleep 5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			result, err := exec.ExecuteWithContext(tt.command, "", sh)
+			if err != nil {
+				t.Fatalf("执行命令失败: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("超时后未及时终止，耗时 %v", elapsed)
+			}
+			if !result.TimedOut || result.Success() || result.ExitCode != 124 {
+				t.Errorf("期望超时结果, 实际 %+v", result)
+			}
+			if !errors.Is(result.Err(), ErrTimeout) {
+				t.Errorf("Err() 应满足 errors.Is(ErrTimeout), 实际 %v", result.Err())
+			}
+		})
+	}
+
+	// 未超时的命令不受影响
+	result, err := exec.ExecuteWithContext("echo ok", "", sh)
+	if err != nil || !result.Success() || result.TimedOut {
+		t.Errorf("未超时的命令应该成功: %+v, %v", result, err)
+	}
+}
+
+// TestNeedsTTY 测试识别需要终端的交互式命令
+func TestNeedsTTY(t *testing.T) {
+	tests := []struct {
+		command string
+		want    bool
+	}{
+		{"vim main.go", true},
+		{"/usr/bin/less README.md", true},
+		{"sudo htop", true},
+		{"TERM=xterm top -d 1", true},
+		{"cat app.log | less", true},
+		{"cd src && nvim .", true},
+		{"git add -p", true},
+		{"git rebase -i HEAD~3", true},
+		{"git commit", true},
+		{"git log --oneline", true},
+		{"ls -la", false},
+		{"grep -r vim .", false},
+		{"echo less", false},
+		{"git status", false},
+		{"git commit -am 'fix typo'", false},
+		{"git add -A", false},
+		{"git rebase main", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := NeedsTTY(tt.command); got != tt.want {
+			t.Errorf("NeedsTTY(%q) = %v, 期望 %v", tt.command, got, tt.want)
+		}
 	}
 }
@@ -0,0 +1,29 @@
+//go:build !unix
+
+package executor
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// procGroup 在不支持进程组的平台上只管理命令进程本身
+type procGroup struct {
+	cmd *exec.Cmd
+}
+
+// newProcGroup 返回命令的进程管理器
+func newProcGroup(cmd *exec.Cmd) *procGroup {
+	return &procGroup{cmd: cmd}
+}
+
+// signal 无法向进程组发送信号，只能结束命令进程本身
+func (g *procGroup) signal(sig syscall.Signal) error {
+	if g.cmd.Process == nil {
+		return nil
+	}
+	return g.cmd.Process.Kill()
+}
+
+// release 不需要恢复终端状态
+func (g *procGroup) release() {}
@@ -0,0 +1,73 @@
+//go:build unix
+
+package executor
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// procGroup 让命令在独立的进程组中运行，以便向命令派生的整个进程树发送信号
+type procGroup struct {
+	cmd *exec.Cmd
+
+	// tty aicli 位于终端前台时打开的控制终端，命令运行期间前台进程组交给命令
+	tty *os.File
+}
+
+// newProcGroup 在 cmd 启动前设置进程组属性
+// aicli 位于终端前台时，命令的进程组同时成为前台进程组，
+// 否则交互式 shell（bash -i）和读取 /dev/tty 的命令（如 sudo）会因位于后台而被 SIGTTIN 挂起
+func newProcGroup(cmd *exec.Cmd) *procGroup {
+	g := &procGroup{cmd: cmd}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return g
+	}
+	pgrp, err := unix.IoctlGetInt(int(tty.Fd()), unix.TIOCGPGRP)
+	if err != nil || pgrp != syscall.Getpgrp() {
+		_ = tty.Close()
+		return g
+	}
+
+	g.tty = tty
+	cmd.SysProcAttr.Foreground = true
+	cmd.SysProcAttr.Ctty = int(tty.Fd())
+	return g
+}
+
+// newSessionGroup 让命令在新的会话中运行，并以 cmd.Stdin（伪终端的从设备）作为控制终端
+// 命令成为会话和进程组的首进程，伪终端的前台进程组就是命令本身
+func newSessionGroup(cmd *exec.Cmd) *procGroup {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	return &procGroup{cmd: cmd}
+}
+
+// signal 向命令的整个进程组发送信号
+func (g *procGroup) signal(sig syscall.Signal) error {
+	if g.cmd.Process == nil {
+		return nil
+	}
+	return syscall.Kill(-g.cmd.Process.Pid, sig)
+}
+
+// release 命令结束后把终端前台进程组还给 aicli
+func (g *procGroup) release() {
+	if g.tty == nil {
+		return
+	}
+
+	// aicli 此时位于后台，设置前台进程组会收到 SIGTTOU，调用期间忽略该信号
+	signal.Ignore(syscall.SIGTTOU)
+	_ = unix.IoctlSetPointerInt(int(g.tty.Fd()), unix.TIOCSPGRP, syscall.Getpgrp())
+	signal.Reset(syscall.SIGTTOU)
+
+	_ = g.tty.Close()
+	g.tty = nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package executor
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestExecutor_Execute_ForwardSignal 测试执行期间收到的中断信号转发给命令的进程组
+func TestExecutor_Execute_ForwardSignal(t *testing.T) {
+	sh := &ShellAdapter{Type: ShellSh, Path: "/bin/sh", Args: []string{"-c"}}
+	exec := NewExecutor()
+
+	go func() {
+		// 等待命令启动并注册信号转发
+		time.Sleep(500 * time.Millisecond)
+		_ = syscall.Kill(os.Getpid(), syscall.SIGHUP)
+	}()
+
+	start := time.Now()
+	result, err := exec.ExecuteWithContext("sleep 5 & sleep 5; wait", "", sh)
+	if err != nil {
+		t.Fatalf("执行命令失败: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("信号未转发给命令，耗时 %v", elapsed)
+	}
+	if !result.Interrupted || result.Success() || result.ExitCode != 128+int(syscall.SIGHUP) {
+		t.Errorf("期望被中断的结果, 实际 %+v", result)
+	}
+	if !errors.Is(result.Err(), ErrInterrupted) {
+		t.Errorf("Err() 应满足 errors.Is(ErrInterrupted), 实际 %v", result.Err())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package executor
+
+// ExecuteTTY 在不支持伪终端的平台上直接连接当前终端执行命令（不捕获输出）
+func (e *Executor) ExecuteTTY(command string) (*ExecResult, error) {
+	return e.ExecuteInteractive(command, "")
+}
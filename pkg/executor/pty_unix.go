@@ -0,0 +1,113 @@
+//go:build unix
+
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"golang.org/x/term"
+)
+
+// ptyDrainTimeout 命令结束后等待伪终端剩余输出的最长时间
+// （命令留在后台的子进程仍可能持有伪终端）
+const ptyDrainTimeout = 200 * time.Millisecond
+
+// ExecuteTTY 在伪终端中执行命令，适用于 vim、less、htop 等需要终端的交互式程序
+// 当前终端切换到原始模式，键盘输入转发给命令，窗口大小变化同步到伪终端；
+// 输出在显示的同时被捕获（伪终端合并了 stdout 和 stderr，结果中的 Stderr 为空）
+func (e *Executor) ExecuteTTY(command string) (*ExecResult, error) {
+	if command == "" {
+		return nil, fmt.Errorf("命令不能为空")
+	}
+
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer ptmx.Close()
+
+	cmd := e.shell.command(command)
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+
+	// 伪终端使用当前终端的窗口大小，并在窗口大小变化时同步
+	_ = pty.InheritSize(os.Stdin, ptmx)
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	defer func() {
+		signal.Stop(winch)
+		close(winch)
+	}()
+	go func() {
+		for range winch {
+			_ = pty.InheritSize(os.Stdin, ptmx)
+		}
+	}()
+
+	// 当前终端切换到原始模式，按键（包括 Ctrl-C）原样交给伪终端处理
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		if state, err := term.MakeRaw(fd); err == nil {
+			defer func() { _ = term.Restore(fd, state) }()
+		}
+	}
+
+	// 转发键盘输入；命令结束后停止读取，避免吞掉之后确认提示的输入
+	if input, stop := openInput(); input != nil {
+		defer stop()
+		go func() { _, _ = io.Copy(ptmx, input) }()
+	}
+
+	// 显示输出的同时捕获，用于历史记录
+	var output bytes.Buffer
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		_, _ = io.Copy(io.MultiWriter(os.Stdout, &output), ptmx)
+	}()
+
+	result := &ExecResult{}
+	err = e.wait(cmd, newSessionGroup(cmd), result)
+
+	// 关闭父进程持有的从设备，所有持有者退出后读取主设备会结束
+	_ = tty.Close()
+	select {
+	case <-drained:
+	case <-time.After(ptyDrainTimeout):
+	}
+
+	result.Stdout = strings.ReplaceAll(output.String(), "\r\n", "\n")
+	return result, err
+}
+
+// openInput 以非阻塞方式复制标准输入，返回的 stop 会中断进行中的读取并恢复标准输入的阻塞模式
+// 标准输入不可用时返回 nil
+func openInput() (*os.File, func()) {
+	stdinFd := int(os.Stdin.Fd())
+	fd, err := syscall.Dup(stdinFd)
+	if err != nil {
+		return nil, nil
+	}
+
+	// 非阻塞的文件描述符由 Go 的轮询器管理，才能通过读取截止时间中断读取
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		_ = syscall.Close(fd)
+		return nil, nil
+	}
+	input := os.NewFile(uintptr(fd), "stdin")
+
+	return input, func() {
+		_ = input.SetReadDeadline(time.Now())
+		_ = input.Close()
+		// O_NONBLOCK 作用于与标准输入共享的打开文件，需要恢复
+		_ = syscall.SetNonblock(stdinFd, false)
+	}
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package executor
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestExecutor_ExecuteTTY 测试命令在伪终端中执行，同时捕获输出和退出码
+func TestExecutor_ExecuteTTY(t *testing.T) {
+	exec := NewExecutor()
+	exec.SetShell(&ShellAdapter{Type: ShellSh, Path: "/bin/sh", Args: []string{"-c"}})
+
+	result, err := exec.ExecuteTTY("test -t 0 && test -t 1 && echo tty; echo line2; exit 3")
+	if err != nil {
+		t.Fatalf("执行命令失败: %v", err)
+	}
+	if result.Stdout != "tty\nline2\n" {
+		t.Errorf("期望捕获伪终端输出, 实际 %q", result.Stdout)
+	}
+	if result.ExitCode != 3 || result.Success() {
+		t.Errorf("期望退出码 3, 实际 %+v", result)
+	}
+	if !strings.Contains(result.Output(), "line2") {
+		t.Errorf("Output() = %q", result.Output())
+	}
+
+	if _, err := exec.ExecuteTTY(""); err == nil {
+		t.Error("空命令应该返回错误")
+	}
+}
@@ -4,6 +4,8 @@ package executor
 import (
 	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"runtime"
 	"strings"
 )
@@ -32,8 +34,41 @@ const (
 
 	// ShellSh 表示 POSIX sh
 	ShellSh ShellType = "sh"
+
+	// ShellFish 表示 fish Shell
+	ShellFish ShellType = "fish"
+
+	// ShellNushell 表示 Nushell
+	ShellNushell ShellType = "nushell"
 )
 
+// ShellAuto 表示自动检测 Shell（execution.shell 的默认值）
+const ShellAuto = "auto"
+
+// shellNames 是 execution.shell 和 --shell 可以使用的 Shell 名称
+var shellNames = map[string]ShellType{
+	"bash":       ShellBash,
+	"zsh":        ShellZsh,
+	"sh":         ShellSh,
+	"fish":       ShellFish,
+	"nu":         ShellNushell,
+	"nushell":    ShellNushell,
+	"powershell": ShellPowerShell,
+	"pwsh":       ShellPowerShell,
+	"cmd":        ShellCmd,
+}
+
+// shellExecutables 是各类型 Shell 在 PATH 中依次查找的可执行文件名
+var shellExecutables = map[ShellType][]string{
+	ShellBash:       {"bash"},
+	ShellZsh:        {"zsh"},
+	ShellSh:         {"sh"},
+	ShellFish:       {"fish"},
+	ShellNushell:    {"nu"},
+	ShellPowerShell: {"pwsh", "powershell"},
+	ShellCmd:        {"cmd"},
+}
+
 // ShellAdapter 表示 Shell 适配器
 type ShellAdapter struct {
 	// Type Shell 类型
@@ -64,32 +99,83 @@ func DetectShell() (*ShellAdapter, error) {
 	}
 }
 
-// detectUnixShell 从路径检测 Unix Shell 类型
-func detectUnixShell(shellPath string) (*ShellAdapter, error) {
-	shellPath = strings.ToLower(shellPath)
+// NewShell 根据名称（如 "bash"、"fish"、"nu"）或可执行文件路径创建 ShellAdapter，
+// 对应 execution.shell 配置和 --shell 参数；名称在 PATH 中查找对应的可执行文件
+func NewShell(name string) (*ShellAdapter, error) {
+	name = strings.TrimSpace(name)
 
-	if strings.Contains(shellPath, "zsh") {
-		return &ShellAdapter{
-			Type: ShellZsh,
-			Path: shellPath,
-			Args: []string{"-i", "-c"},
-		}, nil
+	// 可执行文件路径
+	if strings.ContainsAny(name, `/\`) {
+		if _, err := os.Stat(name); err != nil {
+			return nil, fmt.Errorf("未找到 Shell: %s", name)
+		}
+		return newShellAdapter(shellTypeFromPath(name), name), nil
 	}
 
-	if strings.Contains(shellPath, "bash") {
-		return &ShellAdapter{
-			Type: ShellBash,
-			Path: shellPath,
-			Args: []string{"-i", "-c"},
-		}, nil
+	shellType, ok := shellNames[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 Shell: %s", name)
+	}
+	for _, executable := range shellExecutables[shellType] {
+		if path, err := exec.LookPath(executable); err == nil {
+			return newShellAdapter(shellType, path), nil
+		}
 	}
 
-	// 默认使用 sh
+	return nil, fmt.Errorf("未找到 Shell: %s", name)
+}
+
+// newShellAdapter 创建指定类型的 ShellAdapter
+func newShellAdapter(shellType ShellType, path string) *ShellAdapter {
 	return &ShellAdapter{
-		Type: ShellSh,
-		Path: shellPath,
-		Args: []string{"-c"},
-	}, nil
+		Type: shellType,
+		Path: path,
+		Args: shellArgs(shellType),
+	}
+}
+
+// shellArgs 返回在该类型 Shell 中执行一条命令的参数
+func shellArgs(shellType ShellType) []string {
+	switch shellType {
+	case ShellBash, ShellZsh:
+		// zsh 和 bash 使用交互模式以加载配置文件(如别名)
+		return []string{"-i", "-c"}
+	case ShellPowerShell:
+		return []string{"-NoProfile", "-Command"}
+	case ShellCmd:
+		return []string{"/C"}
+	default:
+		// sh、fish（-c 时仍会加载 config.fish）和 nushell
+		return []string{"-c"}
+	}
+}
+
+// shellTypeFromPath 根据可执行文件名判断 Shell 类型，无法识别时按 POSIX sh 处理
+func shellTypeFromPath(shellPath string) ShellType {
+	name := strings.ToLower(filepath.Base(strings.ReplaceAll(shellPath, `\`, "/")))
+	name = strings.TrimSuffix(name, ".exe")
+
+	switch {
+	case strings.Contains(name, "zsh"):
+		return ShellZsh
+	case strings.Contains(name, "bash"):
+		return ShellBash
+	case name == "fish":
+		return ShellFish
+	case name == "nu":
+		return ShellNushell
+	case name == "pwsh" || name == "powershell":
+		return ShellPowerShell
+	case name == "cmd":
+		return ShellCmd
+	default:
+		return ShellSh
+	}
+}
+
+// detectUnixShell 从路径检测 Unix Shell 类型
+func detectUnixShell(shellPath string) (*ShellAdapter, error) {
+	return newShellAdapter(shellTypeFromPath(shellPath), shellPath), nil
 }
 
 // detectUnixShellDefault 检测 Unix 系统的默认 Shell
@@ -108,16 +194,7 @@ func detectUnixShellDefault() (*ShellAdapter, error) {
 
 	for _, shell := range shells {
 		if _, err := os.Stat(shell.path); err == nil {
-			args := []string{"-c"}
-			// zsh 和 bash 使用交互模式以加载配置文件(如别名)
-			if shell.shellType == ShellZsh || shell.shellType == ShellBash {
-				args = []string{"-i", "-c"}
-			}
-			return &ShellAdapter{
-				Type: shell.shellType,
-				Path: shell.path,
-				Args: args,
-			}, nil
+			return newShellAdapter(shell.shellType, shell.path), nil
 		}
 	}
 
@@ -180,3 +257,11 @@ func (s *ShellAdapter) String() string {
 func (s *ShellAdapter) GetShellType() string {
 	return string(s.Type)
 }
+
+// command 构建在该 Shell 中执行 command 的 *exec.Cmd
+func (s *ShellAdapter) command(command string) *exec.Cmd {
+	args := make([]string, len(s.Args), len(s.Args)+1)
+	copy(args, s.Args)
+	args = append(args, command)
+	return exec.Command(s.Path, args...)
+}
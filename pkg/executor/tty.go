@@ -0,0 +1,96 @@
+// 本文件检测需要终端的交互式命令（编辑器、分页器等）
+
+package executor
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// ttyPrograms 是需要终端才能正常工作的全屏或交互式程序
+var ttyPrograms = map[string]bool{
+	"vi": true, "vim": true, "nvim": true, "nano": true, "emacs": true, "micro": true,
+	"less": true, "more": true, "most": true, "man": true,
+	"top": true, "htop": true, "btop": true, "atop": true, "watch": true,
+	"tmux": true, "screen": true, "mc": true, "ranger": true, "nnn": true, "ncdu": true,
+	"fzf": true, "tig": true, "lazygit": true, "k9s": true,
+}
+
+// commandPrefixes 是在实际程序之前出现、不影响判断的命令前缀
+var commandPrefixes = map[string]bool{
+	"sudo": true, "env": true, "command": true, "exec": true, "nice": true, "time": true, "nohup": true,
+}
+
+// NeedsTTY 判断命令是否运行需要终端的交互式程序（如 vim、less、htop、git add -p）
+// 只做简单的分词：按管道、&&、;、子 shell 拆分后检查每一段的程序名
+func NeedsTTY(command string) bool {
+	segments := strings.FieldsFunc(command, func(r rune) bool {
+		return strings.ContainsRune("|&;()\n", r)
+	})
+
+	for _, segment := range segments {
+		args := strings.Fields(segment)
+
+		// 跳过环境变量赋值（FOO=bar cmd）和 sudo 等前缀
+		for len(args) > 0 && (strings.Contains(args[0], "=") || commandPrefixes[args[0]]) {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		name := filepath.Base(args[0])
+		if ttyPrograms[name] || (name == "git" && gitNeedsTTY(args[1:])) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitNeedsTTY 判断 git 子命令是否需要终端：交互式暂存、打开编辑器或使用分页器
+func gitNeedsTTY(args []string) bool {
+	// 跳过全局选项（-C <path>、-c <name>=<value> 等）
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		if args[0] == "-C" || args[0] == "-c" {
+			args = args[1:]
+		}
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return false
+	}
+
+	sub, opts := args[0], args[1:]
+	switch sub {
+	case "add", "checkout", "reset", "restore", "stash":
+		return hasOption(opts, "p", "--patch", "i", "--interactive")
+	case "rebase":
+		return hasOption(opts, "i", "--interactive")
+	case "commit":
+		// 没有通过参数提供提交信息时会打开编辑器
+		return !hasOption(opts, "m", "--message", "F", "--file", "C", "--reuse-message", "--no-edit")
+	case "log", "diff", "show", "blame":
+		// 输出到终端时使用分页器
+		return true
+	}
+	return false
+}
+
+// hasOption 判断参数中是否包含指定选项；单字母选项也匹配组合写法（如 -am）
+func hasOption(args []string, options ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, opt := range options {
+			if strings.HasPrefix(opt, "--") {
+				if arg == opt || strings.HasPrefix(arg, opt+"=") {
+					return true
+				}
+			} else if strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.Contains(arg[1:], opt) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -9,6 +9,9 @@ const (
 	ErrCreateProvider  = "error.create_provider"
 	ErrTranslateFailed = "error.translate_failed"
 	ErrExecuteFailed   = "error.execute_failed"
+	ErrCommandTimeout  = "error.command_timeout"
+	ErrInterrupted     = "error.interrupted"
+	ErrSelectShell     = "error.select_shell"
 	ErrNoInput         = "error.no_input"
 	ErrEmptyCommand    = "error.empty_command"
 	ErrLoadHistory     = "error.load_history"
@@ -20,15 +23,35 @@ const (
 	ErrReadStdin       = "error.read_stdin"
 	ErrSaveConfig      = "error.save_config"
 	// LLM Provider 内部错误
-	ErrInputEmpty       = "error.input_empty"
-	ErrSerializeRequest = "error.serialize_request"
-	ErrCreateRequest    = "error.create_request"
-	ErrAPIRequest       = "error.api_request"
-	ErrReadResponse     = "error.read_response"
-	ErrParseResponse    = "error.parse_response"
-	ErrAPIError         = "error.api_error"
-	ErrEmptyResponse    = "error.empty_response"
-	ErrEmptyCommandResp = "error.empty_command_resp"
+	ErrInputEmpty        = "error.input_empty"
+	ErrSerializeRequest  = "error.serialize_request"
+	ErrCreateRequest     = "error.create_request"
+	ErrAPIRequest        = "error.api_request"
+	ErrReadResponse      = "error.read_response"
+	ErrParseResponse     = "error.parse_response"
+	ErrAPIError          = "error.api_error"
+	ErrEmptyResponse     = "error.empty_response"
+	ErrContentBlocked    = "error.content_blocked"
+	ErrEmptyCommandResp  = "error.empty_command_resp"
+	ErrInvalidListResp   = "error.invalid_list_resp"
+	ErrInvalidObjectResp = "error.invalid_object_resp"
+	ErrInvalidCandidate  = "error.invalid_candidate"
+
+	// 提示词模板错误
+	ErrLoadPromptTemplate = "error.load_prompt_template"
+
+	// 命令解释错误
+	ErrExplainUnsupported = "error.explain_unsupported"
+	ErrNoCommandToExplain = "error.no_command_to_explain"
+
+	// 命令修正错误
+	ErrFixFailed      = "error.fix_failed"
+	ErrFixUnsupported = "error.fix_unsupported"
+
+	// 多步计划错误
+	ErrPlanStepFailed    = "error.plan_step_failed"
+	ErrPlanEmpty         = "error.plan_empty"
+	ErrInvalidPlanAction = "error.invalid_plan_action"
 )
 
 // 提示信息键
@@ -38,11 +61,16 @@ const (
 	PromptEnterAPIKey     = "prompt.enter_api_key"
 	PromptEnterModel      = "prompt.enter_model"
 	PromptEnterAPIBase    = "prompt.enter_api_base"
+	PromptEnterDeployment = "prompt.enter_deployment"
+	PromptEnterAPIVersion = "prompt.enter_api_version"
 	PromptSelectProvider  = "prompt.select_provider"
 	PromptEnableCheck     = "prompt.enable_check"
 	PromptEnableHistory   = "prompt.enable_history"
 	PromptOverwriteConfig = "prompt.overwrite_config"
 	PromptInputChoice     = "prompt.input_choice"
+	PromptFixCommand      = "prompt.fix_command"
+	PromptPlanReview      = "prompt.plan_review"
+	PromptPlanEdit        = "prompt.plan_edit"
 )
 
 // 界面文本键
@@ -67,6 +95,27 @@ const (
 	MsgDefault            = "msg.default"
 	MsgTranslatedCommand  = "msg.translated_command"
 	MsgTrialAPINotice     = "msg.trial_api_notice" // 试用 API 提示
+	MsgGenerating         = "msg.generating"       // 流式生成命令提示
+	MsgSelectCandidate    = "msg.select_candidate" // 候选命令选择提示
+	MsgProviderFallback   = "msg.provider_fallback" // 切换备用提供商提示
+
+	MsgPromptTemplateFallback = "msg.prompt_template_fallback" // 提示词模板加载失败提示
+
+	// 命令解释
+	MsgExplainSafe  = "msg.explain_safe"
+	MsgExplainRisky = "msg.explain_risky"
+
+	// 命令修正
+	MsgFixedCommand = "msg.fixed_command"
+
+	// 多步计划
+	MsgPlanHeader  = "msg.plan_header"
+	MsgPlanStep    = "msg.plan_step"
+	MsgPlanRefined = "msg.plan_refined"
+	MsgPlanDone    = "msg.plan_done"
+
+	// token 用量统计
+	MsgNoUsage = "msg.no_usage"
 )
 
 // 警告信息键
@@ -95,6 +144,27 @@ const (
 	LabelModel       = "label.model"
 	LabelAPIBase     = "label.api_base"
 	LabelAPIKey      = "label.api_key"
+	LabelExplanation = "label.explanation"
+	LabelFixOf       = "label.fix_of"
+
+	// 环境信息收集器标签
+	LabelTools          = "label.tools"
+	LabelToolsMissing   = "label.tools_missing"
+	LabelDistro         = "label.distro"
+	LabelPackageManager = "label.package_manager"
+	LabelUserland       = "label.userland"
+	LabelProject        = "label.project"
+	LabelGitBranch      = "label.git_branch"
+	LabelGitClean       = "label.git_clean"
+	LabelGitDirty       = "label.git_dirty"
+
+	// token 用量统计标签
+	LabelDate             = "label.date"
+	LabelRequests         = "label.requests"
+	LabelPromptTokens     = "label.prompt_tokens"
+	LabelCompletionTokens = "label.completion_tokens"
+	LabelCost             = "label.cost"
+	LabelTotal            = "label.total"
 )
 
 // Verbose 模式信息键
@@ -105,11 +175,26 @@ const (
 	VerboseCommand          = "verbose.command"
 	VerboseTranslateTime    = "verbose.translate_time"
 	VerboseExecuting        = "verbose.executing"
+	VerboseUsingTTY         = "verbose.using_tty"
 	VerboseExecuteTime      = "verbose.execute_time"
 	VerboseTotalTime        = "verbose.total_time"
 	VerboseConfigNotExist   = "verbose.config_not_exist"
 	VerboseLoadHistoryFailed = "verbose.load_history_failed"
 	VerboseSaveHistoryFailed = "verbose.save_history_failed"
+	VerboseExplanation       = "verbose.explanation"
+	VerboseModelRisk         = "verbose.model_risk"
+	VerboseTools             = "verbose.tools"
+	VerboseUsage             = "verbose.usage"
+	VerboseUsageFormat       = "verbose.usage_format"
+	VerboseSessionTurns      = "verbose.session_turns"
+	VerboseLoadSessionFailed = "verbose.load_session_failed"
+	VerboseSaveSessionFailed = "verbose.save_session_failed"
+	VerboseProvider          = "verbose.provider"
+	VerboseCacheHit          = "verbose.cache_hit"
+	VerboseCollected         = "verbose.collected"
+	VerboseCollectorFailed   = "verbose.collector_failed"
+	VerboseInstructions      = "verbose.instructions"
+	VerboseStdinFormat       = "verbose.stdin_format"
 )
 
 // Dry-run 模式键
@@ -127,11 +212,32 @@ const (
 	LLMSystemPromptRule4      = "llm.system_prompt_rule4"
 	LLMSystemPromptRule5      = "llm.system_prompt_rule5"
 	LLMSystemPromptEnv        = "llm.system_prompt_env"
+	LLMInstructions           = "llm.instructions"
 	LLMUserPromptIntro        = "llm.user_prompt_intro"
 	LLMStdinData              = "llm.stdin_data"
 	LLMTruncated              = "llm.truncated"
 	LLMContextNoContext       = "llm.context_no_context"
 	LLMContextFormat          = "llm.context_format"
+	LLMCandidatesPrompt       = "llm.candidates_prompt"
+	LLMExplainPrompt          = "llm.explain_prompt"
+	LLMExplainUserPrompt      = "llm.explain_user_prompt"
+	LLMFixPrompt              = "llm.fix_prompt"
+	LLMFixNoStderr            = "llm.fix_no_stderr"
+	LLMPlanPrompt             = "llm.plan_prompt"
+	LLMPlanStepPrompt         = "llm.plan_step_prompt"
+	LLMPlanStepOutput         = "llm.plan_step_output"
+
+	// 标准输入摘要
+	LLMStdinSummary   = "llm.stdin_summary"
+	LLMStdinBinary    = "llm.stdin_binary"
+	LLMStdinSchema    = "llm.stdin_schema"
+	LLMStdinFields    = "llm.stdin_fields"
+	LLMStdinColumns   = "llm.stdin_columns"
+	LLMStdinDelimiter = "llm.stdin_delimiter"
+	LLMStdinLevels    = "llm.stdin_levels"
+	LLMStdinHead      = "llm.stdin_head"
+	LLMStdinTail      = "llm.stdin_tail"
+	LLMStdinOmitted   = "llm.stdin_omitted"
 )
 
 // Cobra 命令描述键
@@ -147,6 +253,16 @@ const (
 	CobraFlagHistory     = "cobra.flag_history"
 	CobraFlagRetry       = "cobra.flag_retry"
 	CobraFlagQuiet       = "cobra.flag_quiet"
+	CobraFlagCandidates  = "cobra.flag_candidates"
+	CobraFlagContinue    = "cobra.flag_continue"
+	CobraFlagNoCache     = "cobra.flag_no_cache"
+	CobraFlagShowPrompt  = "cobra.flag_show_prompt"
+	CobraFlagPlan        = "cobra.flag_plan"
+	CobraFlagTimeout     = "cobra.flag_timeout"
+	CobraFlagShell       = "cobra.flag_shell"
+	CobraFlagTTY         = "cobra.flag_tty"
+
+	CobraFlagNoInstructions = "cobra.flag_no_instructions"
 )
 
 // Init 命令键
@@ -154,6 +270,19 @@ const (
 	InitUse   = "init.use"
 	InitShort = "init.short"
 	InitLong  = "init.long"
+
+	// Explain 子命令
+	ExplainUse       = "explain.use"
+	ExplainShort     = "explain.short"
+	ExplainLong      = "explain.long"
+	ExplainFlagShell = "explain.flag_shell"
+
+	// Stats 子命令
+	StatsUse        = "stats.use"
+	StatsShort      = "stats.short"
+	StatsUsageShort = "stats.usage_short"
+	StatsUsageLong  = "stats.usage_long"
+	StatsFlagDays   = "stats.flag_days"
 )
 
 // Completion 命令键
@@ -182,5 +311,7 @@ const (
 	InitProviderAnthropic = "init.provider_anthropic"
 	InitProviderLocal     = "init.provider_local"
 	InitProviderDeepSeek  = "init.provider_deepseek"
+	InitProviderGemini    = "init.provider_gemini"
+	InitProviderAzure     = "init.provider_azure"
 	InitProviderOther     = "init.provider_other"
 )
@@ -8,6 +8,9 @@ var messagesEn = map[string]string{
 	ErrCreateProvider:  "Failed to create LLM Provider",
 	ErrTranslateFailed: "Failed to translate command",
 	ErrExecuteFailed:   "Failed to execute command",
+	ErrCommandTimeout:  "Command timed out and was terminated",
+	ErrInterrupted:     "Interrupted",
+	ErrSelectShell:     "Failed to select shell",
 	ErrNoInput:         "Please provide natural language description",
 	ErrEmptyCommand:    "LLM returned empty command",
 	ErrLoadHistory:     "Failed to load history",
@@ -19,15 +22,29 @@ var messagesEn = map[string]string{
 	ErrReadStdin:       "Failed to read stdin",
 	ErrSaveConfig:      "Failed to save configuration",
 	// LLM Provider internal errors
-	ErrInputEmpty:       "Input cannot be empty",
-	ErrSerializeRequest: "Failed to serialize request",
-	ErrCreateRequest:    "Failed to create request",
-	ErrAPIRequest:       "API request failed",
-	ErrReadResponse:     "Failed to read response",
-	ErrParseResponse:    "Failed to parse response",
-	ErrAPIError:         "API error",
-	ErrEmptyResponse:    "API returned empty response",
-	ErrEmptyCommandResp: "API returned empty command",
+	ErrInputEmpty:        "Input cannot be empty",
+	ErrSerializeRequest:  "Failed to serialize request",
+	ErrCreateRequest:     "Failed to create request",
+	ErrAPIRequest:        "API request failed",
+	ErrReadResponse:      "Failed to read response",
+	ErrParseResponse:     "Failed to parse response",
+	ErrAPIError:          "API error",
+	ErrEmptyResponse:     "API returned empty response",
+	ErrContentBlocked:    "request blocked by the provider's safety filter",
+	ErrEmptyCommandResp:  "API returned empty command",
+	ErrInvalidListResp:   "model returned an invalid or incomplete JSON array (the reply may have exceeded llm.max_tokens)",
+	ErrInvalidObjectResp: "model returned an invalid or incomplete JSON object (the reply may have exceeded llm.max_tokens)",
+	ErrInvalidCandidate:  "Invalid choice: %s",
+
+	// Prompt template errors
+	ErrLoadPromptTemplate: "failed to load prompt template %s",
+	ErrExplainUnsupported: "provider %s does not support explaining commands",
+	ErrNoCommandToExplain: "no command to explain (pass it as an argument or via stdin)",
+	ErrFixFailed:          "Failed to get a corrected command",
+	ErrFixUnsupported:     "provider %s does not support fixing commands",
+	ErrPlanStepFailed:     "Step %d failed",
+	ErrPlanEmpty:          "No steps left to run",
+	ErrInvalidPlanAction:  "Invalid input: %s",
 
 	// Prompts
 	PromptConfirmRisky:    "Continue execution? (y/n): ",
@@ -35,11 +52,16 @@ var messagesEn = map[string]string{
 	PromptEnterAPIKey:     "Please enter API Key",
 	PromptEnterModel:      "Please enter model name",
 	PromptEnterAPIBase:    "Please enter API Base URL",
+	PromptEnterDeployment: "Please enter Azure deployment name",
+	PromptEnterAPIVersion: "Please enter Azure API version",
 	PromptSelectProvider:  "Please select LLM provider",
 	PromptEnableCheck:     "Enable dangerous command safety checks?",
 	PromptEnableHistory:   "Enable history recording?",
 	PromptOverwriteConfig: "Overwrite?",
 	PromptInputChoice:     "Please enter your choice",
+	PromptFixCommand:      "Command exited with code %d. Send the command and its error output to the LLM for a fix? (y/n): ",
+	PromptPlanReview:      "Enter to run all steps, e<N> to edit, d<N> to drop, q to quit: ",
+	PromptPlanEdit:        "New command for step %d: ",
 
 	// UI messages
 	MsgHistoryEmpty:       "No history records",
@@ -62,6 +84,19 @@ var messagesEn = map[string]string{
 	MsgDefault:            "default",
 	MsgTranslatedCommand:  "💡 Executing: %s",
 	MsgTrialAPINotice:     "⚠️  Using trial API. Please run 'aicli init' to configure your own LLM API.",
+	MsgGenerating:         "⏳ Generating: ",
+	MsgSelectCandidate:    "Select a command (↑/↓ or 1-%d, Enter to confirm, q to cancel):",
+	MsgProviderFallback:   "⚠️  %s failed (%v), falling back to %s",
+
+	MsgPromptTemplateFallback: "⚠️  %v, using built-in prompts",
+	MsgExplainSafe:            "✅ Safety check: no dangerous operations detected",
+	MsgExplainRisky:           "⚠️  Safety check: %s (risk level: %s)",
+	MsgFixedCommand:           "🔧 Corrected command (attempt %d/%d): %s",
+	MsgPlanHeader:             "📋 Plan (%d steps):",
+	MsgPlanStep:               "▶ Step %d/%d: %s",
+	MsgPlanRefined:            "🔄 Step %d updated using the previous output: %s",
+	MsgPlanDone:               "✅ All %d steps completed",
+	MsgNoUsage:                "No token usage recorded in the selected period",
 
 	// Warnings
 	WarnDangerousCommand: "Potentially dangerous command detected!",
@@ -70,22 +105,41 @@ var messagesEn = map[string]string{
 	WarnRiskLevel:        "Level",
 
 	// Field labels
-	LabelCommand:    "Command",
-	LabelInput:      "Input",
-	LabelError:      "Error",
-	LabelOutput:     "Output",
-	LabelTimestamp:  "Timestamp",
-	LabelRisk:       "Risk",
-	LabelLevel:      "Level",
-	LabelOS:         "Operating System",
-	LabelShell:      "Shell",
-	LabelWorkDir:    "Working Directory",
-	LabelStdin:      "Standard Input",
-	LabelStdinBytes: "bytes",
-	LabelProvider:   "Provider",
-	LabelModel:      "Model",
-	LabelAPIBase:    "API Base URL",
-	LabelAPIKey:     "API Key",
+	LabelCommand:     "Command",
+	LabelInput:       "Input",
+	LabelError:       "Error",
+	LabelOutput:      "Output",
+	LabelTimestamp:   "Timestamp",
+	LabelRisk:        "Risk",
+	LabelLevel:       "Level",
+	LabelOS:          "Operating System",
+	LabelShell:       "Shell",
+	LabelWorkDir:     "Working Directory",
+	LabelStdin:       "Standard Input",
+	LabelStdinBytes:  "bytes",
+	LabelProvider:    "Provider",
+	LabelModel:       "Model",
+	LabelAPIBase:     "API Base URL",
+	LabelAPIKey:      "API Key",
+	LabelExplanation: "Explanation",
+	LabelFixOf:       "Fix of",
+
+	LabelTools:          "Available tools",
+	LabelToolsMissing:   "not installed",
+	LabelDistro:         "Distribution",
+	LabelPackageManager: "Package manager",
+	LabelUserland:       "Userland",
+	LabelProject:        "Project",
+	LabelGitBranch:      "git: branch %s",
+	LabelGitClean:       "git: branch %s, clean",
+	LabelGitDirty:       "git: branch %s, %d uncommitted changes",
+
+	LabelDate:             "Date",
+	LabelRequests:         "Requests",
+	LabelPromptTokens:     "Input tokens",
+	LabelCompletionTokens: "Output tokens",
+	LabelCost:             "Cost",
+	LabelTotal:            "Total",
 
 	// Verbose mode
 	VerboseInput:             "Natural language input",
@@ -94,11 +148,26 @@ var messagesEn = map[string]string{
 	VerboseCommand:           "Translated command",
 	VerboseTranslateTime:     "Translation time",
 	VerboseExecuting:         "Executing command...",
+	VerboseUsingTTY:          "Running command in a pseudo-terminal",
 	VerboseExecuteTime:       "Execution time",
 	VerboseTotalTime:         "Total time",
 	VerboseConfigNotExist:    "Configuration file does not exist, using default configuration",
 	VerboseLoadHistoryFailed: "Failed to load history: %v",
 	VerboseSaveHistoryFailed: "Failed to save history: %v",
+	VerboseExplanation:       "Explanation",
+	VerboseModelRisk:         "Model-assessed risk",
+	VerboseTools:             "Required tools",
+	VerboseUsage:             "Token usage",
+	VerboseUsageFormat:       "%d (prompt %d, completion %d)",
+	VerboseSessionTurns:      "Conversation history: %d previous turn(s)",
+	VerboseLoadSessionFailed: "Failed to load session: %v",
+	VerboseSaveSessionFailed: "Failed to save session: %v",
+	VerboseProvider:          "Provider",
+	VerboseCacheHit:          "Result loaded from cache (use --no-cache to refresh)",
+	VerboseCollected:         "Collected environment",
+	VerboseCollectorFailed:   "%s collector failed: %v",
+	VerboseInstructions:      "Instructions loaded from %s (%d bytes)",
+	VerboseStdinFormat:       "Stdin format: %s, %d lines, %d bytes sent to the model",
 
 	// Dry-run mode
 	DryRunWillExecute: "Command to be executed: %s",
@@ -106,17 +175,38 @@ var messagesEn = map[string]string{
 	// LLM prompts
 	LLMSystemPromptIntro: "You are a command-line assistant that converts natural language descriptions into executable shell commands.",
 	LLMSystemPromptRules: "Rules:",
-	LLMSystemPromptRule1: "1. Return only the command itself, without any explanation or description",
+	LLMSystemPromptRule1: "1. Respond with only one JSON object: {\"command\": \"<command>\", \"explanation\": \"<one-line explanation>\", \"risk\": \"low|medium|high\", \"tools\": [\"<programs the command needs>\"]}",
 	LLMSystemPromptRule2: "2. Do not use markdown code block format",
-	LLMSystemPromptRule3: "3. The command must be directly executable",
-	LLMSystemPromptRule4: "4. If multiple commands are needed, connect them with && or ;",
+	LLMSystemPromptRule3: "3. The command must be directly executable in the shell listed below, using its syntax",
+	LLMSystemPromptRule4: "4. If multiple commands are needed, chain them the way that shell does (e.g. && or ; in bash)",
 	LLMSystemPromptRule5: "5. Prefer commonly used and compatible commands",
 	LLMSystemPromptEnv:   "Execution Environment:",
+	LLMInstructions:      "Project instructions (from .aicli.md, follow these team conventions):",
 	LLMUserPromptIntro:   "Convert the following natural language description into a command:",
 	LLMStdinData:         "Standard input data:",
 	LLMTruncated:         "... (truncated)",
 	LLMContextNoContext:  "No execution context",
 	LLMContextFormat:     "OS: %s, Shell: %s, WorkDir: %s",
+	LLMCandidatesPrompt:  "Override rules 1 and 2 for this request: provide %d different alternative commands. Respond with only a JSON array, each element shaped like {\"command\": \"...\", \"explanation\": \"one-line explanation\"}, ordered from most to least recommended.",
+	LLMExplainPrompt:     "You are a shell expert. Explain the command given by the user piece by piece: every program, subcommand, flag, argument and operator (pipes, redirections, &&). Answer in English. Respond with only one JSON object: {\"summary\": \"<one sentence on what the command does>\", \"parts\": [{\"token\": \"<piece of the command>\", \"meaning\": \"<what it does>\"}]}. Do not use markdown code blocks.",
+	LLMExplainUserPrompt: "Explain the following command:",
+	LLMFixPrompt:         "The command failed with exit code %d. Its error output (stderr) was:\n%s\nFix the command so that it accomplishes the original request and respond in the same format as before.",
+	LLMFixNoStderr:       "(empty)",
+	LLMPlanPrompt:        "Override rules 1 and 2 for this request: the task needs several dependent commands. Break it into at most %d ordered steps. Respond with only a JSON array, each element shaped like {\"description\": \"what the step does\", \"command\": \"...\", \"uses_output\": true if the command depends on the output of earlier steps}, without markdown code blocks.",
+	LLMPlanStepPrompt:    "The plan above is being executed step by step. Outputs of the previous steps:\n%s\nGive the final command for step %d (%s), planned as: %s. Respond with only this single command, in the format described in the system prompt.",
+	LLMPlanStepOutput:    "Step %d output (%s):\n%s",
+
+	// Stdin summary
+	LLMStdinSummary:   "Standard input is too large, summary follows (format: %s, %d bytes, %d lines):",
+	LLMStdinBinary:    "Standard input is binary data (%d bytes), content not sent",
+	LLMStdinSchema:    "Structure",
+	LLMStdinFields:    "Fields",
+	LLMStdinColumns:   "Columns (%d)",
+	LLMStdinDelimiter: "Delimiter",
+	LLMStdinLevels:    "Log levels",
+	LLMStdinHead:      "First lines",
+	LLMStdinTail:      "Last lines",
+	LLMStdinOmitted:   "... (%d lines omitted)",
 
 	// Cobra command descriptions
 	CobraUse:   "aicli [natural language description]",
@@ -139,12 +229,35 @@ Examples:
 	CobraFlagHistory:     "Show history records",
 	CobraFlagRetry:       "Retry history command ID",
 	CobraFlagQuiet:       "Quiet mode, do not show translated command",
+	CobraFlagCandidates:  "Generate N alternative commands and pick one interactively",
+	CobraFlagContinue:    "Refine the previous command, sending the last conversation to the LLM",
+	CobraFlagNoCache:     "Bypass the translation cache and ask the LLM again",
+	CobraFlagShowPrompt:  "Print the rendered prompt without calling the LLM",
+	CobraFlagPlan:        "Break the task into several steps, review them, then run them in order",
+	CobraFlagTimeout:     "Command execution timeout in seconds (overrides execution.timeout)",
+	CobraFlagShell:       "Shell used to run commands (bash, zsh, fish, nu, ...), overrides execution.shell",
+	CobraFlagTTY:         "Run the command in a pseudo-terminal (interactive programs like vim and less are detected automatically)",
+
+	CobraFlagNoInstructions: "Do not read .aicli.md instruction files",
 
 	// Init command
 	InitUse:   "init",
 	InitShort: "Initialize configuration",
 	InitLong:  "Guide user to set up LLM configuration and generate configuration file ~/.aicli.json",
 
+	// Explain command
+	ExplainUse:       "explain <command>",
+	ExplainShort:     "Explain a shell command flag by flag",
+	ExplainLong:      "Ask the LLM to break a shell command down flag by flag in the configured language, together with the local safety check verdict.\n\nExamples:\n  aicli explain 'tar -xzvf a.tgz -C /tmp'\n  aicli explain < deploy.sh",
+	ExplainFlagShell: "Shell the command is written for (bash, zsh, fish, nu, ...), overrides execution.shell",
+
+	// Stats command
+	StatsUse:        "stats",
+	StatsShort:      "Show usage statistics",
+	StatsUsageShort: "Summarize token usage and cost by day, provider and model",
+	StatsUsageLong:  "Summarize the token usage recorded in the history by day, provider and model. Costs are computed from the pricing table in the configuration at the time of each request.",
+	StatsFlagDays:   "Number of days to include (0 for all history)",
+
 	// Completion command
 	CompletionShort: "Generate the autocompletion script for the specified shell",
 
@@ -162,5 +275,7 @@ Examples:
 	InitProviderAnthropic: "2. Anthropic (Claude)",
 	InitProviderLocal:     "3. Local (Ollama, LocalAI)",
 	InitProviderDeepSeek:  "4. DeepSeek",
-	InitProviderOther:     "5. Other (OpenAI-compatible API)",
+	InitProviderGemini:    "5. Google Gemini",
+	InitProviderAzure:     "6. Azure OpenAI",
+	InitProviderOther:     "7. Other (OpenAI-compatible API)",
 }
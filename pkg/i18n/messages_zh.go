@@ -8,6 +8,9 @@ var messagesZh = map[string]string{
 	ErrCreateProvider:  "创建 LLM Provider 失败",
 	ErrTranslateFailed: "命令转换失败",
 	ErrExecuteFailed:   "命令执行失败",
+	ErrCommandTimeout:  "命令执行超时，已终止",
+	ErrInterrupted:     "已中断",
+	ErrSelectShell:     "选择 Shell 失败",
 	ErrNoInput:         "请提供自然语言描述",
 	ErrEmptyCommand:    "LLM 返回空命令",
 	ErrLoadHistory:     "加载历史记录失败",
@@ -19,15 +22,29 @@ var messagesZh = map[string]string{
 	ErrReadStdin:       "读取 stdin 失败",
 	ErrSaveConfig:      "保存配置失败",
 	// LLM Provider 内部错误
-	ErrInputEmpty:       "输入不能为空",
-	ErrSerializeRequest: "序列化请求失败",
-	ErrCreateRequest:    "创建请求失败",
-	ErrAPIRequest:       "API 请求失败",
-	ErrReadResponse:     "读取响应失败",
-	ErrParseResponse:    "解析响应失败",
-	ErrAPIError:         "API 错误",
-	ErrEmptyResponse:    "API 返回空响应",
-	ErrEmptyCommandResp: "API 返回空命令",
+	ErrInputEmpty:        "输入不能为空",
+	ErrSerializeRequest:  "序列化请求失败",
+	ErrCreateRequest:     "创建请求失败",
+	ErrAPIRequest:        "API 请求失败",
+	ErrReadResponse:      "读取响应失败",
+	ErrParseResponse:     "解析响应失败",
+	ErrAPIError:          "API 错误",
+	ErrEmptyResponse:     "API 返回空响应",
+	ErrContentBlocked:    "请求被提供商的安全策略拦截",
+	ErrEmptyCommandResp:  "API 返回空命令",
+	ErrInvalidListResp:   "模型返回的 JSON 数组无效或不完整（回复可能超过了 llm.max_tokens）",
+	ErrInvalidObjectResp: "模型返回的 JSON 对象无效或不完整（回复可能超过了 llm.max_tokens）",
+	ErrInvalidCandidate:  "无效的选择: %s",
+
+	// 提示词模板错误
+	ErrLoadPromptTemplate: "加载提示词模板 %s 失败",
+	ErrExplainUnsupported: "提供商 %s 不支持解释命令",
+	ErrNoCommandToExplain: "没有需要解释的命令（通过参数或标准输入提供）",
+	ErrFixFailed:          "获取修正命令失败",
+	ErrFixUnsupported:     "提供商 %s 不支持修正命令",
+	ErrPlanStepFailed:     "第 %d 步执行失败",
+	ErrPlanEmpty:          "没有需要执行的步骤",
+	ErrInvalidPlanAction:  "无效的输入: %s",
 
 	// 提示信息
 	PromptConfirmRisky:    "是否继续执行?(y/n): ",
@@ -35,11 +52,16 @@ var messagesZh = map[string]string{
 	PromptEnterAPIKey:     "请输入 API Key",
 	PromptEnterModel:      "请输入模型名称",
 	PromptEnterAPIBase:    "请输入 API Base URL",
+	PromptEnterDeployment: "请输入 Azure 部署名称",
+	PromptEnterAPIVersion: "请输入 Azure API 版本",
 	PromptSelectProvider:  "请选择 LLM 提供商",
 	PromptEnableCheck:     "是否启用危险命令安全检查?",
 	PromptEnableHistory:   "是否启用历史记录?",
 	PromptOverwriteConfig: "是否覆盖?",
 	PromptInputChoice:     "请输入序号",
+	PromptFixCommand:      "命令以退出码 %d 结束。是否将命令和错误输出发送给 LLM 进行修正? (y/n): ",
+	PromptPlanReview:      "回车执行全部步骤，e<N> 编辑，d<N> 删除，q 退出: ",
+	PromptPlanEdit:        "第 %d 步的新命令: ",
 
 	// 界面文本
 	MsgHistoryEmpty:       "没有历史记录",
@@ -62,6 +84,19 @@ var messagesZh = map[string]string{
 	MsgDefault:            "默认",
 	MsgTranslatedCommand:  "💡 执行命令: %s",
 	MsgTrialAPINotice:     "⚠️  当前使用的是内嵌（试用）API，请运行 'aicli init' 来配置您自己的 LLM API。",
+	MsgGenerating:         "⏳ 正在生成: ",
+	MsgSelectCandidate:    "请选择命令 (↑/↓ 或 1-%d, 回车确认, q 取消):",
+	MsgProviderFallback:   "⚠️  %s 调用失败（%v），切换到 %s",
+
+	MsgPromptTemplateFallback: "⚠️  %v，使用内置提示词",
+	MsgExplainSafe:            "✅ 安全检查: 未发现危险操作",
+	MsgExplainRisky:           "⚠️  安全检查: %s（风险等级: %s）",
+	MsgFixedCommand:           "🔧 修正后的命令（第 %d/%d 次）: %s",
+	MsgPlanHeader:             "📋 执行计划（共 %d 步）:",
+	MsgPlanStep:               "▶ 第 %d/%d 步: %s",
+	MsgPlanRefined:            "🔄 根据前面步骤的输出更新第 %d 步: %s",
+	MsgPlanDone:               "✅ 全部 %d 步执行完成",
+	MsgNoUsage:                "所选时间范围内没有 token 用量记录",
 
 	// 警告信息
 	WarnDangerousCommand: "检测到潜在危险命令!",
@@ -70,22 +105,41 @@ var messagesZh = map[string]string{
 	WarnRiskLevel:        "等级",
 
 	// 字段标签
-	LabelCommand:    "命令",
-	LabelInput:      "输入",
-	LabelError:      "错误",
-	LabelOutput:     "输出",
-	LabelTimestamp:  "时间",
-	LabelRisk:       "风险",
-	LabelLevel:      "等级",
-	LabelOS:         "操作系统",
-	LabelShell:      "Shell",
-	LabelWorkDir:    "工作目录",
-	LabelStdin:      "标准输入",
-	LabelStdinBytes: "字节",
-	LabelProvider:   "提供商",
-	LabelModel:      "模型",
-	LabelAPIBase:    "API Base URL",
-	LabelAPIKey:     "API Key",
+	LabelCommand:     "命令",
+	LabelInput:       "输入",
+	LabelError:       "错误",
+	LabelOutput:      "输出",
+	LabelTimestamp:   "时间",
+	LabelRisk:        "风险",
+	LabelLevel:       "等级",
+	LabelOS:          "操作系统",
+	LabelShell:       "Shell",
+	LabelWorkDir:     "工作目录",
+	LabelStdin:       "标准输入",
+	LabelStdinBytes:  "字节",
+	LabelProvider:    "提供商",
+	LabelModel:       "模型",
+	LabelAPIBase:     "API Base URL",
+	LabelAPIKey:      "API Key",
+	LabelExplanation: "说明",
+	LabelFixOf:       "修正自",
+
+	LabelTools:          "可用工具",
+	LabelToolsMissing:   "未安装",
+	LabelDistro:         "发行版",
+	LabelPackageManager: "包管理器",
+	LabelUserland:       "命令行工具集",
+	LabelProject:        "当前项目",
+	LabelGitBranch:      "git: 分支 %s",
+	LabelGitClean:       "git: 分支 %s，没有未提交的修改",
+	LabelGitDirty:       "git: 分支 %s，%d 个未提交的修改",
+
+	LabelDate:             "日期",
+	LabelRequests:         "请求数",
+	LabelPromptTokens:     "输入 token",
+	LabelCompletionTokens: "输出 token",
+	LabelCost:             "费用",
+	LabelTotal:            "合计",
 
 	// Verbose 模式信息
 	VerboseInput:             "自然语言输入",
@@ -94,11 +148,26 @@ var messagesZh = map[string]string{
 	VerboseCommand:           "转换后的命令",
 	VerboseTranslateTime:     "转换耗时",
 	VerboseExecuting:         "开始执行命令...",
+	VerboseUsingTTY:          "在伪终端中执行命令",
 	VerboseExecuteTime:       "执行耗时",
 	VerboseTotalTime:         "总耗时",
 	VerboseConfigNotExist:    "配置文件不存在,使用默认配置",
 	VerboseLoadHistoryFailed: "加载历史记录失败: %v",
 	VerboseSaveHistoryFailed: "保存历史记录失败: %v",
+	VerboseExplanation:       "命令说明",
+	VerboseModelRisk:         "模型评估风险",
+	VerboseTools:             "依赖工具",
+	VerboseUsage:             "Token 用量",
+	VerboseUsageFormat:       "%d (输入 %d, 输出 %d)",
+	VerboseSessionTurns:      "对话历史: 之前 %d 轮",
+	VerboseLoadSessionFailed: "加载会话失败: %v",
+	VerboseSaveSessionFailed: "保存会话失败: %v",
+	VerboseProvider:          "提供商",
+	VerboseCacheHit:          "结果来自缓存（使用 --no-cache 重新生成）",
+	VerboseCollected:         "收集的环境信息",
+	VerboseCollectorFailed:   "%s 收集器失败: %v",
+	VerboseInstructions:      "已加载项目说明 %s（%d 字节）",
+	VerboseStdinFormat:       "标准输入格式: %s，%d 行，发送给模型 %d 字节",
 
 	// Dry-run 模式
 	DryRunWillExecute: "将要执行的命令: %s",
@@ -106,17 +175,38 @@ var messagesZh = map[string]string{
 	// LLM 提示词
 	LLMSystemPromptIntro: "你是一个命令行助手,专门将用户的自然语言描述转换为可执行的 shell 命令。",
 	LLMSystemPromptRules: "规则:",
-	LLMSystemPromptRule1: "1. 只返回命令本身,不要有任何解释或说明",
+	LLMSystemPromptRule1: "1. 只返回一个 JSON 对象: {\"command\": \"<命令>\", \"explanation\": \"<一行说明>\", \"risk\": \"low|medium|high\", \"tools\": [\"<命令依赖的程序>\"]}",
 	LLMSystemPromptRule2: "2. 不要使用 markdown 代码块格式",
-	LLMSystemPromptRule3: "3. 命令必须是可以直接执行的",
-	LLMSystemPromptRule4: "4. 如果需要多个命令,使用 && 或 ; 连接",
+	LLMSystemPromptRule3: "3. 命令必须可以在下方列出的 Shell 中直接执行,使用该 Shell 的语法",
+	LLMSystemPromptRule4: "4. 如果需要多个命令,按该 Shell 的方式连接(如 bash 中的 && 或 ;)",
 	LLMSystemPromptRule5: "5. 优先使用常见且兼容性好的命令",
 	LLMSystemPromptEnv:   "执行环境:",
+	LLMInstructions:      "项目说明（来自 .aicli.md，请遵循其中的团队约定）:",
 	LLMUserPromptIntro:   "将以下自然语言描述转换为命令:",
 	LLMStdinData:         "标准输入数据:",
 	LLMTruncated:         "... (已截断)",
 	LLMContextNoContext:  "无执行上下文",
 	LLMContextFormat:     "OS: %s, Shell: %s, 工作目录: %s",
+	LLMCandidatesPrompt:  "本次请求覆盖规则 1 和 2：请给出 %d 个不同的候选命令。只返回一个 JSON 数组，每个元素形如 {\"command\": \"...\", \"explanation\": \"一行说明\"}，按推荐程度从高到低排列。",
+	LLMExplainPrompt:     "你是一名 shell 专家。请逐个解释用户给出的命令中的每个程序、子命令、选项、参数和操作符（管道、重定向、&& 等），使用中文回答。只返回一个 JSON 对象: {\"summary\": \"<一句话说明命令的作用>\", \"parts\": [{\"token\": \"<命令片段>\", \"meaning\": \"<含义>\"}]}，不要使用 markdown 代码块。",
+	LLMExplainUserPrompt: "请解释以下命令:",
+	LLMFixPrompt:         "该命令执行失败，退出码为 %d。错误输出（stderr）如下:\n%s\n请修正命令，使其完成最初的需求，并按之前相同的格式返回。",
+	LLMFixNoStderr:       "（无）",
+	LLMPlanPrompt:        "本次请求覆盖规则 1 和 2：该任务需要多条相互依赖的命令。请将其拆分为最多 %d 个有序步骤。只返回一个 JSON 数组，每个元素形如 {\"description\": \"该步骤的作用\", \"command\": \"...\", \"uses_output\": 命令是否依赖前面步骤的输出(true/false)}，不要使用 markdown 代码块。",
+	LLMPlanStepPrompt:    "上述计划正在逐步执行。前面步骤的输出:\n%s\n请给出第 %d 步（%s）的最终命令，原计划为: %s。只返回这一条命令，格式与系统提示词中的要求相同。",
+	LLMPlanStepOutput:    "第 %d 步的输出（%s）:\n%s",
+
+	// 标准输入摘要
+	LLMStdinSummary:   "标准输入过大，以下是摘要（格式: %s，%d 字节，%d 行）:",
+	LLMStdinBinary:    "标准输入是二进制数据（%d 字节），未发送内容",
+	LLMStdinSchema:    "结构",
+	LLMStdinFields:    "字段",
+	LLMStdinColumns:   "列（%d）",
+	LLMStdinDelimiter: "分隔符",
+	LLMStdinLevels:    "日志级别",
+	LLMStdinHead:      "开头",
+	LLMStdinTail:      "末尾",
+	LLMStdinOmitted:   "...（省略 %d 行）",
 
 	// Cobra 命令描述
 	CobraUse:   "aicli [自然语言描述]",
@@ -139,12 +229,35 @@ var messagesZh = map[string]string{
 	CobraFlagHistory:     "显示历史记录",
 	CobraFlagRetry:       "重新执行历史命令 ID",
 	CobraFlagQuiet:       "静默模式,不显示翻译后的命令",
+	CobraFlagCandidates:  "生成 N 个候选命令并交互选择",
+	CobraFlagContinue:    "在上一条命令的基础上追问修改（将上次对话发送给 LLM）",
+	CobraFlagNoCache:     "跳过命令缓存，重新请求 LLM",
+	CobraFlagShowPrompt:  "打印渲染后的提示词，不调用 LLM",
+	CobraFlagPlan:        "将任务拆分为多个步骤，审阅后依次执行",
+	CobraFlagTimeout:     "命令执行超时（秒），覆盖 execution.timeout",
+	CobraFlagShell:       "执行命令使用的 Shell（bash、zsh、fish、nu 等），覆盖 execution.shell",
+	CobraFlagTTY:         "在伪终端中执行命令（vim、less 等交互式程序会被自动识别）",
+
+	CobraFlagNoInstructions: "不读取 .aicli.md 项目说明文件",
 
 	// Init 命令
 	InitUse:   "init",
 	InitShort: "初始化配置",
 	InitLong:  "引导用户设置 LLM 配置并生成配置文件 ~/.aicli.json",
 
+	// Explain 命令
+	ExplainUse:       "explain <命令>",
+	ExplainShort:     "逐个参数解释 shell 命令",
+	ExplainLong:      "让 LLM 按配置的界面语言逐个参数解释 shell 命令，并显示本地安全检查的结果。\n\n示例:\n  aicli explain 'tar -xzvf a.tgz -C /tmp'\n  aicli explain < deploy.sh",
+	ExplainFlagShell: "命令所用的 Shell（bash、zsh、fish、nu 等），覆盖 execution.shell",
+
+	// Stats 命令
+	StatsUse:        "stats",
+	StatsShort:      "查看使用统计",
+	StatsUsageShort: "按日期、提供商和模型汇总 token 用量和费用",
+	StatsUsageLong:  "按日期、提供商和模型汇总历史记录中的 token 用量。费用按每次请求时配置文件中的单价表（pricing）计算。",
+	StatsFlagDays:   "统计最近多少天（0 表示全部历史）",
+
 	// Completion 命令
 	CompletionShort: "为指定的 shell 生成自动补全脚本",
 
@@ -162,5 +275,7 @@ var messagesZh = map[string]string{
 	InitProviderAnthropic: "2. Anthropic (Claude)",
 	InitProviderLocal:     "3. Local (Ollama, LocalAI)",
 	InitProviderDeepSeek:  "4. DeepSeek (深度求索)",
-	InitProviderOther:     "5. Other (兼容 OpenAI 协议)",
+	InitProviderGemini:    "5. Google Gemini",
+	InitProviderAzure:     "6. Azure OpenAI",
+	InitProviderOther:     "7. Other (兼容 OpenAI 协议)",
 }
@@ -24,6 +24,13 @@ type AnthropicProvider struct {
 	model   string
 	baseURL string
 	client  *http.Client
+	retry   RetryPolicy
+
+	// tools 为 true 时通过 emit_command 工具调用返回命令
+	tools bool
+
+	// maxTokens 最大输出 token 数（0 表示 DefaultMaxTokens）
+	maxTokens int
 }
 
 // anthropicRequest 表示 Anthropic API 请求体
@@ -32,6 +39,23 @@ type anthropicRequest struct {
 	Messages  []anthropicMessage `json:"messages"`
 	MaxTokens int                `json:"max_tokens"`
 	System    string             `json:"system,omitempty"`
+	Stream    bool               `json:"stream,omitempty"`
+
+	Tools      []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice *anthropicToolChoice `json:"tool_choice,omitempty"`
+}
+
+// anthropicTool 表示 Anthropic 请求中声明的工具
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// anthropicToolChoice 表示强制模型调用指定工具
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 // anthropicMessage 表示 Anthropic 消息
@@ -43,9 +67,41 @@ type anthropicMessage struct {
 // anthropicResponse 表示 Anthropic API 响应体
 type anthropicResponse struct {
 	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
 	} `json:"content"`
+	Usage *anthropicUsage `json:"usage"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// anthropicUsage 表示 Anthropic API 返回的 token 用量
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicStreamEvent 表示 Anthropic 流式响应中的一个事件
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Delta *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta"`
+	ContentBlock *struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"content_block"`
+	Message *struct {
+		Usage *anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage *anthropicUsage `json:"usage"`
 	Error *struct {
 		Type    string `json:"type"`
 		Message string `json:"message"`
@@ -65,6 +121,8 @@ func NewAnthropicProvider(apiKey, model, baseURL string) *AnthropicProvider {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: DefaultRetryPolicy(),
+		tools: true,
 	}
 }
 
@@ -73,40 +131,196 @@ func (p *AnthropicProvider) Name() string {
 	return providerAnthropic
 }
 
+// SetRetryPolicy 设置 HTTP 请求的重试策略
+func (p *AnthropicProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
+
+// SetMaxTokens 设置单条命令回复的最大输出 token 数
+func (p *AnthropicProvider) SetMaxTokens(n int) {
+	p.maxTokens = n
+}
+
+// SetToolCalling 设置是否通过工具调用返回命令
+func (p *AnthropicProvider) SetToolCalling(enabled bool) {
+	p.tools = enabled
+}
+
 // Translate 将自然语言转换为命令
-func (p *AnthropicProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+func (p *AnthropicProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
 	if input == "" {
-		return "", fmt.Errorf("输入不能为空")
+		return nil, fmt.Errorf("输入不能为空")
 	}
 
-	// 构建提示词
-	prompt := BuildPrompt(input, execCtx)
-	systemPrompt := GetSystemPrompt(execCtx)
+	completion, err := p.complete(ctx, BuildMessages(input, execCtx), p.tools)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// Complete 发送对话消息并返回模型的原始文本回复
+func (p *AnthropicProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	return p.complete(ctx, messages, false)
+}
+
+// complete 发送非流式请求，tools 为 true 时要求模型调用 emit_command 工具
+func (p *AnthropicProvider) complete(ctx context.Context, messages []Message, tools bool) (*Completion, error) {
+	resp, err := p.send(ctx, messages, false, tools)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+	}
+
+	// 解析响应
+	var apiResp anthropicResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+	}
+
+	// 验证响应
+	if len(apiResp.Content) == 0 {
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
+	}
+
+	completion := &Completion{Model: p.model}
+	if apiResp.Usage != nil {
+		completion.Usage = newUsage(apiResp.Usage.InputTokens, apiResp.Usage.OutputTokens)
+	}
 
+	// 提取文本内容和工具调用参数
+	for _, content := range apiResp.Content {
+		switch {
+		case content.Type == "text" && completion.Content == "":
+			completion.Content = strings.TrimSpace(content.Text)
+		case content.Type == "tool_use" && content.Name == emitCommandToolName && completion.ToolArguments == "":
+			completion.ToolArguments = string(content.Input)
+		}
+	}
+
+	return completion, nil
+}
+
+// TranslateStream 以流式方式将自然语言转换为命令
+func (p *AnthropicProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	resp, err := p.send(ctx, BuildMessages(input, execCtx), true, p.tools)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	onChunk = filterCommandStream(onChunk)
+
+	var sb, args strings.Builder
+	var inputTokens, outputTokens int
+	toolBlock := -1
+	err = readSSE(resp.Body, func(data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+		}
+
+		switch event.Type {
+		case "error":
+			errMsg := event.Type
+			if event.Error != nil {
+				errMsg = event.Error.Message
+			}
+			return fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), errMsg)
+		case "message_start":
+			if event.Message != nil && event.Message.Usage != nil {
+				inputTokens = event.Message.Usage.InputTokens
+			}
+		case "message_delta":
+			if event.Usage != nil {
+				outputTokens = event.Usage.OutputTokens
+			}
+		case "content_block_start":
+			if event.ContentBlock != nil && event.ContentBlock.Type == "tool_use" &&
+				event.ContentBlock.Name == emitCommandToolName && toolBlock < 0 {
+				toolBlock = event.Index
+			}
+		case "content_block_delta":
+			if event.Delta == nil {
+				return nil
+			}
+			switch {
+			case event.Delta.Type == "text_delta" && event.Delta.Text != "":
+				sb.WriteString(event.Delta.Text)
+				if onChunk != nil {
+					onChunk(event.Delta.Text)
+				}
+			case event.Delta.Type == "input_json_delta" && event.Index == toolBlock && event.Delta.PartialJSON != "":
+				args.WriteString(event.Delta.PartialJSON)
+				if onChunk != nil {
+					onChunk(event.Delta.PartialJSON)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(&Completion{
+		Content:       strings.TrimSpace(sb.String()),
+		Usage:         newUsage(inputTokens, outputTokens),
+		Model:         p.model,
+		ToolArguments: args.String(),
+	})
+}
+
+// send 构建并发送请求，返回状态码为 200 的响应
+// Anthropic 的系统提示词通过独立的 system 字段传递
+func (p *AnthropicProvider) send(ctx context.Context, messages []Message, stream, tools bool) (*http.Response, error) {
 	// 构建请求体
 	reqBody := anthropicRequest{
 		Model:     p.model,
-		MaxTokens: 500,
-		System:    systemPrompt,
-		Messages: []anthropicMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+		MaxTokens: maxTokensFor(ctx, p.maxTokens),
+		Stream:    stream,
+	}
+	for _, msg := range messages {
+		if msg.Role == RoleSystem {
+			if reqBody.System != "" {
+				reqBody.System += "\n\n"
+			}
+			reqBody.System += msg.Content
+			continue
+		}
+		reqBody.Messages = append(reqBody.Messages, anthropicMessage{Role: msg.Role, Content: msg.Content})
+	}
+	if tools {
+		reqBody.Tools = []anthropicTool{{
+			Name:        emitCommandToolName,
+			Description: emitCommandToolDescription,
+			InputSchema: emitCommandSchema(),
+		}}
+		reqBody.ToolChoice = &anthropicToolChoice{Type: "tool", Name: emitCommandToolName}
 	}
 
 	// 序列化请求体
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
 	}
 
 	// 创建 HTTP 请求
 	url := p.baseURL + "/messages"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
 	}
 
 	// 设置请求头
@@ -115,55 +329,23 @@ func (p *AnthropicProvider) Translate(ctx context.Context, input string, execCtx
 	req.Header.Set("anthropic-version", "2023-06-01")
 
 	// 发送请求
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
+	resp, err := p.retry.Do(p.client, req)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
 		var errResp anthropicResponse
 		json.Unmarshal(body, &errResp)
-		errMsg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
 		if errResp.Error != nil {
-			errMsg = fmt.Sprintf("%s: %s", errMsg, errResp.Error.Message)
-		}
-		return "", fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), errMsg)
-	}
-
-	// 解析响应
-	var apiResp anthropicResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
-	}
-
-	// 验证响应
-	if len(apiResp.Content) == 0 {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyResponse))
-	}
-
-	// 提取文本内容
-	command := ""
-	for _, content := range apiResp.Content {
-		if content.Type == "text" {
-			command = strings.TrimSpace(content.Text)
-			break
+			statusErr.Message = errResp.Error.Message
 		}
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIError), statusErr)
 	}
 
-	if command == "" {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyCommandResp))
-	}
-
-	// 清理命令（移除可能的 markdown 代码块标记）
-	command = cleanCommand(command)
-
-	return command, nil
+	return resp, nil
 }
@@ -61,14 +61,14 @@ func TestAnthropicProvider_Translate_Success(t *testing.T) {
 		WorkDir: "/home/user",
 	}
 
-	command, err := provider.Translate(ctx, "列出当前目录所有文件", execCtx)
+	result, err := provider.Translate(ctx, "列出当前目录所有文件", execCtx)
 
 	// 验证结果
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
-	if command != commandLsla {
-		t.Errorf("期望命令 'ls -la', 实际为 '%s'", command)
+	if result.Command != commandLsla {
+		t.Errorf("期望命令 'ls -la', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -115,13 +115,13 @@ func TestAnthropicProvider_Translate_WithStdin(t *testing.T) {
 		Stdin:   "log file content with ERROR messages",
 	}
 
-	command, err := provider.Translate(ctx, "查找错误日志", execCtx)
+	result, err := provider.Translate(ctx, "查找错误日志", execCtx)
 
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
-	if command != commandGrepError {
-		t.Errorf("期望命令 'grep ERROR', 实际为 '%s'", command)
+	if result.Command != commandGrepError {
+		t.Errorf("期望命令 'grep ERROR', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -230,3 +230,81 @@ func TestAnthropicProvider_Name(t *testing.T) {
 		t.Errorf("期望名称 'anthropic', 实际为 '%s'", provider.Name())
 	}
 }
+
+// TestAnthropicProvider_TranslateStream 测试流式命令转换
+func TestAnthropicProvider_TranslateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: message_start\ndata: {\"type\":\"message_start\"}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"ls\"}}\n\n"))
+		w.Write([]byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\" -la\"}}\n\n"))
+		w.Write([]byte("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-api-key", "claude-3-sonnet-20240229", server.URL)
+
+	var streamed string
+	result, err := provider.TranslateStream(context.Background(), "列出文件", &ExecutionContext{OS: "linux"}, func(chunk string) {
+		streamed += chunk
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != commandLsla {
+		t.Errorf("期望命令 'ls -la', 实际为 '%s'", result.Command)
+	}
+	if streamed != commandLsla {
+		t.Errorf("期望增量输出 'ls -la', 实际为 '%s'", streamed)
+	}
+}
+
+// TestAnthropicProvider_TranslateStream_ErrorEvent 测试流中的错误事件
+func TestAnthropicProvider_TranslateStream_ErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-api-key", "claude-3-sonnet-20240229", server.URL)
+
+	_, err := provider.TranslateStream(context.Background(), "列出文件", nil, nil)
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+}
+
+// TestAnthropicProvider_MaxTokens 测试请求使用配置的最大输出 token 数，多步回复放宽上限
+func TestAnthropicProvider_MaxTokens(t *testing.T) {
+	var received anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"[{\"command\":\"ls\"}]"}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-api-key", "claude-3", server.URL)
+	if _, err := provider.Complete(context.Background(), []Message{{Role: RoleUser, Content: "list"}}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if received.MaxTokens != DefaultMaxTokens {
+		t.Errorf("max_tokens = %d, 期望 %d", received.MaxTokens, DefaultMaxTokens)
+	}
+
+	provider.SetMaxTokens(800)
+	if _, err := provider.Complete(context.Background(), []Message{{Role: RoleUser, Content: "list"}}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if received.MaxTokens != 800 {
+		t.Errorf("max_tokens = %d, 期望 800", received.MaxTokens)
+	}
+
+	if _, err := TranslatePlan(context.Background(), provider, "list", nil); err != nil {
+		t.Fatalf("TranslatePlan() error = %v", err)
+	}
+	if received.MaxTokens != multiStepMaxTokens {
+		t.Errorf("计划请求的 max_tokens = %d, 期望 %d", received.MaxTokens, multiStepMaxTokens)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/studyzy/aicli/pkg/i18n"
 )
 
 const (
@@ -24,6 +26,10 @@ const (
 // 使用硬编码的API配置，用户无需配置即可试用
 type BuiltinProvider struct {
 	client *http.Client
+	retry  RetryPolicy
+
+	// maxTokens 最大输出 token 数（0 表示 DefaultMaxTokens）
+	maxTokens int
 }
 
 // NewBuiltinProvider 创建一个新的内置试用 Provider
@@ -32,6 +38,7 @@ func NewBuiltinProvider() *BuiltinProvider {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: DefaultRetryPolicy(),
 	}
 }
 
@@ -40,62 +47,42 @@ func (p *BuiltinProvider) Name() string {
 	return providerBuiltin
 }
 
-// Translate 将自然语言转换为命令
-func (p *BuiltinProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
-	if input == "" {
-		return "", fmt.Errorf("输入不能为空")
-	}
-
-	// 构建提示词
-	prompt := BuildPrompt(input, execCtx)
-	systemPrompt := GetSystemPrompt(execCtx)
+// SetRetryPolicy 设置 HTTP 请求的重试策略
+func (p *BuiltinProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
 
-	// 构建请求体（使用 OpenAI 兼容格式）
-	reqBody := map[string]interface{}{
-		"model": builtinModel,
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": systemPrompt,
-			},
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"temperature": 0.3,
-		"max_tokens":  500,
-	}
+// SetMaxTokens 设置单条命令回复的最大输出 token 数
+func (p *BuiltinProvider) SetMaxTokens(n int) {
+	p.maxTokens = n
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+// Translate 将自然语言转换为命令
+func (p *BuiltinProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
 	}
 
-	// 创建请求
-	req, err := http.NewRequestWithContext(ctx, "POST", builtinAPIBase+"/chat/completions", bytes.NewBuffer(jsonData))
+	completion, err := p.Complete(ctx, BuildMessages(input, execCtx))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+builtinAPIKey)
+	return newTranslationResult(completion)
+}
 
-	// 发送请求
-	resp, err := p.client.Do(req)
+// Complete 发送对话消息并返回模型的原始文本回复
+func (p *BuiltinProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	resp, err := p.send(ctx, messages, false)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	// 读取响应
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// 解析响应
@@ -105,6 +92,7 @@ func (p *BuiltinProvider) Translate(ctx context.Context, input string, execCtx *
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
 		Error *struct {
 			Message string `json:"message"`
 			Type    string `json:"type"`
@@ -112,25 +100,83 @@ func (p *BuiltinProvider) Translate(ctx context.Context, input string, execCtx *
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
 
 	if result.Error != nil {
-		return "", fmt.Errorf("API error: %s", result.Error.Message)
+		return nil, fmt.Errorf("API error: %s", result.Error.Message)
 	}
 
 	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("no response from API")
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
 	}
 
-	command := strings.TrimSpace(result.Choices[0].Message.Content)
+	return &Completion{
+		Content: strings.TrimSpace(result.Choices[0].Message.Content),
+		Usage:   result.Usage.toUsage(),
+		Model:   builtinModel,
+	}, nil
+}
+
+// TranslateStream 以流式方式将自然语言转换为命令（OpenAI 兼容的 SSE 格式）
+func (p *BuiltinProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
 
-	// 清理可能的 markdown 代码块格式
-	command = strings.TrimPrefix(command, "```bash")
-	command = strings.TrimPrefix(command, "```sh")
-	command = strings.TrimPrefix(command, "```")
-	command = strings.TrimSuffix(command, "```")
-	command = strings.TrimSpace(command)
+	resp, err := p.send(ctx, BuildMessages(input, execCtx), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion, err := readOpenAIStream(resp.Body, filterCommandStream(onChunk))
+	if err != nil {
+		return nil, err
+	}
+	completion.Model = builtinModel
+
+	return newTranslationResult(completion)
+}
+
+// send 构建并发送请求，返回状态码为 200 的响应
+func (p *BuiltinProvider) send(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
+	// 构建请求体（使用 OpenAI 兼容格式）
+	reqBody := map[string]interface{}{
+		"model":       builtinModel,
+		"messages":    toOpenAIMessages(messages),
+		"temperature": 0.3,
+		"max_tokens":  maxTokensFor(ctx, p.maxTokens),
+	}
+	if stream {
+		reqBody["stream"] = true
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// 创建请求
+	req, err := http.NewRequestWithContext(ctx, "POST", builtinAPIBase+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+builtinAPIKey)
+
+	// 发送请求
+	resp, err := p.retry.Do(p.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API request failed: %w", &StatusError{StatusCode: resp.StatusCode, Message: string(body)})
+	}
 
-	return command, nil
+	return resp, nil
 }
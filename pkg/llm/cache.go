@@ -0,0 +1,339 @@
+// Package llm 提供命令转换结果的磁盘缓存功能
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// PromptVersion 提示词版本，修改提示词格式时递增，使旧的缓存失效
+// 内置提示词的文字也会计入缓存键（见 builtinPromptText），修改规则措辞不需要手动递增
+const PromptVersion = "2"
+
+// builtinPromptText 返回不含执行环境和用户输入的内置提示词，其摘要写入缓存键（测试中可替换）
+var builtinPromptText = func() string {
+	return builtinSystemPrompt(nil) + "\x00" + builtinUserPrompt("", nil)
+}
+
+// cacheFileExt 缓存文件扩展名
+const cacheFileExt = ".json"
+
+// Cache 是基于磁盘的命令转换结果缓存
+// 每个条目保存为缓存目录下的一个 JSON 文件，文件名为缓存键
+type Cache struct {
+	dir        string
+	ttl        time.Duration
+	maxEntries int
+}
+
+// cacheEntry 表示磁盘上的一个缓存条目
+type cacheEntry struct {
+	CreatedAt time.Time          `json:"created_at"`
+	Result    *TranslationResult `json:"result"`
+}
+
+// NewCache 创建一个磁盘缓存
+// dir: 缓存目录
+// ttl: 条目有效期（0 表示永不过期）
+// maxEntries: 最大条目数（0 表示不限制），超出时删除最旧的条目
+func NewCache(dir string, ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		dir:        dir,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+}
+
+// DefaultCacheDir 返回默认缓存目录（用户缓存目录下的 aicli/translations）
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "aicli", "translations"), nil
+}
+
+// Get 读取缓存条目，不存在或已过期时返回 false
+func (c *Cache) Get(key string) (*TranslationResult, bool) {
+	path := c.path(key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Result == nil {
+		os.Remove(path)
+		return nil, false
+	}
+
+	if c.ttl > 0 && time.Since(entry.CreatedAt) > c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+
+	return entry.Result, true
+}
+
+// Put 写入缓存条目，并在超出数量限制时清理最旧的条目
+func (c *Cache) Put(key string, result *TranslationResult) error {
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	data, err := json.Marshal(cacheEntry{CreatedAt: time.Now(), Result: result})
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %w", err)
+	}
+
+	if err := os.WriteFile(c.path(key), data, 0600); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+
+	return c.prune()
+}
+
+// Clear 删除所有缓存条目
+func (c *Cache) Clear() error {
+	files, err := c.files()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		os.Remove(filepath.Join(c.dir, f.Name()))
+	}
+	return nil
+}
+
+// prune 删除超出数量限制的最旧条目
+func (c *Cache) prune() error {
+	if c.maxEntries <= 0 {
+		return nil
+	}
+
+	files, err := c.files()
+	if err != nil || len(files) <= c.maxEntries {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, f := range files[:len(files)-c.maxEntries] {
+		os.Remove(filepath.Join(c.dir, f.Name()))
+	}
+
+	return nil
+}
+
+// files 列出缓存目录中的所有缓存文件
+func (c *Cache) files() ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取缓存目录失败: %w", err)
+	}
+
+	files := make([]os.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), cacheFileExt) {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			files = append(files, info)
+		}
+	}
+	return files, nil
+}
+
+// path 返回缓存键对应的文件路径
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key+cacheFileExt)
+}
+
+// CacheKey 根据输入、执行上下文、模型、提示词版本和自定义模板计算缓存键
+// ignoreWorkDir 为 true 时不同目录下的相同请求共享缓存
+// 环境信息收集器的完整输出（Details）不计入缓存键，改用开销很小的项目摘要（Fingerprint），
+// 这样调用方可以先查询缓存，未命中时再运行耗时的收集器
+func CacheKey(input string, execCtx *ExecutionContext, model string, ignoreWorkDir bool) string {
+	key := struct {
+		Version      string    `json:"v"`
+		Lang         string    `json:"lang"`
+		Model        string    `json:"model"`
+		Input        string    `json:"input"`
+		OS           string    `json:"os,omitempty"`
+		Shell        string    `json:"shell,omitempty"`
+		WorkDir      string    `json:"workdir,omitempty"`
+		Stdin        string    `json:"stdin,omitempty"`
+		StdinSummary string    `json:"stdin_summary,omitempty"`
+		Fingerprint  string    `json:"fingerprint,omitempty"`
+		Instructions string    `json:"instructions,omitempty"`
+		History      []Message `json:"history,omitempty"`
+		Prompt       string    `json:"prompt,omitempty"`
+		Builtin      string    `json:"builtin"`
+	}{
+		Version: PromptVersion,
+		Lang:    i18n.Lang(),
+		Model:   model,
+		Input:   strings.TrimSpace(input),
+		Prompt:  promptFingerprint(),
+		Builtin: fmt.Sprintf("%x", sha256.Sum256([]byte(builtinPromptText()))),
+	}
+
+	if execCtx != nil {
+		key.OS = execCtx.OS
+		key.Shell = execCtx.Shell
+		key.Stdin = execCtx.Stdin
+		key.StdinSummary = execCtx.StdinSummary
+		key.Fingerprint = execCtx.Fingerprint
+		key.Instructions = execCtx.Instructions
+		key.History = execCtx.History
+		if !ignoreWorkDir {
+			key.WorkDir = execCtx.WorkDir
+		}
+	}
+
+	data, _ := json.Marshal(key)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CachedProvider 为任意 Provider 添加磁盘缓存
+// 命中缓存时直接返回，不调用 LLM；未命中时调用被包装的 Provider 并保存成功的结果
+type CachedProvider struct {
+	provider      Provider
+	cache         *Cache
+	model         string
+	ignoreWorkDir bool
+	refresh       bool
+}
+
+// NewCachedProvider 创建带缓存的 Provider
+// model: 参与缓存键计算的模型名称
+func NewCachedProvider(provider Provider, cache *Cache, model string) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		cache:    cache,
+		model:    model,
+	}
+}
+
+// SetIgnoreWorkDir 设置缓存键是否忽略工作目录
+func (p *CachedProvider) SetIgnoreWorkDir(ignore bool) {
+	p.ignoreWorkDir = ignore
+}
+
+// SetRefresh 设置是否跳过缓存读取（仍会用新结果更新缓存）
+func (p *CachedProvider) SetRefresh(refresh bool) {
+	p.refresh = refresh
+}
+
+// Unwrap 返回被包装的 Provider
+func (p *CachedProvider) Unwrap() Provider {
+	return p.provider
+}
+
+// Name 返回被包装的提供商名称
+func (p *CachedProvider) Name() string {
+	return p.provider.Name()
+}
+
+// Translate 优先从缓存读取转换结果
+func (p *CachedProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	key := CacheKey(input, execCtx, p.model, p.ignoreWorkDir)
+	if result, ok := p.lookup(key); ok {
+		return result, nil
+	}
+
+	result, err := p.provider.Translate(ctx, input, execCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.store(key, result)
+	return result, nil
+}
+
+// TranslateStream 优先从缓存读取转换结果，命中时一次性输出命令
+func (p *CachedProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	key := CacheKey(input, execCtx, p.model, p.ignoreWorkDir)
+	if result, ok := p.lookup(key); ok {
+		if onChunk != nil {
+			onChunk(result.Command)
+		}
+		return result, nil
+	}
+
+	var result *TranslationResult
+	var err error
+	if streamer, ok := p.provider.(StreamingProvider); ok {
+		result, err = streamer.TranslateStream(ctx, input, execCtx, onChunk)
+	} else {
+		result, err = p.provider.Translate(ctx, input, execCtx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.store(key, result)
+	return result, nil
+}
+
+// Lookup 只查询缓存，不调用 LLM；execCtx 不需要包含收集器输出
+func (p *CachedProvider) Lookup(input string, execCtx *ExecutionContext) (*TranslationResult, bool) {
+	return p.lookup(CacheKey(input, execCtx, p.model, p.ignoreWorkDir))
+}
+
+// Complete 直接调用被包装的 Provider（对话补全不缓存）
+func (p *CachedProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	completer, ok := AsCompleter(p.provider)
+	if !ok {
+		return nil, errNotCompleter
+	}
+	return completer.Complete(ctx, messages)
+}
+
+// SupportsCompletion 报告被包装的 Provider 是否支持 Complete
+func (p *CachedProvider) SupportsCompletion() bool {
+	_, ok := AsCompleter(p.provider)
+	return ok
+}
+
+// lookup 读取缓存，返回的结果标记为来自缓存且不计 token 用量
+func (p *CachedProvider) lookup(key string) (*TranslationResult, bool) {
+	if p.refresh {
+		return nil, false
+	}
+
+	result, ok := p.cache.Get(key)
+	if !ok || result.Command == "" {
+		return nil, false
+	}
+
+	result.Cached = true
+	result.Usage = Usage{}
+	return result, true
+}
+
+// store 保存转换结果（写入失败不影响命令转换）
+func (p *CachedProvider) store(key string, result *TranslationResult) {
+	if result == nil || result.Command == "" {
+		return
+	}
+	if result.Provider == "" {
+		result.Provider = p.provider.Name()
+	}
+	_ = p.cache.Put(key, result)
+}
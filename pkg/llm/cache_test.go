@@ -0,0 +1,184 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// TestCachedProvider_HitAndMiss 测试缓存命中时不再调用 LLM
+func TestCachedProvider_HitAndMiss(t *testing.T) {
+	calls := 0
+	inner := &MockLLMProvider{
+		ResultFunc: func(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+			calls++
+			return &TranslationResult{Command: "df -h", Usage: newUsage(10, 5)}, nil
+		},
+	}
+
+	cache := NewCache(t.TempDir(), time.Hour, 0)
+	provider := NewCachedProvider(inner, cache, "gpt-4")
+	execCtx := &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/tmp"}
+
+	first, err := provider.Translate(context.Background(), "show disk usage", execCtx)
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if first.Cached {
+		t.Error("first result should not be cached")
+	}
+
+	second, err := provider.Translate(context.Background(), "show disk usage", execCtx)
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("inner provider calls = %d, want 1", calls)
+	}
+	if !second.Cached || second.Command != "df -h" || second.Usage.TotalTokens != 0 {
+		t.Errorf("second result = %+v, want cached df -h without usage", second)
+	}
+
+	// 不同的 Shell 不共享缓存
+	if _, err := provider.Translate(context.Background(), "show disk usage", &ExecutionContext{OS: "linux", Shell: "zsh", WorkDir: "/tmp"}); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("inner provider calls = %d, want 2", calls)
+	}
+
+	// refresh 模式跳过读取
+	provider.SetRefresh(true)
+	if _, err := provider.Translate(context.Background(), "show disk usage", execCtx); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("inner provider calls = %d, want 3", calls)
+	}
+}
+
+// TestCachedProvider_Completer 测试缓存包装器只在被包装的 Provider 支持时报告支持 Complete
+func TestCachedProvider_Completer(t *testing.T) {
+	cache := NewCache(t.TempDir(), time.Hour, 0)
+
+	if _, ok := AsCompleter(NewCachedProvider(&MockLLMProvider{}, cache, "")); !ok {
+		t.Error("被包装的 Provider 支持 Complete 时应报告支持")
+	}
+
+	provider := NewCachedProvider(&translateOnlyProvider{}, cache, "")
+	if _, ok := AsCompleter(provider); ok {
+		t.Error("被包装的 Provider 不支持 Complete 时不应报告支持")
+	}
+	candidates, err := TranslateCandidates(context.Background(), provider, "list", nil, 3)
+	if err != nil || len(candidates) != 1 || candidates[0].Command != "ls" {
+		t.Errorf("TranslateCandidates() = %v, %v", candidates, err)
+	}
+}
+
+// TestCachedProvider_Stream 测试流式模式命中缓存时一次性输出命令
+func TestCachedProvider_Stream(t *testing.T) {
+	inner := &MockLLMProvider{TranslateFn: func(input string) string { return "uptime" }}
+	provider := NewCachedProvider(inner, NewCache(t.TempDir(), 0, 0), "")
+
+	execCtx := &ExecutionContext{OS: "linux", Shell: "bash"}
+	if _, err := provider.TranslateStream(context.Background(), "how long running", execCtx, nil); err != nil {
+		t.Fatalf("TranslateStream() error = %v", err)
+	}
+
+	var chunks []string
+	result, err := provider.TranslateStream(context.Background(), "how long running", execCtx, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("TranslateStream() error = %v", err)
+	}
+	if !result.Cached || len(chunks) != 1 || chunks[0] != "uptime" {
+		t.Errorf("result = %+v, chunks = %v, want cached uptime", result, chunks)
+	}
+}
+
+// TestCacheKey 测试缓存键的计算
+func TestCacheKey(t *testing.T) {
+	base := &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/a"}
+	other := &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/b"}
+
+	if CacheKey("ls", base, "gpt-4", false) == CacheKey("ls", other, "gpt-4", false) {
+		t.Error("different WorkDir should produce different keys")
+	}
+	if CacheKey("ls", base, "gpt-4", true) != CacheKey("ls", other, "gpt-4", true) {
+		t.Error("ignoreWorkDir should produce identical keys")
+	}
+	if CacheKey("ls", base, "gpt-4", false) == CacheKey("ls", base, "gpt-3.5", false) {
+		t.Error("different model should produce different keys")
+	}
+	if CacheKey("ls", base, "gpt-4", false) == CacheKey("ls", &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/a", Stdin: "x"}, "gpt-4", false) {
+		t.Error("different stdin should produce different keys")
+	}
+	if CacheKey("ls", base, "gpt-4", false) != CacheKey("ls", &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/a", Details: "- jq 1.7"}, "gpt-4", false) {
+		t.Error("collector details should not affect the key")
+	}
+	goRepo := &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/a", Fingerprint: "project: Go module example.com/a"}
+	nodeRepo := &ExecutionContext{OS: "linux", Shell: "bash", WorkDir: "/b", Fingerprint: "project: Node.js package b"}
+	if CacheKey("run the tests", goRepo, "gpt-4", true) == CacheKey("run the tests", nodeRepo, "gpt-4", true) {
+		t.Error("different project fingerprints should produce different keys even with ignoreWorkDir")
+	}
+}
+
+// TestCacheKey_PromptText 测试内置提示词的文字变化时缓存键随之变化
+func TestCacheKey_PromptText(t *testing.T) {
+	execCtx := &ExecutionContext{OS: "linux", Shell: "fish"}
+
+	for _, key := range []string{i18n.LLMSystemPromptRule3, i18n.LLMSystemPromptRule4, i18n.LLMUserPromptIntro} {
+		if !strings.Contains(builtinPromptText(), i18n.T(key)) {
+			t.Errorf("缓存键使用的内置提示词缺少 %s", key)
+		}
+	}
+
+	before := CacheKey("ls", execCtx, "gpt-4", false)
+	original := builtinPromptText
+	defer func() { builtinPromptText = original }()
+	builtinPromptText = func() string { return original() + "\n- 新规则" }
+
+	if CacheKey("ls", execCtx, "gpt-4", false) == before {
+		t.Error("修改内置提示词后缓存键应变化")
+	}
+}
+
+// TestCache_TTLAndMaxEntries 测试过期和数量限制
+func TestCache_TTLAndMaxEntries(t *testing.T) {
+	dir := t.TempDir()
+
+	cache := NewCache(dir, time.Millisecond, 0)
+	if err := cache.Put("expired", &TranslationResult{Command: "ls"}); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := cache.Get("expired"); ok {
+		t.Error("expired entry should not be returned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "expired.json")); !os.IsNotExist(err) {
+		t.Error("expired entry should be removed")
+	}
+
+	cache = NewCache(dir, 0, 2)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := cache.Put(key, &TranslationResult{Command: "echo " + key}); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		// 保证修改时间不同
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	files, _ := cache.files()
+	if len(files) != 2 {
+		t.Errorf("cache entries = %d, want 2", len(files))
+	}
+	if _, ok := cache.Get("c"); !ok {
+		t.Error("newest entry should be kept")
+	}
+}
@@ -0,0 +1,127 @@
+// Package llm 提供多候选命令生成功能
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// MaxCandidates 候选命令的最大数量（对应数字键 1-9）
+const MaxCandidates = 9
+
+// Candidate 表示一个候选命令
+type Candidate struct {
+	// Command 候选命令
+	Command string `json:"command"`
+
+	// Explanation 一行说明
+	Explanation string `json:"explanation"`
+
+	// Provider 生成该候选的提供商名称
+	Provider string `json:"-"`
+
+	// Model 生成该候选的模型名称
+	Model string `json:"-"`
+
+	// Usage 生成候选列表的请求的 token 用量（所有候选共享同一次请求）
+	Usage Usage `json:"-"`
+}
+
+// Result 将候选命令转换为翻译结果
+func (c Candidate) Result() *TranslationResult {
+	return &TranslationResult{Command: c.Command, Explanation: c.Explanation, Provider: c.Provider, Model: c.Model, Usage: c.Usage}
+}
+
+// TranslateCandidates 请求 Provider 生成 n 个候选命令
+// Provider 未实现 Completer 接口或 n <= 1 时回退到 Translate，只返回一个候选
+func TranslateCandidates(ctx context.Context, p Provider, input string, execCtx *ExecutionContext, n int) ([]Candidate, error) {
+	if n > MaxCandidates {
+		n = MaxCandidates
+	}
+
+	completer, ok := AsCompleter(p)
+	if !ok || n <= 1 {
+		result, err := p.Translate(ctx, input, execCtx)
+		if err != nil {
+			return nil, err
+		}
+		return []Candidate{{Command: result.Command, Explanation: result.Explanation, Provider: result.Provider, Model: result.Model, Usage: result.Usage}}, nil
+	}
+
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	// 多个候选的 JSON 数组比单条命令长得多，放宽输出上限避免被截断
+	completion, err := completer.Complete(withMinMaxTokens(ctx, multiStepMaxTokens), BuildCandidatesMessages(input, execCtx, n))
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := parseCandidates(completion.Content, n)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, newEmptyResponseError(i18n.ErrEmptyCommandResp)
+	}
+	for i := range candidates {
+		candidates[i].Provider = completion.Provider
+		candidates[i].Model = completion.Model
+		candidates[i].Usage = completion.Usage
+	}
+
+	return candidates, nil
+}
+
+// parseCandidates 解析模型返回的候选命令列表
+// 期望格式为 JSON 数组；回复不是数组时把整段回复当作单个命令，
+// 以 [ 开头但无法解析（如输出达到 max_tokens 被截断）时返回错误，避免把 JSON 文本当作候选命令
+func parseCandidates(text string, n int) ([]Candidate, error) {
+	var parsed []Candidate
+
+	body := stripCodeFence(strings.TrimSpace(text))
+	start := strings.Index(body, "[")
+	end := strings.LastIndex(body, "]")
+	if start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(body[start:end+1]), &parsed); err != nil {
+			parsed = nil
+		}
+	}
+
+	if parsed == nil {
+		if strings.HasPrefix(body, "[") {
+			return nil, fmt.Errorf("%s", i18n.T(i18n.ErrInvalidListResp))
+		}
+		result, err := parseTranslation(text)
+		if err != nil {
+			return nil, err
+		}
+		if result.Command != "" {
+			return []Candidate{{Command: result.Command, Explanation: result.Explanation}}, nil
+		}
+		return nil, nil
+	}
+
+	// 清理、去重并限制数量
+	seen := make(map[string]bool)
+	result := make([]Candidate, 0, len(parsed))
+	for _, c := range parsed {
+		c.Command = cleanCommand(c.Command)
+		c.Explanation = strings.TrimSpace(c.Explanation)
+		if c.Command == "" || seen[c.Command] {
+			continue
+		}
+		seen[c.Command] = true
+		result = append(result, c)
+		if len(result) >= n {
+			break
+		}
+	}
+
+	return result, nil
+}
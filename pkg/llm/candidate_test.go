@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestParseCandidates 测试候选命令解析
+func TestParseCandidates(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		n    int
+		want []string
+	}{
+		{
+			name: "JSON 数组",
+			text: `[{"command":"find . -name '*.log'","explanation":"使用 find"},{"command":"fd -e log","explanation":"使用 fd"}]`,
+			n:    3,
+			want: []string{"find . -name '*.log'", "fd -e log"},
+		},
+		{
+			name: "markdown 代码块包裹",
+			text: "```json\n[{\"command\":\"ls -la\",\"explanation\":\"列出\"}]\n```",
+			n:    3,
+			want: []string{"ls -la"},
+		},
+		{
+			name: "去重并限制数量",
+			text: `[{"command":"ls"},{"command":"ls"},{"command":"ls -a"},{"command":"ls -l"}]`,
+			n:    2,
+			want: []string{"ls", "ls -a"},
+		},
+		{
+			name: "非 JSON 回退为单个命令",
+			text: "ls -la",
+			n:    3,
+			want: []string{"ls -la"},
+		},
+		{
+			name: "空回复",
+			text: "   ",
+			n:    3,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCandidates(tt.text, tt.n)
+			if err != nil {
+				t.Fatalf("parseCandidates() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseCandidates() 返回 %d 个候选, 期望 %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i].Command != tt.want[i] {
+					t.Errorf("候选 %d = %q, 期望 %q", i, got[i].Command, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestParseCandidates_Truncated 测试被截断的 JSON 数组返回错误，而不是作为候选命令
+func TestParseCandidates_Truncated(t *testing.T) {
+	text := `[{"command":"find . -name '*.log'","explanation":"使用 find"},{"command":"fd -e`
+	if got, err := parseCandidates(text, 3); err == nil {
+		t.Errorf("parseCandidates() 应返回错误, 实际 %v", got)
+	}
+	// 模型退回单个对象但被截断时同样返回错误
+	if got, err := parseCandidates(`{"command":"fd -e log","expla`, 3); err == nil {
+		t.Errorf("截断的 JSON 对象应返回错误, 实际 %v", got)
+	}
+
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			if limit := maxTokensFor(ctx, DefaultMaxTokens); limit != multiStepMaxTokens {
+				t.Errorf("候选请求的最大输出 token 数 = %d, 期望 %d", limit, multiStepMaxTokens)
+			}
+			return &Completion{Content: text}, nil
+		},
+	}
+	if got, err := TranslateCandidates(context.Background(), provider, "查找日志", nil, 3); err == nil {
+		t.Errorf("TranslateCandidates() 应返回错误, 实际 %v", got)
+	}
+}
+
+// TestTranslateCandidates 测试通过 Completer 生成候选命令
+func TestTranslateCandidates(t *testing.T) {
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			if !strings.Contains(messages[0].Content, "3") {
+				t.Errorf("系统提示词应包含候选数量, 实际为: %s", messages[0].Content)
+			}
+			return &Completion{Content: `[{"command":"find . -name '*.go'","explanation":"find"},{"command":"fd -e go","explanation":"fd"}]`}, nil
+		},
+	}
+
+	candidates, err := TranslateCandidates(context.Background(), provider, "查找 go 文件", &ExecutionContext{}, 3)
+	if err != nil {
+		t.Fatalf("TranslateCandidates() 失败: %v", err)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("期望 2 个候选, 实际为 %d", len(candidates))
+	}
+	if candidates[1].Explanation != "fd" {
+		t.Errorf("期望说明为 'fd', 实际为 %q", candidates[1].Explanation)
+	}
+}
+
+// TestTranslateCandidates_FallbackToTranslate 测试 n<=1 时回退到 Translate
+func TestTranslateCandidates_FallbackToTranslate(t *testing.T) {
+	provider := &MockLLMProvider{
+		TranslateFn: func(input string) string {
+			return "echo " + input
+		},
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			t.Error("n<=1 时不应调用 Complete")
+			return nil, nil
+		},
+	}
+
+	candidates, err := TranslateCandidates(context.Background(), provider, "hi", nil, 1)
+	if err != nil {
+		t.Fatalf("TranslateCandidates() 失败: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Command != "echo hi" {
+		t.Errorf("期望单个候选 'echo hi', 实际为 %v", candidates)
+	}
+}
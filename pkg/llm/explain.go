@@ -0,0 +1,112 @@
+// Package llm 提供命令解释功能（命令 → 自然语言）
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// Explanation 表示对一条命令的解释
+type Explanation struct {
+	// Summary 一句话说明命令的作用
+	Summary string `json:"summary"`
+
+	// Parts 逐个片段（程序、子命令、参数、操作符）的解释
+	Parts []ExplanationPart `json:"parts"`
+
+	// Usage token 用量
+	Usage Usage `json:"-"`
+
+	// Provider 实际返回结果的提供商名称
+	Provider string `json:"-"`
+
+	// Model 实际使用的模型
+	Model string `json:"-"`
+}
+
+// ExplanationPart 表示命令中一个片段的含义
+type ExplanationPart struct {
+	// Token 命令片段，如 "-x" 或 "| grep"
+	Token string `json:"token"`
+
+	// Meaning 片段的含义
+	Meaning string `json:"meaning"`
+}
+
+// BuildExplainMessages 构建解释命令的对话消息
+// 系统提示词要求模型按界面语言逐个参数解释命令，并返回 JSON
+func BuildExplainMessages(command string, ctx *ExecutionContext) []Message {
+	var sb strings.Builder
+	sb.WriteString(i18n.T(i18n.LLMExplainPrompt) + "\n")
+
+	if ctx != nil {
+		sb.WriteString("\n" + i18n.T(i18n.LLMSystemPromptEnv) + "\n")
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelOS), ctx.OS))
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelShell), ctx.Shell))
+	}
+
+	return []Message{
+		{Role: RoleSystem, Content: sb.String()},
+		{Role: RoleUser, Content: i18n.T(i18n.LLMExplainUserPrompt) + "\n" + command},
+	}
+}
+
+// Explain 请求 Provider 解释一条命令
+// Provider 需要实现 Completer 接口
+func Explain(ctx context.Context, p Provider, command string, execCtx *ExecutionContext) (*Explanation, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil, fmt.Errorf("%s", i18n.T(i18n.ErrInputEmpty))
+	}
+
+	completer, ok := AsCompleter(p)
+	if !ok {
+		return nil, fmt.Errorf("%s", i18n.T(i18n.ErrExplainUnsupported, p.Name()))
+	}
+
+	completion, err := completer.Complete(ctx, BuildExplainMessages(command, execCtx))
+	if err != nil {
+		return nil, err
+	}
+
+	explanation := parseExplanation(completion.Content)
+	if explanation.Summary == "" && len(explanation.Parts) == 0 {
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
+	}
+	explanation.Usage = completion.Usage
+	explanation.Provider = completion.Provider
+	explanation.Model = completion.Model
+
+	return explanation, nil
+}
+
+// parseExplanation 解析模型返回的解释
+// 期望格式为 JSON 对象，解析失败时把整段回复作为说明
+func parseExplanation(text string) *Explanation {
+	body := stripCodeFence(strings.TrimSpace(text))
+
+	start := strings.Index(body, "{")
+	end := strings.LastIndex(body, "}")
+	if start >= 0 && end > start {
+		var parsed Explanation
+		if err := json.Unmarshal([]byte(body[start:end+1]), &parsed); err == nil {
+			parsed.Summary = strings.TrimSpace(parsed.Summary)
+			parts := parsed.Parts[:0]
+			for _, part := range parsed.Parts {
+				part.Token = strings.TrimSpace(part.Token)
+				part.Meaning = strings.TrimSpace(part.Meaning)
+				if part.Token != "" {
+					parts = append(parts, part)
+				}
+			}
+			parsed.Parts = parts
+			return &parsed
+		}
+	}
+
+	return &Explanation{Summary: body}
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestParseExplanation 测试命令解释解析
+func TestParseExplanation(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		summary   string
+		wantParts []string
+	}{
+		{
+			name:      "JSON 对象",
+			text:      `{"summary":"解压归档","parts":[{"token":"tar","meaning":"归档工具"},{"token":"-x","meaning":"解压"}]}`,
+			summary:   "解压归档",
+			wantParts: []string{"tar", "-x"},
+		},
+		{
+			name:      "markdown 代码块包裹并带前后说明",
+			text:      "```json\n说明如下 {\"summary\":\"列出文件\",\"parts\":[{\"token\":\" ls \",\"meaning\":\"列出\"},{\"token\":\"\",\"meaning\":\"空片段\"}]}\n```",
+			summary:   "列出文件",
+			wantParts: []string{"ls"},
+		},
+		{
+			name:    "非 JSON 回退为概述",
+			text:    "这个命令列出当前目录下的文件",
+			summary: "这个命令列出当前目录下的文件",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseExplanation(tt.text)
+			if got.Summary != tt.summary {
+				t.Errorf("Summary = %q, 期望 %q", got.Summary, tt.summary)
+			}
+			if len(got.Parts) != len(tt.wantParts) {
+				t.Fatalf("返回 %d 个片段, 期望 %d: %v", len(got.Parts), len(tt.wantParts), got.Parts)
+			}
+			for i, token := range tt.wantParts {
+				if got.Parts[i].Token != token {
+					t.Errorf("片段 %d = %q, 期望 %q", i, got.Parts[i].Token, token)
+				}
+			}
+		})
+	}
+}
+
+// TestExplain 测试通过 Completer 解释命令
+func TestExplain(t *testing.T) {
+	var received []Message
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			received = messages
+			return &Completion{
+				Content: `{"summary":"解压","parts":[{"token":"-C /tmp","meaning":"解压到 /tmp"}]}`,
+				Usage:   newUsage(10, 5),
+			}, nil
+		},
+	}
+
+	got, err := Explain(context.Background(), provider, "tar -xzvf a.tgz -C /tmp", &ExecutionContext{OS: "linux", Shell: "bash"})
+	if err != nil {
+		t.Fatalf("Explain() error = %v", err)
+	}
+	if got.Summary != "解压" || len(got.Parts) != 1 || got.Parts[0].Token != "-C /tmp" {
+		t.Errorf("Explain() = %+v", got)
+	}
+	if got.Usage.TotalTokens != 15 {
+		t.Errorf("Usage.TotalTokens = %d, 期望 15", got.Usage.TotalTokens)
+	}
+
+	if len(received) != 2 || received[0].Role != RoleSystem || received[1].Role != RoleUser {
+		t.Fatalf("消息结构不正确: %+v", received)
+	}
+	if !strings.Contains(received[1].Content, "tar -xzvf a.tgz -C /tmp") {
+		t.Errorf("用户消息应包含命令: %q", received[1].Content)
+	}
+	if !strings.Contains(received[0].Content, "linux") {
+		t.Errorf("系统消息应包含操作系统: %q", received[0].Content)
+	}
+}
+
+// explainOnlyProvider 只实现 Provider 接口，不支持 Complete
+type explainOnlyProvider struct{}
+
+func (explainOnlyProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (explainOnlyProvider) Name() string { return "plain" }
+
+// TestExplain_Errors 测试解释命令的错误情况
+func TestExplain_Errors(t *testing.T) {
+	if _, err := Explain(context.Background(), NewMockProvider(), "  ", nil); err == nil {
+		t.Error("空命令应返回错误")
+	}
+
+	if _, err := Explain(context.Background(), explainOnlyProvider{}, "ls", nil); err == nil {
+		t.Error("不支持 Complete 的提供商应返回错误")
+	}
+
+	empty := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			return &Completion{Content: "  "}, nil
+		},
+	}
+	_, err := Explain(context.Background(), empty, "ls", nil)
+	var emptyErr *EmptyResponseError
+	if !errors.As(err, &emptyErr) {
+		t.Errorf("空回复应返回 EmptyResponseError, got %v", err)
+	}
+}
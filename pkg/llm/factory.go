@@ -4,6 +4,7 @@ package llm
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/studyzy/aicli/pkg/config"
 )
@@ -17,12 +18,98 @@ const (
 
 // NewProvider 根据配置创建对应的 Provider
 // 这是工厂函数，根据配置中的 Provider 类型创建相应的实现
-func NewProvider(cfg *config.Config) (Provider, error) {
+// 配置了备用提供商时返回按顺序故障转移的 FallbackProvider
+// 返回的 Provider 被中间件包装：错误脱敏（最外层）、调用方传入的 middlewares，
+// 以及启用日志时每个提供商各自的日志中间件（这样故障转移前的失败也会被记录）
+func NewProvider(cfg *config.Config, middlewares ...Middleware) (Provider, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("配置不能为空")
 	}
 
-	providerType := strings.ToLower(cfg.LLM.Provider)
+	logger, err := newLoggerFromConfig(cfg.Logging)
+	if err != nil {
+		return nil, err
+	}
+
+	var provider Provider
+	if len(cfg.LLM.Fallbacks) == 0 {
+		provider, err = newProviderFromConfig(cfg.LLM, logger)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		chain := cfg.LLM.Chain()
+		entries := make([]FallbackEntry, 0, len(chain))
+		for _, llmCfg := range chain {
+			p, err := newProviderFromConfig(llmCfg, logger)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, FallbackEntry{
+				Provider: p,
+				Timeout:  time.Duration(llmCfg.Timeout) * time.Second,
+			})
+		}
+		provider = NewFallbackProvider(entries...)
+	}
+
+	return Chain(provider, append([]Middleware{ErrorMaskingMiddleware()}, middlewares...)...), nil
+}
+
+// newProviderFromConfig 根据单个 LLM 配置创建 Provider，并应用重试策略和日志
+func newProviderFromConfig(cfg config.LLMConfig, logger *Logger) (Provider, error) {
+	provider, err := newBaseProvider(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if r, ok := provider.(retryConfigurable); ok {
+		r.SetRetryPolicy(newRetryPolicy(cfg))
+	}
+	if t, ok := provider.(toolConfigurable); ok {
+		t.SetToolCalling(!cfg.DisableTools)
+	}
+	if m, ok := provider.(maxTokensConfigurable); ok {
+		m.SetMaxTokens(cfg.MaxTokens)
+	}
+
+	return LoggingMiddleware(logger, cfg.Model)(provider), nil
+}
+
+// retryConfigurable 表示支持设置重试策略的 Provider
+type retryConfigurable interface {
+	SetRetryPolicy(policy RetryPolicy)
+}
+
+// toolConfigurable 表示支持通过工具调用返回命令的 Provider
+type toolConfigurable interface {
+	SetToolCalling(enabled bool)
+}
+
+// maxTokensConfigurable 表示请求中带有最大输出 token 数的 Provider
+// OpenAI 兼容接口和 Ollama 不设置输出上限（部分 OpenAI 模型不接受 max_tokens 参数）
+type maxTokensConfigurable interface {
+	SetMaxTokens(n int)
+}
+
+// newRetryPolicy 根据配置创建重试策略
+// MaxRetries 为 0 时使用默认值，为负数时不重试
+func newRetryPolicy(cfg config.LLMConfig) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if cfg.MaxRetries < 0 {
+		policy.MaxRetries = 0
+	} else if cfg.MaxRetries > 0 {
+		policy.MaxRetries = cfg.MaxRetries
+	}
+	if cfg.RetryBackoff > 0 {
+		policy.Backoff = time.Duration(cfg.RetryBackoff) * time.Millisecond
+	}
+	return policy
+}
+
+// newBaseProvider 根据提供商类型创建 Provider
+func newBaseProvider(cfg config.LLMConfig) (Provider, error) {
+	providerType := strings.ToLower(cfg.Provider)
 
 	switch providerType {
 	case providerBuiltin:
@@ -37,6 +124,12 @@ func NewProvider(cfg *config.Config) (Provider, error) {
 	case providerLocal, "ollama":
 		return newLocalModelFromConfig(cfg)
 
+	case providerGemini:
+		return newGeminiFromConfig(cfg)
+
+	case providerAzure:
+		return newAzureFromConfig(cfg)
+
 	case providerMock:
 		// Mock provider 用于测试
 		return &MockLLMProvider{
@@ -46,51 +139,80 @@ func NewProvider(cfg *config.Config) (Provider, error) {
 		}, nil
 
 	default:
-		return nil, fmt.Errorf("不支持的 LLM 提供商: %s", cfg.LLM.Provider)
+		return nil, fmt.Errorf("不支持的 LLM 提供商: %s", cfg.Provider)
 	}
 }
 
 // newOpenAIFromConfig 从配置创建 OpenAI Provider
-func newOpenAIFromConfig(cfg *config.Config) (Provider, error) {
+func newOpenAIFromConfig(cfg config.LLMConfig) (Provider, error) {
 	// 内置 provider 不需要 API Key
-	if cfg.LLM.Provider == providerBuiltin {
+	if cfg.Provider == providerBuiltin {
 		return NewBuiltinProvider(), nil
 	}
 	
-	if cfg.LLM.APIKey == "" {
+	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("OpenAI API 密钥未配置")
 	}
 
-	model := cfg.LLM.Model
+	model := cfg.Model
 	if model == "" {
 		model = "gpt-4" // 默认模型
 	}
 
-	return NewOpenAIProvider(cfg.LLM.APIKey, model, cfg.LLM.APIBase), nil
+	return NewOpenAIProvider(cfg.APIKey, model, cfg.APIBase), nil
 }
 
 // newAnthropicFromConfig 从配置创建 Anthropic Provider
-func newAnthropicFromConfig(cfg *config.Config) (Provider, error) {
-	if cfg.LLM.APIKey == "" {
+func newAnthropicFromConfig(cfg config.LLMConfig) (Provider, error) {
+	if cfg.APIKey == "" {
 		return nil, fmt.Errorf("anthropic API 密钥未配置")
 	}
 
-	model := cfg.LLM.Model
+	model := cfg.Model
 	if model == "" {
 		model = "claude-3-sonnet-20240229" // 默认模型
 	}
 
-	return NewAnthropicProvider(cfg.LLM.APIKey, model, cfg.LLM.APIBase), nil
+	return NewAnthropicProvider(cfg.APIKey, model, cfg.APIBase), nil
+}
+
+// newAzureFromConfig 从配置创建 Azure OpenAI Provider
+func newAzureFromConfig(cfg config.LLMConfig) (Provider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("azure OpenAI API 密钥未配置")
+	}
+	if cfg.APIBase == "" {
+		return nil, fmt.Errorf("azure OpenAI 资源地址 (api_base) 未配置")
+	}
+	if cfg.Deployment == "" {
+		return nil, fmt.Errorf("azure OpenAI 部署名称 (deployment) 未配置")
+	}
+
+	return NewAzureOpenAIProvider(cfg.APIKey, cfg.APIBase, cfg.Deployment, cfg.APIVersion), nil
+}
+
+// newGeminiFromConfig 从配置创建 Gemini Provider
+func newGeminiFromConfig(cfg config.LLMConfig) (Provider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("gemini API 密钥未配置")
+	}
+
+	model := cfg.Model
+	if model == "" {
+		model = "gemini-1.5-flash" // 默认模型
+	}
+
+	return NewGeminiProvider(cfg.APIKey, model, cfg.APIBase), nil
 }
 
 // newLocalModelFromConfig 从配置创建本地模型 Provider
-func newLocalModelFromConfig(cfg *config.Config) (Provider, error) {
-	model := cfg.LLM.Model
+func newLocalModelFromConfig(cfg config.LLMConfig) (Provider, error) {
+	model := cfg.Model
 	if model == "" {
 		model = "llama2" // 默认模型
 	}
 
-	baseURL := cfg.LLM.APIBase
+	baseURL := cfg.APIBase
 	if baseURL == "" {
 		baseURL = defaultOllamaURL // Ollama 默认地址
 	}
@@ -107,6 +229,8 @@ func GetSupportedProviders() []string {
 		"claude",
 		"local",
 		"ollama",
+		"gemini",
+		"azure",
 		"mock",
 	}
 }
@@ -122,6 +122,64 @@ func TestNewProvider_LocalModel_AliasOllama(t *testing.T) {
 	}
 }
 
+// TestNewProvider_Gemini 测试创建 Gemini Provider
+func TestNewProvider_Gemini(t *testing.T) {
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			Provider: providerGemini,
+			APIKey:   "test-key",
+		},
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("创建 Gemini Provider 失败: %v", err)
+	}
+
+	gemini, ok := FindProvider[*GeminiProvider](provider)
+	if !ok {
+		t.Fatalf("期望 *GeminiProvider, 实际为 %T", provider)
+	}
+	if gemini.model != "gemini-1.5-flash" {
+		t.Errorf("期望默认模型为 'gemini-1.5-flash', 实际为 '%s'", gemini.model)
+	}
+
+	cfg.LLM.APIKey = ""
+	if _, err := NewProvider(cfg); err == nil {
+		t.Error("缺少 API Key 时应该返回错误")
+	}
+}
+
+// TestNewProvider_Azure 测试创建 Azure OpenAI Provider
+func TestNewProvider_Azure(t *testing.T) {
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			Provider:   providerAzure,
+			APIKey:     "test-key",
+			APIBase:    "https://my-resource.openai.azure.com",
+			Deployment: "gpt-4o",
+		},
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("创建 Azure Provider 失败: %v", err)
+	}
+
+	azure, ok := FindProvider[*OpenAIProvider](provider)
+	if !ok || !azure.azure {
+		t.Fatalf("期望 Azure 模式的 *OpenAIProvider, 实际为 %T", provider)
+	}
+	if azure.apiVersion != defaultAzureAPIVersion {
+		t.Errorf("期望默认 API 版本 %s, 实际为 %s", defaultAzureAPIVersion, azure.apiVersion)
+	}
+
+	cfg.LLM.Deployment = ""
+	if _, err := NewProvider(cfg); err == nil {
+		t.Error("缺少部署名称时应该返回错误")
+	}
+}
+
 // TestNewProvider_Mock 测试创建 Mock Provider
 func TestNewProvider_Mock(t *testing.T) {
 	cfg := &config.Config{
@@ -236,6 +294,8 @@ func TestGetSupportedProviders(t *testing.T) {
 		"openai":    true,
 		"anthropic": true,
 		"local":     true,
+		"gemini":    true,
+		"azure":     true,
 		"mock":      true,
 	}
 
@@ -305,3 +365,71 @@ func TestNewProvider_CaseInsensitive(t *testing.T) {
 		})
 	}
 }
+
+// TestNewProvider_Fallbacks 测试配置备用提供商时创建 FallbackProvider
+func TestNewProvider_Fallbacks(t *testing.T) {
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			Provider: providerLocal,
+			Model:    "llama2",
+			Timeout:  10,
+			Fallbacks: []config.LLMConfig{
+				{Provider: providerOpenAI, APIKey: "test-key"},
+				{Provider: providerBuiltin},
+			},
+		},
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("创建 FallbackProvider 失败: %v", err)
+	}
+
+	fallback, ok := FindProvider[*FallbackProvider](provider)
+	if !ok {
+		t.Fatalf("期望 *FallbackProvider, 实际为 %T", provider)
+	}
+	if fallback.Name() != "local,openai,builtin" {
+		t.Errorf("期望 Provider 名称为 'local,openai,builtin', 实际为 '%s'", fallback.Name())
+	}
+	for _, entry := range fallback.entries {
+		if entry.Timeout.Seconds() != 10 {
+			t.Errorf("%s 超时 = %v, 期望继承主提供商的 10s", entry.Provider.Name(), entry.Timeout)
+		}
+	}
+
+	// 备用提供商配置无效时返回错误
+	cfg.LLM.Fallbacks = []config.LLMConfig{{Provider: providerOpenAI}}
+	if _, err := NewProvider(cfg); err == nil {
+		t.Error("备用提供商缺少 API Key 时应该返回错误")
+	}
+}
+
+// TestNewProvider_DisableTools 测试 disable_tools 关闭工具调用
+func TestNewProvider_DisableTools(t *testing.T) {
+	cfg := &config.Config{
+		LLM: config.LLMConfig{
+			Provider:     providerOpenAI,
+			APIKey:       "test-key",
+			Model:        "gpt-4",
+			DisableTools: true,
+		},
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("创建 Provider 失败: %v", err)
+	}
+	if openai, _ := FindProvider[*OpenAIProvider](provider); openai.tools {
+		t.Error("期望 disable_tools 关闭工具调用")
+	}
+
+	cfg.LLM.DisableTools = false
+	provider, err = NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("创建 Provider 失败: %v", err)
+	}
+	if openai, _ := FindProvider[*OpenAIProvider](provider); !openai.tools {
+		t.Error("期望默认开启工具调用")
+	}
+}
@@ -0,0 +1,190 @@
+// Package llm 提供多个 Provider 之间的故障转移功能
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// FallbackEntry 表示故障转移链中的一个提供商
+type FallbackEntry struct {
+	// Provider 提供商实例
+	Provider Provider
+
+	// Timeout 单个提供商的请求超时（0 表示只受调用方上下文限制）
+	Timeout time.Duration
+}
+
+// FallbackProvider 按顺序尝试多个 Provider
+// 遇到连接错误、超时、5xx/429 或空响应时切换到下一个，其他错误（如认证失败）直接返回
+type FallbackProvider struct {
+	entries    []FallbackEntry
+	onFallback func(from, to string, err error)
+}
+
+// NewFallbackProvider 创建一个故障转移 Provider
+func NewFallbackProvider(entries ...FallbackEntry) *FallbackProvider {
+	return &FallbackProvider{entries: entries}
+}
+
+// SetFallbackHandler 设置切换提供商时的回调（用于提示用户）
+func (p *FallbackProvider) SetFallbackHandler(fn func(from, to string, err error)) {
+	p.onFallback = fn
+}
+
+// Name 返回提供商名称（按尝试顺序列出所有提供商）
+func (p *FallbackProvider) Name() string {
+	names := make([]string, 0, len(p.entries))
+	for _, entry := range p.entries {
+		names = append(names, entry.Provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Translate 依次尝试各个提供商将自然语言转换为命令
+func (p *FallbackProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	var result *TranslationResult
+	err := p.try(ctx, func(ctx context.Context, provider Provider) error {
+		r, err := provider.Translate(ctx, input, execCtx)
+		if err != nil {
+			return err
+		}
+		if r.Provider == "" {
+			r.Provider = provider.Name()
+		}
+		result = r
+		return nil
+	})
+	return result, err
+}
+
+// TranslateStream 依次尝试各个提供商，以流式方式转换命令
+// 不支持流式输出的提供商使用 Translate
+// 提供商可能输出部分内容后才失败，因此除最后一个提供商外，输出先缓冲，成功后再交给 onChunk，
+// 避免失败的输出和下一个提供商的输出拼接在一起
+func (p *FallbackProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	var result *TranslationResult
+	attempt := 0
+	err := p.try(ctx, func(ctx context.Context, provider Provider) error {
+		attempt++
+		var chunks []string
+		emit := func(chunk string) { chunks = append(chunks, chunk) }
+		if onChunk == nil || attempt == len(p.entries) {
+			emit = onChunk
+		}
+
+		var r *TranslationResult
+		var err error
+		if streamer, ok := provider.(StreamingProvider); ok {
+			r, err = streamer.TranslateStream(ctx, input, execCtx, emit)
+		} else {
+			r, err = provider.Translate(ctx, input, execCtx)
+		}
+		if err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			onChunk(chunk)
+		}
+		if r.Provider == "" {
+			r.Provider = provider.Name()
+		}
+		result = r
+		return nil
+	})
+	return result, err
+}
+
+// Complete 依次尝试实现了 Completer 接口的提供商
+func (p *FallbackProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	var completion *Completion
+	err := p.try(ctx, func(ctx context.Context, provider Provider) error {
+		completer, ok := AsCompleter(provider)
+		if !ok {
+			return errNotCompleter
+		}
+		c, err := completer.Complete(ctx, messages)
+		if err != nil {
+			return err
+		}
+		if c.Provider == "" {
+			c.Provider = provider.Name()
+		}
+		completion = c
+		return nil
+	})
+	return completion, err
+}
+
+// SupportsCompletion 报告是否有提供商支持 Complete
+func (p *FallbackProvider) SupportsCompletion() bool {
+	for _, entry := range p.entries {
+		if _, ok := AsCompleter(entry.Provider); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// errNotCompleter 表示提供商不支持 Complete（直接跳到下一个）
+var errNotCompleter = errors.New("provider does not support completion")
+
+// try 依次调用 call，直到成功或遇到不应切换的错误
+func (p *FallbackProvider) try(ctx context.Context, call func(ctx context.Context, provider Provider) error) error {
+	if len(p.entries) == 0 {
+		return fmt.Errorf("没有可用的 LLM 提供商")
+	}
+
+	var err error
+	for i, entry := range p.entries {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if entry.Timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, entry.Timeout)
+		}
+		err = call(attemptCtx, entry.Provider)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		err = fmt.Errorf("%s: %w", entry.Provider.Name(), err)
+
+		// 调用方已取消或超时，或者错误不是暂时性的，不再尝试后续提供商
+		if ctx.Err() != nil || !shouldFallback(err) || i == len(p.entries)-1 {
+			return err
+		}
+
+		if p.onFallback != nil {
+			p.onFallback(entry.Provider.Name(), p.entries[i+1].Provider.Name(), err)
+		}
+	}
+
+	return err
+}
+
+// shouldFallback 判断错误是否应该切换到下一个提供商
+// 连接错误、超时、5xx/429 和空响应会触发切换
+func shouldFallback(err error) bool {
+	if errors.Is(err, errNotCompleter) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError ||
+			statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
+	var emptyErr *EmptyResponseError
+	if errors.As(err, &emptyErr) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,244 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFallbackProvider_ConnectionError 测试连接失败时切换到下一个提供商
+func TestFallbackProvider_ConnectionError(t *testing.T) {
+	// 关闭的服务器模拟未运行的 Ollama
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	down.Close()
+
+	var switched []string
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: NewLocalModelProvider("llama2", down.URL)},
+		FallbackEntry{Provider: &MockLLMProvider{ProviderName: "backup", TranslateFn: func(input string) string { return "ls -la" }}},
+	)
+	provider.SetFallbackHandler(func(from, to string, err error) {
+		switched = append(switched, from+"->"+to)
+	})
+
+	result, err := provider.Translate(context.Background(), "list files", &ExecutionContext{})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if result.Command != "ls -la" {
+		t.Errorf("Command = %s, want ls -la", result.Command)
+	}
+	if result.Provider != "backup" {
+		t.Errorf("Provider = %s, want backup", result.Provider)
+	}
+	if len(switched) != 1 || switched[0] != "local->backup" {
+		t.Errorf("fallback notifications = %v, want [local->backup]", switched)
+	}
+}
+
+// TestFallbackProvider_ServerErrorAndEmpty 测试 5xx 和空响应触发切换
+func TestFallbackProvider_ServerErrorAndEmpty(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	noRetry := NewOpenAIProvider("key", "gpt-4", failing.URL)
+	noRetry.SetRetryPolicy(RetryPolicy{})
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":""}}]}`))
+	}))
+	defer empty.Close()
+
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"pwd"}}]}`))
+	}))
+	defer ok.Close()
+
+	var switched []string
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: noRetry},
+		FallbackEntry{Provider: NewOpenAIProvider("key", "gpt-4", empty.URL)},
+		FallbackEntry{Provider: NewOpenAIProvider("key", "gpt-4", ok.URL)},
+	)
+	provider.SetFallbackHandler(func(from, to string, err error) {
+		switched = append(switched, from)
+	})
+
+	result, err := provider.Translate(context.Background(), "where am i", &ExecutionContext{})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if result.Command != "pwd" || result.Provider != providerOpenAI {
+		t.Errorf("result = %+v, want pwd from openai", result)
+	}
+	if len(switched) != 2 {
+		t.Errorf("fallback count = %d, want 2", len(switched))
+	}
+}
+
+// TestFallbackProvider_NonRetryableError 测试认证失败等错误不切换提供商
+func TestFallbackProvider_NonRetryableError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+	}))
+	defer server.Close()
+
+	called := false
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: NewOpenAIProvider("bad-key", "gpt-4", server.URL)},
+		FallbackEntry{Provider: &MockLLMProvider{TranslateFn: func(input string) string {
+			called = true
+			return "ls"
+		}}},
+	)
+
+	_, err := provider.Translate(context.Background(), "list files", &ExecutionContext{})
+	if err == nil {
+		t.Fatal("Translate() should return error")
+	}
+	if called {
+		t.Error("401 不应该切换到备用提供商")
+	}
+	if !strings.Contains(err.Error(), "openai") || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("error = %v, should contain provider name and API message", err)
+	}
+}
+
+// TestFallbackProvider_Timeout 测试单个提供商超时后切换
+func TestFallbackProvider_Timeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: NewOpenAIProvider("key", "gpt-4", slow.URL), Timeout: 50 * time.Millisecond},
+		FallbackEntry{Provider: &MockLLMProvider{TranslateFn: func(input string) string { return "date" }}},
+	)
+
+	result, err := provider.Translate(context.Background(), "what time", &ExecutionContext{})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if result.Command != "date" {
+		t.Errorf("Command = %s, want date", result.Command)
+	}
+}
+
+// roundTripFunc 用函数实现 http.RoundTripper
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestFallbackProvider_BuiltinEmptyResponse 测试内置提供商没有返回 choices 时切换到下一个提供商
+func TestFallbackProvider_BuiltinEmptyResponse(t *testing.T) {
+	builtin := NewBuiltinProvider()
+	builtin.SetRetryPolicy(RetryPolicy{})
+	builtin.client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"choices":[]}`)),
+			Request:    req,
+		}, nil
+	})
+
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: builtin},
+		FallbackEntry{Provider: &MockLLMProvider{ProviderName: "backup", TranslateFn: func(input string) string { return "df -h" }}},
+	)
+
+	result, err := provider.Translate(context.Background(), "disk usage", &ExecutionContext{})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if result.Command != "df -h" || result.Provider != "backup" {
+		t.Errorf("result = %+v, want df -h from backup", result)
+	}
+}
+
+// streamingProvider 依次输出 chunks，然后返回 err（err 为空时返回完整命令）
+type streamingProvider struct {
+	name   string
+	chunks []string
+	err    error
+}
+
+func (p *streamingProvider) Name() string { return p.name }
+
+func (p *streamingProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	return p.TranslateStream(ctx, input, execCtx, nil)
+}
+
+func (p *streamingProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	for _, chunk := range p.chunks {
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &TranslationResult{Command: strings.Join(p.chunks, "")}, nil
+}
+
+// TestFallbackProvider_StreamPartialFailure 测试输出部分内容后失败的提供商不会污染下一个提供商的输出
+func TestFallbackProvider_StreamPartialFailure(t *testing.T) {
+	provider := NewFallbackProvider(
+		FallbackEntry{Provider: &streamingProvider{name: "broken", chunks: []string{"rm -"}, err: &StatusError{StatusCode: http.StatusBadGateway}}},
+		FallbackEntry{Provider: &streamingProvider{name: "backup", chunks: []string{"ls", " -la"}}},
+		FallbackEntry{Provider: &MockLLMProvider{TranslateFn: func(input string) string { return "date" }}},
+	)
+
+	var chunks []string
+	result, err := provider.TranslateStream(context.Background(), "list files", &ExecutionContext{}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("TranslateStream() error = %v", err)
+	}
+	if result.Command != "ls -la" || result.Provider != "backup" {
+		t.Errorf("result = %+v, want ls -la from backup", result)
+	}
+	if got := strings.Join(chunks, ""); got != "ls -la" {
+		t.Errorf("streamed output = %q, want only the successful provider's output", got)
+	}
+}
+
+// TestShouldFallback 测试错误分类
+func TestShouldFallback(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"5xx", fmt.Errorf("api: %w", &StatusError{StatusCode: 503}), true},
+		{"429", &StatusError{StatusCode: 429}, true},
+		{"401", &StatusError{StatusCode: 401}, false},
+		{"empty", &EmptyResponseError{Message: "empty"}, true},
+		{"deadline", fmt.Errorf("x: %w", context.DeadlineExceeded), true},
+		{"other", errors.New("parse error"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldFallback(tt.err); got != tt.want {
+				t.Errorf("shouldFallback(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
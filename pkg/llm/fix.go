@@ -0,0 +1,70 @@
+// Package llm 提供失败命令的修正功能
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// MaxFixStderrBytes 发送给模型的错误输出最大字节数，超出时只保留末尾部分
+const MaxFixStderrBytes = 2048
+
+// FailedCommand 描述一条以非零退出码结束的命令
+type FailedCommand struct {
+	// Command 执行失败的命令
+	Command string
+
+	// ExitCode 命令退出码
+	ExitCode int
+
+	// Stderr 命令的标准错误输出
+	Stderr string
+}
+
+// BuildFixMessages 构建请求修正命令的对话消息
+// 在原始对话之后附上失败的命令、退出码和截断后的错误输出
+func BuildFixMessages(input string, ctx *ExecutionContext, failed *FailedCommand) []Message {
+	stderr := strings.TrimSpace(tailString(failed.Stderr, MaxFixStderrBytes))
+	if stderr == "" {
+		stderr = i18n.T(i18n.LLMFixNoStderr)
+	}
+
+	messages := BuildMessages(input, ctx)
+	return append(messages,
+		Message{Role: RoleAssistant, Content: failed.Command},
+		Message{Role: RoleUser, Content: i18n.T(i18n.LLMFixPrompt, failed.ExitCode, stderr)},
+	)
+}
+
+// Fix 请求 Provider 根据失败信息给出修正后的命令
+// Provider 需要实现 Completer 接口
+func Fix(ctx context.Context, p Provider, input string, execCtx *ExecutionContext, failed *FailedCommand) (*TranslationResult, error) {
+	completer, ok := AsCompleter(p)
+	if !ok {
+		return nil, fmt.Errorf("%s", i18n.T(i18n.ErrFixUnsupported, p.Name()))
+	}
+
+	completion, err := completer.Complete(ctx, BuildFixMessages(input, execCtx, failed))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// tailString 返回字符串末尾不超过 max 字节的部分（不截断 UTF-8 字符），被截断时以 "..." 开头
+func tailString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+
+	cut := len(s) - max
+	for cut < len(s) && !utf8.RuneStart(s[cut]) {
+		cut++
+	}
+	return "..." + s[cut:]
+}
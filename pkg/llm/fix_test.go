@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"context"
+	"testing"
+)
+
+// TestBuildFixMessages 测试修正命令的消息结构
+func TestBuildFixMessages(t *testing.T) {
+	failed := &FailedCommand{
+		Command:  "tar -xf a.tgz",
+		ExitCode: 2,
+		Stderr:   "tar: a.tgz: Cannot open",
+	}
+
+	messages := BuildFixMessages("解压 a.tgz", &ExecutionContext{OS: "linux", Shell: "bash"}, failed)
+	if len(messages) != 4 {
+		t.Fatalf("期望 4 条消息, 实际为 %d", len(messages))
+	}
+
+	roles := []string{RoleSystem, RoleUser, RoleAssistant, RoleUser}
+	for i, role := range roles {
+		if messages[i].Role != role {
+			t.Errorf("消息 %d 角色 = %s, 期望 %s", i, messages[i].Role, role)
+		}
+	}
+	if messages[2].Content != failed.Command {
+		t.Errorf("assistant 消息应为失败的命令, 实际为 %q", messages[2].Content)
+	}
+
+	if messages[3].Content == "" {
+		t.Error("修正请求不应为空")
+	}
+}
+
+// TestFix 测试通过 Completer 获取修正命令
+func TestFix(t *testing.T) {
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			return &Completion{Content: `{"command":"tar -xzf a.tgz","explanation":"解压 gzip 归档"}`}, nil
+		},
+	}
+
+	result, err := Fix(context.Background(), provider, "解压 a.tgz", nil, &FailedCommand{Command: "tar -xf a.tgz", ExitCode: 2})
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if result.Command != "tar -xzf a.tgz" {
+		t.Errorf("Fix() command = %q", result.Command)
+	}
+
+	if _, err := Fix(context.Background(), explainOnlyProvider{}, "ls", nil, &FailedCommand{Command: "ls"}); err == nil {
+		t.Error("不支持 Complete 的提供商应返回错误")
+	}
+}
+
+// TestTailString 测试按字节保留字符串末尾
+func TestTailString(t *testing.T) {
+	if got := tailString("short", 10); got != "short" {
+		t.Errorf("tailString() = %q", got)
+	}
+	if got := tailString("abcdef", 3); got != "...def" {
+		t.Errorf("tailString() = %q", got)
+	}
+	// 不应截断多字节字符
+	if got := tailString("中文错误", 5); got != "...误" {
+		t.Errorf("tailString() = %q", got)
+	}
+}
@@ -0,0 +1,326 @@
+// Package llm 提供了 Google Gemini LLM 提供商的实现
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+const (
+	providerGemini = "gemini"
+
+	// geminiRoleModel Gemini 中 assistant 角色的名称
+	geminiRoleModel = "model"
+)
+
+// GeminiProvider 实现了 Google Gemini generateContent API 的 LLMProvider 接口
+type GeminiProvider struct {
+	apiKey  string
+	model   string
+	baseURL string
+	client  *http.Client
+	retry   RetryPolicy
+
+	// maxTokens 最大输出 token 数（0 表示 DefaultMaxTokens）
+	maxTokens int
+}
+
+// geminiRequest 表示 Gemini API 请求体
+type geminiRequest struct {
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	Contents          []geminiContent        `json:"contents"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+// geminiContent 表示 Gemini 的一条消息
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiPart 表示消息中的一个文本片段
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+// geminiGenerationConfig 表示生成参数
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens"`
+}
+
+// geminiResponse 表示 Gemini API 响应体（流式响应的每个事件格式相同）
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+// geminiBlockedReasons 表示回复被安全策略拦截的 finishReason
+var geminiBlockedReasons = map[string]bool{
+	"SAFETY":             true,
+	"RECITATION":         true,
+	"BLOCKLIST":          true,
+	"PROHIBITED_CONTENT": true,
+	"SPII":               true,
+}
+
+// NewGeminiProvider 创建一个新的 Gemini Provider
+func NewGeminiProvider(apiKey, model, baseURL string) *GeminiProvider {
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+
+	return &GeminiProvider{
+		apiKey:  apiKey,
+		model:   model,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		retry: DefaultRetryPolicy(),
+	}
+}
+
+// Name 返回提供商名称
+func (p *GeminiProvider) Name() string {
+	return providerGemini
+}
+
+// SetRetryPolicy 设置 HTTP 请求的重试策略
+func (p *GeminiProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
+
+// SetMaxTokens 设置单条命令回复的最大输出 token 数
+func (p *GeminiProvider) SetMaxTokens(n int) {
+	p.maxTokens = n
+}
+
+// Translate 将自然语言转换为命令
+func (p *GeminiProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	completion, err := p.Complete(ctx, BuildMessages(input, execCtx))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// Complete 发送对话消息并返回模型的原始文本回复
+func (p *GeminiProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	resp, err := p.send(ctx, messages, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+	}
+
+	// 解析响应
+	var apiResp geminiResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+	}
+
+	text, err := p.extractText(&apiResp)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" {
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
+	}
+
+	completion := &Completion{Content: strings.TrimSpace(text), Model: p.model}
+	if apiResp.UsageMetadata != nil {
+		completion.Usage = newUsage(apiResp.UsageMetadata.PromptTokenCount, apiResp.UsageMetadata.CandidatesTokenCount)
+	}
+
+	return completion, nil
+}
+
+// TranslateStream 以流式方式将自然语言转换为命令
+func (p *GeminiProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	resp, err := p.send(ctx, BuildMessages(input, execCtx), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	onChunk = filterCommandStream(onChunk)
+
+	var sb strings.Builder
+	var usage Usage
+	err = readSSE(resp.Body, func(data string) error {
+		var event geminiResponse
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+		}
+
+		text, err := p.extractText(&event)
+		if err != nil {
+			return err
+		}
+		if event.UsageMetadata != nil {
+			usage = newUsage(event.UsageMetadata.PromptTokenCount, event.UsageMetadata.CandidatesTokenCount)
+		}
+		if text == "" {
+			return nil
+		}
+
+		sb.WriteString(text)
+		if onChunk != nil {
+			onChunk(text)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(&Completion{
+		Content: strings.TrimSpace(sb.String()),
+		Usage:   usage,
+		Model:   p.model,
+	})
+}
+
+// extractText 从响应中提取第一个候选的文本
+// 请求或回复被安全策略拦截时返回 TranslationError
+func (p *GeminiProvider) extractText(resp *geminiResponse) (string, error) {
+	if resp.Error != nil {
+		return "", fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), resp.Error.Message)
+	}
+
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		return "", &TranslationError{
+			Provider: p.Name(),
+			Message:  i18n.T(i18n.ErrContentBlocked),
+			Err:      errors.New(resp.PromptFeedback.BlockReason),
+		}
+	}
+
+	if len(resp.Candidates) == 0 {
+		return "", nil
+	}
+
+	candidate := resp.Candidates[0]
+	var sb strings.Builder
+	for _, part := range candidate.Content.Parts {
+		sb.WriteString(part.Text)
+	}
+
+	if sb.Len() == 0 && geminiBlockedReasons[candidate.FinishReason] {
+		return "", &TranslationError{
+			Provider: p.Name(),
+			Message:  i18n.T(i18n.ErrContentBlocked),
+			Err:      errors.New(candidate.FinishReason),
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// send 构建并发送请求，返回状态码为 200 的响应
+// Gemini 的系统提示词通过 systemInstruction 字段传递，assistant 角色名为 model
+func (p *GeminiProvider) send(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
+	// 构建请求体
+	reqBody := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{
+			Temperature:     0.3,
+			MaxOutputTokens: maxTokensFor(ctx, p.maxTokens),
+		},
+	}
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleSystem:
+			if reqBody.SystemInstruction == nil {
+				reqBody.SystemInstruction = &geminiContent{}
+			}
+			reqBody.SystemInstruction.Parts = append(reqBody.SystemInstruction.Parts, geminiPart{Text: msg.Content})
+		case RoleAssistant:
+			reqBody.Contents = append(reqBody.Contents, geminiContent{Role: geminiRoleModel, Parts: []geminiPart{{Text: msg.Content}}})
+		default:
+			reqBody.Contents = append(reqBody.Contents, geminiContent{Role: RoleUser, Parts: []geminiPart{{Text: msg.Content}}})
+		}
+	}
+
+	// 序列化请求体
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
+	}
+
+	// 创建 HTTP 请求
+	endpoint := fmt.Sprintf("%s/models/%s:generateContent", p.baseURL, url.PathEscape(p.model))
+	if stream {
+		endpoint = fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse", p.baseURL, url.PathEscape(p.model))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
+	}
+
+	// 设置请求头
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	// 发送请求
+	resp, err := p.retry.Do(p.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
+	}
+
+	// 检查 HTTP 状态码
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		var errResp geminiResponse
+		json.Unmarshal(body, &errResp)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
+		if errResp.Error != nil {
+			statusErr.Message = errResp.Error.Message
+			if errResp.Error.Status != "" {
+				statusErr.Message = errResp.Error.Status + ": " + errResp.Error.Message
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIError), statusErr)
+	}
+
+	return resp, nil
+}
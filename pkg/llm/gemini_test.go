@@ -0,0 +1,162 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestGeminiProvider_Translate_Success 测试成功的命令转换
+func TestGeminiProvider_Translate_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-flash:generateContent" {
+			t.Errorf("请求路径 = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-goog-api-key") != "test-api-key" {
+			t.Error("期望存在 x-goog-api-key 请求头")
+		}
+
+		var req geminiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("解析请求失败: %v", err)
+		}
+		if req.SystemInstruction == nil || len(req.SystemInstruction.Parts) == 0 {
+			t.Error("系统提示词应放在 systemInstruction 中")
+		}
+		roles := make([]string, 0, len(req.Contents))
+		for _, c := range req.Contents {
+			roles = append(roles, c.Role)
+		}
+		if got := strings.Join(roles, ","); got != "user,model,user" {
+			t.Errorf("消息角色 = %s, 期望 user,model,user", got)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"candidates": [{"content": {"role": "model", "parts": [{"text": "{\"command\": \"ls -la\", \"explanation\": \"list\"}"}]}, "finishReason": "STOP"}],
+			"usageMetadata": {"promptTokenCount": 20, "candidatesTokenCount": 8, "totalTokenCount": 28}
+		}`))
+	}))
+	defer server.Close()
+
+	provider := NewGeminiProvider("test-api-key", "gemini-1.5-flash", server.URL)
+	execCtx := &ExecutionContext{
+		OS:    "linux",
+		Shell: "bash",
+		History: []Message{
+			{Role: RoleUser, Content: "list files"},
+			{Role: RoleAssistant, Content: `{"command":"ls"}`},
+		},
+	}
+
+	result, err := provider.Translate(context.Background(), "include hidden files", execCtx)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != commandLsla || result.Explanation != "list" {
+		t.Errorf("result = %+v, 期望 ls -la", result)
+	}
+	if result.Usage.TotalTokens != 28 {
+		t.Errorf("TotalTokens = %d, 期望 28", result.Usage.TotalTokens)
+	}
+}
+
+// TestGeminiProvider_Translate_Blocked 测试安全策略拦截
+func TestGeminiProvider_Translate_Blocked(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "prompt blocked",
+			body: `{"promptFeedback": {"blockReason": "SAFETY"}}`,
+			want: "SAFETY",
+		},
+		{
+			name: "candidate blocked",
+			body: `{"candidates": [{"content": {"parts": []}, "finishReason": "PROHIBITED_CONTENT"}]}`,
+			want: "PROHIBITED_CONTENT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			provider := NewGeminiProvider("test-api-key", "gemini-1.5-flash", server.URL)
+			_, err := provider.Translate(context.Background(), "do something", &ExecutionContext{})
+
+			var transErr *TranslationError
+			if !errors.As(err, &transErr) {
+				t.Fatalf("期望 TranslationError, 实际为 %v", err)
+			}
+			if transErr.Provider != providerGemini || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, 期望包含 %s", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestGeminiProvider_Translate_APIError 测试 API 错误详情
+func TestGeminiProvider_Translate_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": {"code": 400, "message": "API key not valid", "status": "INVALID_ARGUMENT"}}`))
+	}))
+	defer server.Close()
+
+	provider := NewGeminiProvider("bad-key", "gemini-1.5-flash", server.URL)
+	_, err := provider.Translate(context.Background(), "list files", &ExecutionContext{})
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("期望 StatusError 400, 实际为 %v", err)
+	}
+	if !strings.Contains(err.Error(), "INVALID_ARGUMENT: API key not valid") {
+		t.Errorf("error = %v, 应包含错误详情", err)
+	}
+}
+
+// TestGeminiProvider_TranslateStream 测试流式转换
+func TestGeminiProvider_TranslateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-1.5-flash:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("请求地址 = %s", r.URL.String())
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"ls \"}]}}]}\n\n"))
+		w.Write([]byte("data: {\"candidates\": [{\"content\": {\"parts\": [{\"text\": \"-la\"}]}, \"finishReason\": \"STOP\"}], \"usageMetadata\": {\"promptTokenCount\": 5, \"candidatesTokenCount\": 3}}\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewGeminiProvider("test-api-key", "gemini-1.5-flash", server.URL)
+
+	var chunks []string
+	result, err := provider.TranslateStream(context.Background(), "list files", &ExecutionContext{}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != commandLsla {
+		t.Errorf("Command = %s, 期望 ls -la", result.Command)
+	}
+	if strings.Join(chunks, "") != commandLsla {
+		t.Errorf("chunks = %v", chunks)
+	}
+	if result.Usage.TotalTokens != 8 {
+		t.Errorf("TotalTokens = %d, 期望 8", result.Usage.TotalTokens)
+	}
+}
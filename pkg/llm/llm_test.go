@@ -55,14 +55,14 @@ func TestOpenAIProvider_Translate_Success(t *testing.T) {
 	}
 
 	// 执行转换
-	command, err := provider.Translate(context.Background(), "列出当前目录的所有文件", ctx)
+	result, err := provider.Translate(context.Background(), "列出当前目录的所有文件", ctx)
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
 
 	// 验证结果
-	if command != "ls -la" {
-		t.Errorf("期望命令为 'ls -la', 实际为 '%s'", command)
+	if result.Command != "ls -la" {
+		t.Errorf("期望命令为 'ls -la', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -113,13 +113,55 @@ func TestOpenAIProvider_Translate_WithStdin(t *testing.T) {
 		Stdin:   "test input data\nwith errors\nERROR: something went wrong",
 	}
 
-	command, err := provider.Translate(context.Background(), "过滤出包含ERROR的行", ctx)
+	result, err := provider.Translate(context.Background(), "过滤出包含ERROR的行", ctx)
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
 
-	if command != "grep ERROR" {
-		t.Errorf("期望命令为 'grep ERROR', 实际为 '%s'", command)
+	if result.Command != "grep ERROR" {
+		t.Errorf("期望命令为 'grep ERROR', 实际为 '%s'", result.Command)
+	}
+}
+
+// TestOpenAIProvider_Translate_WithHistory 测试追问模式下对话历史被发送
+func TestOpenAIProvider_Translate_WithHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openAIRequest
+		json.NewDecoder(r.Body).Decode(&reqBody)
+
+		roles := make([]string, 0, len(reqBody.Messages))
+		for _, msg := range reqBody.Messages {
+			roles = append(roles, msg.Role)
+		}
+		if got := strings.Join(roles, ","); got != "system,user,assistant,user" {
+			t.Errorf("消息角色顺序 = %s, 期望 system,user,assistant,user", got)
+		}
+		if len(reqBody.Messages) == 4 && reqBody.Messages[2].Content != `{"command":"ls -S"}` {
+			t.Errorf("历史 assistant 消息 = %s", reqBody.Messages[2].Content)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ls -S *.log"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+
+	ctx := &ExecutionContext{
+		OS:    "linux",
+		Shell: "bash",
+		History: []Message{
+			{Role: RoleUser, Content: "list big files here"},
+			{Role: RoleAssistant, Content: `{"command":"ls -S"}`},
+		},
+	}
+
+	result, err := provider.Translate(context.Background(), "only .log files", ctx)
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != "ls -S *.log" {
+		t.Errorf("期望命令为 'ls -S *.log', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -236,3 +278,110 @@ func TestOpenAIProvider_Translate_EmptyCommand(t *testing.T) {
 		t.Fatal("期望返回错误（空命令），但成功了")
 	}
 }
+
+// TestOpenAIProvider_TranslateStream 测试流式命令转换
+func TestOpenAIProvider_TranslateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody["stream"] != true {
+			t.Errorf("期望请求体包含 stream=true, 实际为 %v", reqBody["stream"])
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"ls \"}}]}\n\n"))
+		w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"-la\"}}]}\n\n"))
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+
+	var chunks []string
+	result, err := provider.TranslateStream(context.Background(), "列出文件", &ExecutionContext{OS: "linux"}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != "ls -la" {
+		t.Errorf("期望命令为 'ls -la', 实际为 '%s'", result.Command)
+	}
+	if len(chunks) != 2 {
+		t.Errorf("期望收到 2 个增量块, 实际为 %d", len(chunks))
+	}
+}
+
+// TestOpenAIProvider_Translate_StructuredResult 测试解析 JSON 结果和 token 用量
+func TestOpenAIProvider_Translate_StructuredResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := map[string]interface{}{
+			"choices": []map[string]interface{}{
+				{
+					"message": map[string]string{
+						"content": `{"command":"df -h","explanation":"显示磁盘使用情况","risk":"low","tools":["df"]}`,
+					},
+				},
+			},
+			"usage": map[string]int{
+				"prompt_tokens":     120,
+				"completion_tokens": 30,
+				"total_tokens":      150,
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+
+	result, err := provider.Translate(context.Background(), "查看磁盘", &ExecutionContext{OS: "linux"})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != "df -h" {
+		t.Errorf("期望命令为 'df -h', 实际为 '%s'", result.Command)
+	}
+	if result.Explanation != "显示磁盘使用情况" {
+		t.Errorf("期望说明为 '显示磁盘使用情况', 实际为 '%s'", result.Explanation)
+	}
+	if result.Usage.TotalTokens != 150 || result.Usage.PromptTokens != 120 {
+		t.Errorf("token 用量解析错误: %+v", result.Usage)
+	}
+}
+
+// TestAzureOpenAIProvider_Translate 测试 Azure OpenAI 的请求地址和认证方式
+func TestAzureOpenAIProvider_Translate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/gpt-4o-prod/chat/completions" {
+			t.Errorf("请求路径 = %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-06-01" {
+			t.Errorf("api-version = %s, 期望 2024-06-01", got)
+		}
+		if r.Header.Get("api-key") != "azure-key" {
+			t.Error("期望存在 api-key 请求头")
+		}
+		if r.Header.Get("Authorization") != "" {
+			t.Error("Azure 请求不应包含 Authorization 请求头")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ls -la"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewAzureOpenAIProvider("azure-key", server.URL+"/", "gpt-4o-prod", "")
+	if provider.Name() != providerAzure {
+		t.Errorf("Name() = %s, 期望 azure", provider.Name())
+	}
+
+	result, err := provider.Translate(context.Background(), "列出文件", &ExecutionContext{OS: "linux", Shell: "bash"})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != "ls -la" {
+		t.Errorf("期望命令为 'ls -la', 实际为 '%s'", result.Command)
+	}
+}
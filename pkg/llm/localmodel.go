@@ -19,6 +19,7 @@ type LocalModelProvider struct {
 	model   string
 	baseURL string
 	client  *http.Client
+	retry   RetryPolicy
 }
 
 // ollamaRequest 表示 Ollama API 请求体
@@ -40,8 +41,10 @@ type ollamaResponse struct {
 		Role    string `json:"role"`
 		Content string `json:"content"`
 	} `json:"message"`
-	Done  bool   `json:"done"`
-	Error string `json:"error,omitempty"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count,omitempty"`
+	EvalCount       int    `json:"eval_count,omitempty"`
+	Error           string `json:"error,omitempty"`
 }
 
 // NewLocalModelProvider 创建一个新的本地模型 Provider
@@ -58,6 +61,7 @@ func NewLocalModelProvider(model, baseURL string) *LocalModelProvider {
 		client: &http.Client{
 			Timeout: 60 * time.Second, // 本地模型可能需要更长时间
 		},
+		retry: DefaultRetryPolicy(),
 	}
 }
 
@@ -66,90 +70,150 @@ func (p *LocalModelProvider) Name() string {
 	return providerLocal
 }
 
+// SetRetryPolicy 设置 HTTP 请求的重试策略
+func (p *LocalModelProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
+
 // Translate 将自然语言转换为命令
-func (p *LocalModelProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+func (p *LocalModelProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
 	if input == "" {
-		return "", fmt.Errorf("输入不能为空")
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	completion, err := p.Complete(ctx, BuildMessages(input, execCtx))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// Complete 发送对话消息并返回模型的原始文本回复
+func (p *LocalModelProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	resp, err := p.send(ctx, messages, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
 	}
 
-	// 构建提示词
-	prompt := BuildPrompt(input, execCtx)
-	systemPrompt := GetSystemPrompt(execCtx)
+	// 解析响应
+	var apiResp ollamaResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+	}
+
+	// 验证响应
+	if apiResp.Message == nil {
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
+	}
 
+	return &Completion{
+		Content: strings.TrimSpace(apiResp.Message.Content),
+		Usage:   newUsage(apiResp.PromptEvalCount, apiResp.EvalCount),
+		Model:   p.model,
+	}, nil
+}
+
+// TranslateStream 以流式方式将自然语言转换为命令
+// Ollama 的流式响应是每行一个 JSON 对象（NDJSON）
+func (p *LocalModelProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	resp, err := p.send(ctx, BuildMessages(input, execCtx), true)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	onChunk = filterCommandStream(onChunk)
+
+	var sb strings.Builder
+	var usage Usage
+	err = readNDJSON(resp.Body, func(line []byte) error {
+		var chunk ollamaResponse
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), chunk.Error)
+		}
+		if chunk.Done {
+			// 最后一个数据块携带 token 统计
+			usage = newUsage(chunk.PromptEvalCount, chunk.EvalCount)
+		}
+		if chunk.Message != nil && chunk.Message.Content != "" {
+			sb.WriteString(chunk.Message.Content)
+			if onChunk != nil {
+				onChunk(chunk.Message.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(&Completion{
+		Content: strings.TrimSpace(sb.String()),
+		Usage:   usage,
+		Model:   p.model,
+	})
+}
+
+// send 构建并发送请求，返回状态码为 200 的响应
+func (p *LocalModelProvider) send(ctx context.Context, messages []Message, stream bool) (*http.Response, error) {
 	// 构建请求体
 	reqBody := ollamaRequest{
 		Model:  p.model,
-		Stream: false, // 不使用流式响应
-		Messages: []ollamaMessage{
-			{
-				Role:    "system",
-				Content: systemPrompt,
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+		Stream: stream,
+	}
+	for _, msg := range messages {
+		reqBody.Messages = append(reqBody.Messages, ollamaMessage{Role: msg.Role, Content: msg.Content})
 	}
 
 	// 序列化请求体
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
 	}
 
 	// 创建 HTTP 请求
 	url := p.baseURL + "/api/chat"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
 	}
 
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
 
 	// 发送请求
-	resp, err := p.client.Do(req)
+	resp, err := p.retry.Do(p.client, req)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
-	}
-	defer resp.Body.Close()
-
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
 		var errResp ollamaResponse
 		json.Unmarshal(body, &errResp)
-		errMsg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
 		if errResp.Error != "" {
-			errMsg = fmt.Sprintf("%s: %s", errMsg, errResp.Error)
+			statusErr.Message = errResp.Error
 		}
-		return "", fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), errMsg)
-	}
-
-	// 解析响应
-	var apiResp ollamaResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIError), statusErr)
 	}
 
-	// 验证响应
-	if apiResp.Message == nil {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyResponse))
-	}
-
-	command := strings.TrimSpace(apiResp.Message.Content)
-	if command == "" {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyCommandResp))
-	}
-
-	// 清理命令（移除可能的 markdown 代码块标记）
-	command = cleanCommand(command)
-
-	return command, nil
+	return resp, nil
 }
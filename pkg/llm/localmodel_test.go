@@ -47,14 +47,14 @@ func TestLocalModelProvider_Translate_Success(t *testing.T) {
 		WorkDir: "/home/user",
 	}
 
-	command, err := provider.Translate(ctx, "列出当前目录文件", execCtx)
+	result, err := provider.Translate(ctx, "列出当前目录文件", execCtx)
 
 	// 验证结果
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
-	if command != "ls -l" {
-		t.Errorf("期望命令 'ls -l', 实际为 '%s'", command)
+	if result.Command != "ls -l" {
+		t.Errorf("期望命令 'ls -l', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -92,13 +92,13 @@ func TestLocalModelProvider_Translate_WithStdin(t *testing.T) {
 		Stdin:   "some input data\nwith multiple lines",
 	}
 
-	command, err := provider.Translate(ctx, "统计行数", execCtx)
+	result, err := provider.Translate(ctx, "统计行数", execCtx)
 
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
-	if command != "wc -l" {
-		t.Errorf("期望命令 'wc -l', 实际为 '%s'", command)
+	if result.Command != "wc -l" {
+		t.Errorf("期望命令 'wc -l', 实际为 '%s'", result.Command)
 	}
 }
 
@@ -114,6 +114,7 @@ func TestLocalModelProvider_Translate_APIError(t *testing.T) {
 	defer server.Close()
 
 	provider := NewLocalModelProvider("invalid-model", server.URL)
+	provider.SetRetryPolicy(RetryPolicy{})
 
 	ctx := context.Background()
 	execCtx := &ExecutionContext{OS: "linux", Shell: "bash"}
@@ -234,12 +235,45 @@ func TestLocalModelProvider_StreamingResponse(t *testing.T) {
 	ctx := context.Background()
 	execCtx := &ExecutionContext{OS: "linux", Shell: "bash"}
 
-	command, err := provider.Translate(ctx, "打印hello", execCtx)
+	result, err := provider.Translate(ctx, "打印hello", execCtx)
 
 	if err != nil {
 		t.Fatalf("转换失败: %v", err)
 	}
-	if command != "echo hello" {
-		t.Errorf("期望命令 'echo hello', 实际为 '%s'", command)
+	if result.Command != "echo hello" {
+		t.Errorf("期望命令 'echo hello', 实际为 '%s'", result.Command)
+	}
+}
+
+// TestLocalModelProvider_TranslateStream 测试 NDJSON 流式命令转换
+func TestLocalModelProvider_TranslateStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if reqBody["stream"] != true {
+			t.Errorf("期望请求体包含 stream=true, 实际为 %v", reqBody["stream"])
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"echo"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":" hello"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	provider := NewLocalModelProvider("llama2", server.URL)
+
+	var chunks []string
+	result, err := provider.TranslateStream(context.Background(), "打印hello", &ExecutionContext{OS: "linux"}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != "echo hello" {
+		t.Errorf("期望命令 'echo hello', 实际为 '%s'", result.Command)
+	}
+	if len(chunks) != 2 {
+		t.Errorf("期望收到 2 个增量块, 实际为 %d", len(chunks))
 	}
 }
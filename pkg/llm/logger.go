@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -194,21 +195,26 @@ func maskError(err error) string {
 	}
 
 	errStr := err.Error()
-
-	// 脱敏 API Key（查找类似 sk-xxx 或 key=xxx 的模式）
-	if strings.Contains(errStr, "sk-") {
-		errStr = strings.ReplaceAll(errStr, "sk-", "sk-***")
-	}
-	if strings.Contains(errStr, "key=") {
-		errStr = strings.ReplaceAll(errStr, "key=", "key=***")
-	}
-	if strings.Contains(errStr, "api_key") {
-		errStr = strings.ReplaceAll(errStr, "api_key", "api_key=***")
+	for _, p := range secretPatterns {
+		errStr = p.re.ReplaceAllString(errStr, p.repl)
 	}
 
 	return errStr
 }
 
+// secretPatterns 匹配错误信息中可能出现的密钥
+// 前缀要求位于单词边界且后面有足够长的字符，避免误伤 disk-usage、task-runner 这类普通文本
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}`), "sk-***"},
+	{regexp.MustCompile(`\bAIza[A-Za-z0-9_\-]{16,}`), "AIza***"},
+	{regexp.MustCompile(`(?i)bearer\s+[^\s"',]+`), "Bearer ***"},
+	{regexp.MustCompile(`([?&\s]|^)key=[^&\s"']*`), "${1}key=***"},
+	{regexp.MustCompile(`api_key["']?\s*[:= ]?\s*["']?[^\s"',&}]*`), "api_key=***"},
+}
+
 // ParseLogLevel 从字符串解析日志级别
 func ParseLogLevel(level string) (LogLevel, error) {
 	switch strings.ToLower(level) {
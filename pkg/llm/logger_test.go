@@ -273,9 +273,15 @@ func TestMaskError(t *testing.T) {
 		},
 		{
 			"包含 sk- 的错误",
-			errors.New("authentication failed with sk-12345"),
+			errors.New("authentication failed with sk-proj-1234567890abcdef"),
 			"sk-***",
-			"sk-12345",
+			"sk-proj-1234567890abcdef",
+		},
+		{
+			"包含 Gemini API Key 的错误",
+			errors.New("request failed: AIzaSyA1234567890abcdefghij"),
+			"AIza***",
+			"AIzaSyA1234567890abcdefghij",
 		},
 		{
 			"包含 key= 的错误",
@@ -305,6 +311,20 @@ func TestMaskError(t *testing.T) {
 	}
 }
 
+// TestMaskError_PlainText 测试普通命令文本不会被误当作密钥
+func TestMaskError_PlainText(t *testing.T) {
+	for _, text := range []string{
+		"command failed: du -sh disk-usage",
+		"npm run task-runner -- --watch",
+		"unexpected token sk- in reply",
+		"grep -r ask-for-help-with-something-long docs/",
+	} {
+		if got := maskError(errors.New(text)); got != text {
+			t.Errorf("maskError(%q) = %q, 不应修改普通文本", text, got)
+		}
+	}
+}
+
 // TestParseLogLevel 测试解析日志级别
 func TestParseLogLevel(t *testing.T) {
 	tests := []struct {
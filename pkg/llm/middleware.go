@@ -0,0 +1,247 @@
+// Package llm 提供 Provider 中间件（日志、计时、错误脱敏）
+package llm
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/config"
+)
+
+// Middleware 包装一个 Provider，在不修改提供商实现的情况下添加日志、计时、错误脱敏等功能
+type Middleware func(next Provider) Provider
+
+// Chain 依次用中间件包装 Provider，第一个中间件位于最外层（最先收到请求、最后看到结果）
+func Chain(p Provider, middlewares ...Middleware) Provider {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		p = middlewares[i](p)
+	}
+	return p
+}
+
+// FindProvider 沿 Unwrap 链查找指定类型的 Provider（如被中间件包装的 *FallbackProvider）
+func FindProvider[T any](p Provider) (T, bool) {
+	for p != nil {
+		if found, ok := p.(T); ok {
+			return found, true
+		}
+		u, ok := p.(interface{ Unwrap() Provider })
+		if !ok {
+			break
+		}
+		p = u.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+// ProviderWrapper 把所有调用转发给被包装的 Provider
+// 自定义中间件可以嵌入它，只覆盖需要拦截的方法
+type ProviderWrapper struct {
+	// Next 被包装的 Provider
+	Next Provider
+}
+
+// Name 返回被包装的提供商名称
+func (w *ProviderWrapper) Name() string {
+	return w.Next.Name()
+}
+
+// Unwrap 返回被包装的 Provider
+func (w *ProviderWrapper) Unwrap() Provider {
+	return w.Next
+}
+
+// Translate 调用被包装的 Provider
+func (w *ProviderWrapper) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	return w.Next.Translate(ctx, input, execCtx)
+}
+
+// TranslateStream 调用被包装的 Provider，不支持流式输出时使用 Translate
+func (w *ProviderWrapper) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if streamer, ok := w.Next.(StreamingProvider); ok {
+		return streamer.TranslateStream(ctx, input, execCtx, onChunk)
+	}
+	return w.Next.Translate(ctx, input, execCtx)
+}
+
+// Complete 调用被包装的 Provider，未实现 Completer 时返回错误
+func (w *ProviderWrapper) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	completer, ok := AsCompleter(w.Next)
+	if !ok {
+		return nil, errNotCompleter
+	}
+	return completer.Complete(ctx, messages)
+}
+
+// SupportsCompletion 报告被包装的 Provider 是否支持 Complete
+func (w *ProviderWrapper) SupportsCompletion() bool {
+	_, ok := AsCompleter(w.Next)
+	return ok
+}
+
+// hookProvider 在每次调用前后执行回调，是内置中间件的公共实现
+type hookProvider struct {
+	ProviderWrapper
+
+	// before 在调用前执行，input 为用户输入（Complete 为最后一条用户消息）
+	before func(input string)
+
+	// after 在调用后执行，reply 为返回的命令或回复文本；返回值替换原错误
+	after func(reply string, err error, elapsed time.Duration) error
+}
+
+// Translate 调用被包装的 Provider 并执行回调
+func (h *hookProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	start := h.start(input)
+	result, err := h.ProviderWrapper.Translate(ctx, input, execCtx)
+	return result, h.finish(resultCommand(result), err, start)
+}
+
+// TranslateStream 调用被包装的 Provider 并执行回调
+func (h *hookProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	start := h.start(input)
+	result, err := h.ProviderWrapper.TranslateStream(ctx, input, execCtx, onChunk)
+	return result, h.finish(resultCommand(result), err, start)
+}
+
+// Complete 调用被包装的 Provider 并执行回调
+func (h *hookProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	start := h.start(lastUserMessage(messages))
+	completion, err := h.ProviderWrapper.Complete(ctx, messages)
+
+	reply := ""
+	if completion != nil {
+		reply = completion.Content
+	}
+	return completion, h.finish(reply, err, start)
+}
+
+// start 执行调用前的回调并返回开始时间
+func (h *hookProvider) start(input string) time.Time {
+	if h.before != nil {
+		h.before(input)
+	}
+	return time.Now()
+}
+
+// finish 执行调用后的回调
+func (h *hookProvider) finish(reply string, err error, start time.Time) error {
+	if h.after == nil {
+		return err
+	}
+	return h.after(reply, err, time.Since(start))
+}
+
+// resultCommand 返回转换结果中的命令（结果为空时返回空字符串）
+func resultCommand(result *TranslationResult) string {
+	if result == nil {
+		return ""
+	}
+	return result.Command
+}
+
+// lastUserMessage 返回最后一条用户消息的内容
+func lastUserMessage(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
+
+// LoggingMiddleware 记录每次请求、响应和错误（脱敏，不记录完整输入和命令）
+// model 为写入请求日志的模型名称
+func LoggingMiddleware(logger *Logger, model string) Middleware {
+	return func(next Provider) Provider {
+		if !logger.IsEnabled() {
+			return next
+		}
+		return &hookProvider{
+			ProviderWrapper: ProviderWrapper{Next: next},
+			before: func(input string) {
+				logger.LogRequest(next.Name(), model, input)
+			},
+			after: func(reply string, err error, elapsed time.Duration) error {
+				if err != nil {
+					logger.LogError(next.Name(), err)
+				} else {
+					logger.LogResponse(next.Name(), reply, elapsed)
+				}
+				return err
+			},
+		}
+	}
+}
+
+// TimingMiddleware 在每次调用结束后报告耗时（用于嵌入 aicli 的程序收集指标）
+func TimingMiddleware(observe func(provider string, elapsed time.Duration, err error)) Middleware {
+	return func(next Provider) Provider {
+		return &hookProvider{
+			ProviderWrapper: ProviderWrapper{Next: next},
+			after: func(reply string, err error, elapsed time.Duration) error {
+				observe(next.Name(), elapsed, err)
+				return err
+			},
+		}
+	}
+}
+
+// ErrorMaskingMiddleware 隐藏错误信息中的 API Key（如 Gemini 请求地址中的 key= 参数）
+// 原始错误仍可通过 errors.Is/errors.As 访问
+func ErrorMaskingMiddleware() Middleware {
+	return func(next Provider) Provider {
+		return &hookProvider{
+			ProviderWrapper: ProviderWrapper{Next: next},
+			after: func(reply string, err error, elapsed time.Duration) error {
+				if err == nil {
+					return nil
+				}
+				masked := maskError(err)
+				if masked == err.Error() {
+					return err
+				}
+				return &maskedError{msg: masked, err: err}
+			},
+		}
+	}
+}
+
+// maskedError 是脱敏后的错误
+type maskedError struct {
+	msg string
+	err error
+}
+
+func (e *maskedError) Error() string {
+	return e.msg
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// newLoggerFromConfig 根据日志配置创建日志记录器，未指定文件时输出到 stderr（不影响命令输出）
+func newLoggerFromConfig(cfg config.LoggingConfig) (*Logger, error) {
+	if !cfg.Enabled {
+		return DisabledLogger(), nil
+	}
+
+	level, err := ParseLogLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(cfg.File) == "" {
+		return NewStderrLogger(level), nil
+	}
+
+	path, err := config.ExpandPath(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+	return NewFileLogger(level, path)
+}
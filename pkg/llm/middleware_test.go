@@ -0,0 +1,244 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/studyzy/aicli/pkg/config"
+)
+
+// recordingMiddleware 记录调用顺序的中间件
+type recordingMiddleware struct {
+	ProviderWrapper
+	name  string
+	calls *[]string
+}
+
+func (r *recordingMiddleware) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	*r.calls = append(*r.calls, r.name)
+	return r.ProviderWrapper.Translate(ctx, input, execCtx)
+}
+
+// TestChain 测试第一个中间件位于最外层
+func TestChain(t *testing.T) {
+	var calls []string
+	record := func(name string) Middleware {
+		return func(next Provider) Provider {
+			return &recordingMiddleware{ProviderWrapper: ProviderWrapper{Next: next}, name: name, calls: &calls}
+		}
+	}
+
+	base := &MockLLMProvider{TranslateFn: func(input string) string { return "ls" }}
+	provider := Chain(base, record("outer"), record("inner"))
+
+	result, err := provider.Translate(context.Background(), "list", nil)
+	if err != nil || result.Command != "ls" {
+		t.Fatalf("Translate() = %v, %v", result, err)
+	}
+	if strings.Join(calls, ",") != "outer,inner" {
+		t.Errorf("调用顺序 = %v, 期望 outer,inner", calls)
+	}
+
+	// 嵌入 ProviderWrapper 的中间件仍然支持 Complete
+	completion, err := provider.(Completer).Complete(context.Background(), []Message{{Role: RoleUser, Content: "list"}})
+	if err != nil || completion.Content != "ls" {
+		t.Errorf("Complete() = %v, %v", completion, err)
+	}
+}
+
+// TestFindProvider 测试沿 Unwrap 链查找 Provider
+func TestFindProvider(t *testing.T) {
+	fallback := NewFallbackProvider(FallbackEntry{Provider: &MockLLMProvider{}})
+	provider := Chain(fallback, ErrorMaskingMiddleware(), TimingMiddleware(func(string, time.Duration, error) {}))
+
+	found, ok := FindProvider[*FallbackProvider](provider)
+	if !ok || found != fallback {
+		t.Errorf("FindProvider() = %v, %v", found, ok)
+	}
+	if _, ok := FindProvider[*OpenAIProvider](provider); ok {
+		t.Error("不应找到未包含的 Provider 类型")
+	}
+}
+
+// TestLoggingMiddleware 测试记录请求、响应和脱敏后的错误
+func TestLoggingMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewLogger(LogLevelInfo, &buf)
+
+	fail := false
+	base := &MockLLMProvider{
+		ProviderName: "mock",
+		TranslateFunc: func(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+			if fail {
+				return "", errors.New("invalid api key sk-secret1234567890abcdef")
+			}
+			return "find . -name '*.go'", nil
+		},
+	}
+	provider := LoggingMiddleware(logger, "gpt-4")(base)
+
+	if _, err := provider.Translate(context.Background(), "查找 go 文件", nil); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	fail = true
+	if _, err := provider.Translate(context.Background(), "查找 go 文件", nil); err == nil {
+		t.Fatal("期望返回错误")
+	}
+
+	output := buf.String()
+	for _, want := range []string{"LLM 请求", "gpt-4", "LLM 响应", "find . -name", "LLM 错误", "sk-***"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("日志应包含 %q:\n%s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"查找 go 文件", "sk-secret1234567890abcdef"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("日志不应包含 %q:\n%s", unwanted, output)
+		}
+	}
+
+	// 禁用的日志记录器不包装 Provider
+	if LoggingMiddleware(DisabledLogger(), "")(base) != Provider(base) {
+		t.Error("禁用日志时不应包装 Provider")
+	}
+}
+
+// TestTimingMiddleware 测试报告每次调用的耗时和错误
+func TestTimingMiddleware(t *testing.T) {
+	var observed []string
+	base := &MockLLMProvider{ProviderName: "mock", TranslateFn: func(input string) string { return "ls" }}
+	provider := TimingMiddleware(func(provider string, elapsed time.Duration, err error) {
+		observed = append(observed, fmt.Sprintf("%s:%v", provider, err))
+	})(base)
+
+	if _, err := provider.Translate(context.Background(), "list", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.(StreamingProvider).TranslateStream(context.Background(), "list", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(observed) != 2 || observed[0] != "mock:<nil>" {
+		t.Errorf("observed = %v", observed)
+	}
+}
+
+// TestErrorMaskingMiddleware 测试错误中的密钥被隐藏且原始错误仍可访问
+func TestErrorMaskingMiddleware(t *testing.T) {
+	statusErr := &StatusError{StatusCode: 503}
+	base := &MockLLMProvider{
+		TranslateFunc: func(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+			return "", fmt.Errorf(`Post "https://example.com/v1/models/gemini:generateContent?key=AIzaSyABC123&alt=sse": %w`, statusErr)
+		},
+	}
+	provider := ErrorMaskingMiddleware()(base)
+
+	_, err := provider.Translate(context.Background(), "list", nil)
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+	if strings.Contains(err.Error(), "ABC123") {
+		t.Errorf("错误信息未脱敏: %v", err)
+	}
+	var target *StatusError
+	if !errors.As(err, &target) || target != statusErr {
+		t.Error("脱敏后的错误应保留原始错误链")
+	}
+	if !shouldFallback(err) {
+		t.Error("脱敏后的 5xx 错误仍应触发故障转移")
+	}
+}
+
+// TestNewProvider_Logging 测试根据日志配置把请求记录到文件
+func TestNewProvider_Logging(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "aicli.log")
+	cfg := &config.Config{
+		LLM:     config.LLMConfig{Provider: providerMock},
+		Logging: config.LoggingConfig{Enabled: true, Level: "info", File: logFile},
+	}
+
+	provider, err := NewProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if _, err := provider.Translate(context.Background(), "list files", nil); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("读取日志文件失败: %v", err)
+	}
+	if !strings.Contains(string(data), "LLM 响应 - Provider: mock") {
+		t.Errorf("日志文件内容不符合预期: %s", data)
+	}
+
+	// 无效的日志级别返回错误
+	cfg.Logging.Level = "verbose"
+	if _, err := NewProvider(cfg); err == nil {
+		t.Error("无效的日志级别应该返回错误")
+	}
+}
+
+// translateOnlyProvider 只实现 Translate 的自定义 Provider
+type translateOnlyProvider struct{}
+
+func (p *translateOnlyProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	return &TranslationResult{Command: "ls"}, nil
+}
+
+func (p *translateOnlyProvider) Name() string {
+	return "custom"
+}
+
+// TestNewProvider_TranslateOnly 测试被包装的 Provider 不支持 Complete 时，扩展功能回退到 Translate
+func TestNewProvider_TranslateOnly(t *testing.T) {
+	cfg := &config.Config{LLM: config.LLMConfig{Provider: providerMock}}
+	custom := func(next Provider) Provider { return &translateOnlyProvider{} }
+
+	provider, err := NewProvider(cfg, custom)
+	if err != nil {
+		t.Fatalf("NewProvider() error = %v", err)
+	}
+	if _, ok := AsCompleter(provider); ok {
+		t.Error("包装后的 Provider 不应报告支持 Complete")
+	}
+
+	ctx := context.Background()
+	candidates, err := TranslateCandidates(ctx, provider, "list", nil, 3)
+	if err != nil || len(candidates) != 1 || candidates[0].Command != "ls" {
+		t.Errorf("TranslateCandidates() = %v, %v", candidates, err)
+	}
+
+	plan, err := TranslatePlan(ctx, provider, "list", nil)
+	if err != nil || len(plan.Steps) != 1 || plan.Steps[0].Command != "ls" {
+		t.Errorf("TranslatePlan() = %v, %v", plan, err)
+	}
+
+	steps := []PlanStep{{Command: "ls"}}
+	if result, err := RefineStep(ctx, provider, "list", nil, steps, nil, 0); err != nil || result.Command != "ls" {
+		t.Errorf("RefineStep() = %v, %v", result, err)
+	}
+
+	if _, err := Fix(ctx, provider, "list", nil, &FailedCommand{Command: "ls", ExitCode: 1}); err == nil || errors.Is(err, errNotCompleter) {
+		t.Errorf("Fix() 应返回不支持修正的错误, 实际 %v", err)
+	}
+	if _, err := Explain(ctx, provider, "ls", nil); err == nil || errors.Is(err, errNotCompleter) {
+		t.Errorf("Explain() 应返回不支持解释的错误, 实际 %v", err)
+	}
+
+	// 故障转移中的提供商都不支持 Complete 时同样回退
+	fallback := NewFallbackProvider(FallbackEntry{Provider: &translateOnlyProvider{}})
+	if _, ok := AsCompleter(Chain(fallback, ErrorMaskingMiddleware())); ok {
+		t.Error("FallbackProvider 不应报告支持 Complete")
+	}
+	if _, ok := AsCompleter(NewFallbackProvider(FallbackEntry{Provider: &translateOnlyProvider{}}, FallbackEntry{Provider: &MockLLMProvider{}})); !ok {
+		t.Error("有提供商支持 Complete 时 FallbackProvider 应报告支持")
+	}
+}
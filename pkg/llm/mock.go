@@ -4,33 +4,69 @@ import "context"
 
 // MockLLMProvider 是用于测试的 Mock LLM 提供商
 type MockLLMProvider struct {
+	// ResultFunc 自定义翻译函数（返回结构化结果）
+	ResultFunc func(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error)
+
 	// TranslateFunc 自定义翻译函数
 	TranslateFunc func(ctx context.Context, input string, execCtx *ExecutionContext) (string, error)
 
 	// TranslateFn 简化版翻译函数（只接受 input）
 	TranslateFn func(input string) string
 
+	// CompleteFunc 自定义对话补全函数
+	CompleteFunc func(ctx context.Context, messages []Message) (*Completion, error)
+
 	// ProviderName 提供商名称
 	ProviderName string
 }
 
 // Translate 执行翻译（调用自定义函数）
-func (m *MockLLMProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+func (m *MockLLMProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
+	if m.ResultFunc != nil {
+		return m.ResultFunc(ctx, input, execCtx)
+	}
+
 	if m.TranslateFunc != nil {
-		return m.TranslateFunc(ctx, input, execCtx)
+		command, err := m.TranslateFunc(ctx, input, execCtx)
+		if err != nil {
+			return nil, err
+		}
+		return &TranslationResult{Command: command}, nil
 	}
 
 	// 简化版：只使用 input
 	if m.TranslateFn != nil {
-		return m.TranslateFn(input), nil
+		return &TranslationResult{Command: m.TranslateFn(input)}, nil
 	}
 
-	return "", &TranslationError{
+	return nil, &TranslationError{
 		Provider: m.Name(),
 		Message:  "TranslateFunc not implemented",
 	}
 }
 
+// Complete 执行对话补全（调用自定义函数）
+// 未设置 CompleteFunc 时，使用最后一条用户消息调用翻译函数
+func (m *MockLLMProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	if m.CompleteFunc != nil {
+		return m.CompleteFunc(ctx, messages)
+	}
+
+	input := ""
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == RoleUser {
+			input = messages[i].Content
+			break
+		}
+	}
+
+	result, err := m.Translate(ctx, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Completion{Content: result.Command, Usage: result.Usage}, nil
+}
+
 // Name 返回提供商名称
 func (m *MockLLMProvider) Name() string {
 	if m.ProviderName != "" {
@@ -8,24 +8,100 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/studyzy/aicli/pkg/i18n"
 )
 
+const (
+	providerAzure = "azure"
+
+	// defaultAzureAPIVersion Azure OpenAI 默认的 API 版本
+	defaultAzureAPIVersion = "2024-06-01"
+)
+
 // OpenAIProvider 实现了 OpenAI API 的 LLMProvider 接口
+// 同时用于 Azure OpenAI（部署地址和 api-key 认证方式不同）
 type OpenAIProvider struct {
 	apiKey  string
 	model   string
 	baseURL string
 	client  *http.Client
+	retry   RetryPolicy
+
+	// tools 为 true 时通过 emit_command 工具调用返回命令
+	tools bool
+
+	// azure 为 true 时使用 Azure OpenAI 的请求地址和认证方式
+	azure      bool
+	deployment string
+	apiVersion string
 }
 
 // openAIRequest 表示 OpenAI API 请求体
 type openAIRequest struct {
-	Model    string          `json:"model"`
-	Messages []openAIMessage `json:"messages"`
+	Model         string               `json:"model"`
+	Messages      []openAIMessage      `json:"messages"`
+	Stream        bool                 `json:"stream,omitempty"`
+	StreamOptions *openAIStreamOptions `json:"stream_options,omitempty"`
+	Tools         []openAITool         `json:"tools,omitempty"`
+	ToolChoice    *openAIToolChoice    `json:"tool_choice,omitempty"`
+}
+
+// openAITool 表示 OpenAI 请求中声明的工具
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function openAIFunction `json:"function"`
+}
+
+// openAIFunction 表示工具对应的函数定义
+type openAIFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// openAIToolChoice 表示强制模型调用指定工具
+type openAIToolChoice struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+}
+
+// openAIToolCall 表示模型返回的工具调用
+type openAIToolCall struct {
+	Index    int `json:"index"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIStreamOptions 表示 OpenAI 流式请求选项
+type openAIStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// openAIUsage 表示 OpenAI API 返回的 token 用量
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// toUsage 转换为通用的 Usage
+func (u *openAIUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	usage := newUsage(u.PromptTokens, u.CompletionTokens)
+	if u.TotalTokens > 0 {
+		usage.TotalTokens = u.TotalTokens
+	}
+	return usage
 }
 
 // openAIMessage 表示 OpenAI 消息
@@ -38,9 +114,25 @@ type openAIMessage struct {
 type openAIResponse struct {
 	Choices []struct {
 		Message struct {
-			Content string `json:"content"`
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
 		} `json:"message"`
 	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openAIStreamChunk 表示 OpenAI 流式响应中的一个数据块
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string           `json:"content"`
+			ToolCalls []openAIToolCall `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
 	Error *struct {
 		Message string `json:"message"`
 	} `json:"error"`
@@ -59,99 +151,262 @@ func NewOpenAIProvider(apiKey, model, baseURL string) *OpenAIProvider {
 		client: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: DefaultRetryPolicy(),
+		tools: true,
 	}
 }
 
+// NewAzureOpenAIProvider 创建一个 Azure OpenAI Provider
+// endpoint: Azure 资源地址，如 https://my-resource.openai.azure.com
+// deployment: 模型部署名称
+// apiVersion: API 版本，为空时使用默认版本
+func NewAzureOpenAIProvider(apiKey, endpoint, deployment, apiVersion string) *OpenAIProvider {
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+
+	p := NewOpenAIProvider(apiKey, deployment, strings.TrimSuffix(endpoint, "/"))
+	p.azure = true
+	p.deployment = deployment
+	p.apiVersion = apiVersion
+	return p
+}
+
 // Name 返回提供商名称
 func (p *OpenAIProvider) Name() string {
+	if p.azure {
+		return providerAzure
+	}
 	return providerOpenAI
 }
 
+// chatURL 返回对话补全接口地址
+func (p *OpenAIProvider) chatURL() string {
+	if p.azure {
+		return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+			p.baseURL, url.PathEscape(p.deployment), url.QueryEscape(p.apiVersion))
+	}
+	return p.baseURL + "/chat/completions"
+}
+
+// SetRetryPolicy 设置 HTTP 请求的重试策略
+func (p *OpenAIProvider) SetRetryPolicy(policy RetryPolicy) {
+	p.retry = policy
+}
+
+// SetToolCalling 设置是否通过工具调用返回命令
+// 不支持 tools 参数的 OpenAI 兼容服务需要关闭，此时解析文本回复
+func (p *OpenAIProvider) SetToolCalling(enabled bool) {
+	p.tools = enabled
+}
+
 // Translate 将自然语言转换为命令
-func (p *OpenAIProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error) {
+func (p *OpenAIProvider) Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error) {
 	if input == "" {
-		return "", fmt.Errorf("输入不能为空")
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	completion, err := p.complete(ctx, BuildMessages(input, execCtx), p.tools)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// TranslateStream 以流式方式将自然语言转换为命令
+func (p *OpenAIProvider) TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error) {
+	if input == "" {
+		return nil, fmt.Errorf("输入不能为空")
+	}
+
+	resp, err := p.send(ctx, BuildMessages(input, execCtx), true, p.tools)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	completion, err := readOpenAIStream(resp.Body, filterCommandStream(onChunk))
+	if err != nil {
+		return nil, err
 	}
+	completion.Model = p.model
 
-	// 构建提示词
-	prompt := BuildPrompt(input, execCtx)
+	return newTranslationResult(completion)
+}
+
+// Complete 发送对话消息并返回模型的原始文本回复
+func (p *OpenAIProvider) Complete(ctx context.Context, messages []Message) (*Completion, error) {
+	return p.complete(ctx, messages, false)
+}
+
+// complete 发送非流式请求，tools 为 true 时要求模型调用 emit_command 工具
+func (p *OpenAIProvider) complete(ctx context.Context, messages []Message, tools bool) (*Completion, error) {
+	resp, err := p.send(ctx, messages, false, tools)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// 读取响应体
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+	}
+
+	// 解析响应
+	var apiResp openAIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+	}
+
+	// 验证响应
+	if len(apiResp.Choices) == 0 {
+		return nil, newEmptyResponseError(i18n.ErrEmptyResponse)
+	}
 
+	message := apiResp.Choices[0].Message
+	completion := &Completion{
+		Content: strings.TrimSpace(message.Content),
+		Usage:   apiResp.Usage.toUsage(),
+		Model:   p.model,
+	}
+	for _, call := range message.ToolCalls {
+		if call.Function.Name == emitCommandToolName {
+			completion.ToolArguments = call.Function.Arguments
+			break
+		}
+	}
+
+	return completion, nil
+}
+
+// send 构建并发送请求，返回状态码为 200 的响应
+func (p *OpenAIProvider) send(ctx context.Context, messages []Message, stream, tools bool) (*http.Response, error) {
 	// 构建请求体
 	reqBody := openAIRequest{
-		Model: p.model,
-		Messages: []openAIMessage{
-			{
-				Role:    "system",
-				Content: GetSystemPrompt(execCtx),
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+		Model:    p.model,
+		Stream:   stream,
+		Messages: toOpenAIMessages(messages),
+	}
+	if stream {
+		// 请求在流的最后一个数据块中返回 token 用量
+		reqBody.StreamOptions = &openAIStreamOptions{IncludeUsage: true}
+	}
+	if tools {
+		reqBody.Tools, reqBody.ToolChoice = openAIEmitCommandTool()
 	}
 
 	// 序列化请求体
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrSerializeRequest), err)
 	}
 
 	// 创建 HTTP 请求
-	url := p.baseURL + "/chat/completions"
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.chatURL(), bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrCreateRequest), err)
 	}
 
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.apiKey)
-
-	// 发送请求
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
+	if p.azure {
+		req.Header.Set("api-key", p.apiKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
 	}
-	defer resp.Body.Close()
 
-	// 读取响应体
-	body, err := io.ReadAll(resp.Body)
+	// 发送请求
+	resp, err := p.retry.Do(p.client, req)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIRequest), err)
 	}
 
 	// 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
 		var errResp openAIResponse
 		json.Unmarshal(body, &errResp)
-		errMsg := fmt.Sprintf("HTTP %d", resp.StatusCode)
+		statusErr := &StatusError{StatusCode: resp.StatusCode}
 		if errResp.Error != nil {
-			errMsg = fmt.Sprintf("%s: %s", errMsg, errResp.Error.Message)
+			statusErr.Message = errResp.Error.Message
 		}
-		return "", fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), errMsg)
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrAPIError), statusErr)
 	}
 
-	// 解析响应
-	var apiResp openAIResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return "", fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
-	}
+	return resp, nil
+}
 
-	// 验证响应
-	if len(apiResp.Choices) == 0 {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyResponse))
-	}
+// openAIEmitCommandTool 返回 emit_command 工具声明以及强制调用该工具的 tool_choice
+func openAIEmitCommandTool() ([]openAITool, *openAIToolChoice) {
+	tools := []openAITool{{
+		Type: "function",
+		Function: openAIFunction{
+			Name:        emitCommandToolName,
+			Description: emitCommandToolDescription,
+			Parameters:  emitCommandSchema(),
+		},
+	}}
+	choice := &openAIToolChoice{Type: "function"}
+	choice.Function.Name = emitCommandToolName
+	return tools, choice
+}
 
-	command := strings.TrimSpace(apiResp.Choices[0].Message.Content)
-	if command == "" {
-		return "", fmt.Errorf("%s", i18n.T(i18n.ErrEmptyCommandResp))
+// toOpenAIMessages 将通用消息转换为 OpenAI 兼容格式
+func toOpenAIMessages(messages []Message) []openAIMessage {
+	result := make([]openAIMessage, 0, len(messages))
+	for _, msg := range messages {
+		result = append(result, openAIMessage{Role: msg.Role, Content: msg.Content})
 	}
+	return result
+}
+
+// readOpenAIStream 读取 OpenAI 兼容格式的 SSE 流，返回完整的回复文本
+// 模型通过工具调用返回时，参数片段同样传给 onChunk 并汇总到 ToolArguments
+func readOpenAIStream(r io.Reader, onChunk func(chunk string)) (*Completion, error) {
+	var sb, args strings.Builder
+	completion := &Completion{}
 
-	// 清理命令（移除可能的 markdown 代码块标记）
-	command = cleanCommand(command)
+	err := readSSE(r, func(data string) error {
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s: %s", i18n.T(i18n.ErrAPIError), chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			completion.Usage = chunk.Usage.toUsage()
+		}
+		for _, choice := range chunk.Choices {
+			for _, call := range choice.Delta.ToolCalls {
+				if call.Index != 0 || call.Function.Arguments == "" {
+					continue
+				}
+				args.WriteString(call.Function.Arguments)
+				if onChunk != nil {
+					onChunk(call.Function.Arguments)
+				}
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			sb.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	return command, nil
+	completion.Content = strings.TrimSpace(sb.String())
+	completion.ToolArguments = args.String()
+	return completion, nil
 }
 
 // cleanCommand 清理命令字符串
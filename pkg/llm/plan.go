@@ -0,0 +1,169 @@
+// Package llm 提供多步计划生成功能
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// MaxPlanSteps 计划的最大步骤数
+const MaxPlanSteps = 10
+
+// MaxStepOutputBytes 每个步骤的输出发送给模型时的最大字节数，超出时只保留末尾部分
+const MaxStepOutputBytes = 1024
+
+// PlanStep 表示计划中的一个步骤
+type PlanStep struct {
+	// Description 步骤说明
+	Description string `json:"description"`
+
+	// Command 步骤命令
+	Command string `json:"command"`
+
+	// UsesOutput 命令是否依赖前面步骤的输出（执行前会根据输出重新生成命令）
+	UsesOutput bool `json:"uses_output,omitempty"`
+}
+
+// Plan 表示一个按顺序执行的多步计划
+type Plan struct {
+	// Steps 有序的步骤列表
+	Steps []PlanStep
+
+	// Usage token 用量
+	Usage Usage
+
+	// Provider 实际返回结果的提供商名称
+	Provider string
+
+	// Model 实际使用的模型名称
+	Model string
+}
+
+// BuildPlanMessages 构建生成多步计划的对话消息
+func BuildPlanMessages(input string, ctx *ExecutionContext) []Message {
+	messages := BuildMessages(input, ctx)
+	messages[0].Content += "\n" + i18n.T(i18n.LLMPlanPrompt, MaxPlanSteps)
+	return messages
+}
+
+// TranslatePlan 请求 Provider 将任务拆分为多个步骤
+// Provider 未实现 Completer 接口时回退到 Translate，返回只有一个步骤的计划
+func TranslatePlan(ctx context.Context, p Provider, input string, execCtx *ExecutionContext) (*Plan, error) {
+	completer, ok := AsCompleter(p)
+	if !ok {
+		result, err := p.Translate(ctx, input, execCtx)
+		if err != nil {
+			return nil, err
+		}
+		return &Plan{
+			Steps:    []PlanStep{{Description: result.Explanation, Command: result.Command}},
+			Usage:    result.Usage,
+			Provider: result.Provider,
+			Model:    result.Model,
+		}, nil
+	}
+
+	// 多个步骤的 JSON 数组比单条命令长得多，放宽输出上限避免被截断
+	completion, err := completer.Complete(withMinMaxTokens(ctx, multiStepMaxTokens), BuildPlanMessages(input, execCtx))
+	if err != nil {
+		return nil, err
+	}
+
+	steps, err := parsePlan(completion.Content)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, newEmptyResponseError(i18n.ErrEmptyCommandResp)
+	}
+
+	return &Plan{Steps: steps, Usage: completion.Usage, Provider: completion.Provider, Model: completion.Model}, nil
+}
+
+// BuildStepMessages 构建根据前面步骤的输出重新生成第 index 步命令的对话消息
+// outputs 为已执行步骤的输出，与 steps 一一对应
+func BuildStepMessages(input string, ctx *ExecutionContext, steps []PlanStep, outputs []string, index int) []Message {
+	planJSON, _ := json.Marshal(steps)
+
+	var sb strings.Builder
+	for i, output := range outputs {
+		sb.WriteString(i18n.T(i18n.LLMPlanStepOutput, i+1, steps[i].Command,
+			strings.TrimSpace(tailString(output, MaxStepOutputBytes))) + "\n")
+	}
+
+	step := steps[index]
+	messages := BuildMessages(input, ctx)
+	return append(messages,
+		Message{Role: RoleAssistant, Content: string(planJSON)},
+		Message{Role: RoleUser, Content: i18n.T(i18n.LLMPlanStepPrompt, sb.String(), index+1, step.Description, step.Command)},
+	)
+}
+
+// RefineStep 请求 Provider 根据前面步骤的输出给出第 index 步的最终命令
+// Provider 未实现 Completer 接口时返回原计划中的命令
+func RefineStep(ctx context.Context, p Provider, input string, execCtx *ExecutionContext, steps []PlanStep, outputs []string, index int) (*TranslationResult, error) {
+	completer, ok := AsCompleter(p)
+	if !ok {
+		return &TranslationResult{Command: steps[index].Command, Explanation: steps[index].Description}, nil
+	}
+
+	completion, err := completer.Complete(ctx, BuildStepMessages(input, execCtx, steps, outputs, index))
+	if err != nil {
+		return nil, err
+	}
+
+	return newTranslationResult(completion)
+}
+
+// parsePlan 解析模型返回的步骤列表
+// 期望格式为 JSON 数组；回复不是数组时把整段回复当作单个步骤，
+// 以 [ 开头但无法解析（如输出达到 max_tokens 被截断）时返回错误，避免把 JSON 文本当作命令执行
+func parsePlan(text string) ([]PlanStep, error) {
+	var parsed []PlanStep
+
+	body := stripCodeFence(strings.TrimSpace(text))
+	start := strings.Index(body, "[")
+	end := strings.LastIndex(body, "]")
+	if start >= 0 && end > start {
+		if err := json.Unmarshal([]byte(body[start:end+1]), &parsed); err != nil {
+			parsed = nil
+		}
+	}
+
+	if parsed == nil {
+		if strings.HasPrefix(body, "[") {
+			return nil, fmt.Errorf("%s", i18n.T(i18n.ErrInvalidListResp))
+		}
+		result, err := parseTranslation(text)
+		if err != nil {
+			return nil, err
+		}
+		if result.Command != "" {
+			return []PlanStep{{Description: result.Explanation, Command: result.Command}}, nil
+		}
+		return nil, nil
+	}
+
+	steps := make([]PlanStep, 0, len(parsed))
+	for _, s := range parsed {
+		s.Command = cleanCommand(s.Command)
+		s.Description = strings.TrimSpace(s.Description)
+		if s.Command == "" {
+			continue
+		}
+		// 第一步没有可依赖的输出
+		if len(steps) == 0 {
+			s.UsesOutput = false
+		}
+		steps = append(steps, s)
+		if len(steps) >= MaxPlanSteps {
+			break
+		}
+	}
+
+	return steps, nil
+}
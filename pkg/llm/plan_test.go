@@ -0,0 +1,147 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// TestParsePlan 测试多步计划解析
+func TestParsePlan(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		want       []string
+		usesOutput []bool
+	}{
+		{
+			name:       "JSON 数组",
+			text:       `[{"description":"克隆","command":"git clone x","uses_output":true},{"description":"构建","command":"make","uses_output":true}]`,
+			want:       []string{"git clone x", "make"},
+			usesOutput: []bool{false, true},
+		},
+		{
+			name:       "markdown 代码块包裹并跳过空命令",
+			text:       "```json\n[{\"command\":\"ls\"},{\"command\":\" \"},{\"command\":\"pwd\"}]\n```",
+			want:       []string{"ls", "pwd"},
+			usesOutput: []bool{false, false},
+		},
+		{
+			name:       "非 JSON 回退为单个步骤",
+			text:       "ls -la",
+			want:       []string{"ls -la"},
+			usesOutput: []bool{false},
+		},
+		{
+			name: "空回复",
+			text: "  ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePlan(tt.text)
+			if err != nil {
+				t.Fatalf("parsePlan() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parsePlan() 返回 %d 个步骤, 期望 %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i].Command != tt.want[i] || got[i].UsesOutput != tt.usesOutput[i] {
+					t.Errorf("步骤 %d = %+v, 期望命令 %q, uses_output=%v", i, got[i], tt.want[i], tt.usesOutput[i])
+				}
+			}
+		})
+	}
+}
+
+// TestParsePlan_Invalid 测试无法解析的 JSON 数组（如输出被截断）返回错误，而不是当作命令
+func TestParsePlan_Invalid(t *testing.T) {
+	for _, text := range []string{
+		`[{"description":"克隆","command":"git clone x"},{"description":"构建","comm`,
+		"```json\n[{\"command\": ls}]\n```",
+	} {
+		if steps, err := parsePlan(text); err == nil {
+			t.Errorf("parsePlan(%q) 应返回错误, 实际 %+v", text, steps)
+		}
+	}
+}
+
+// TestParsePlan_MaxSteps 测试步骤数量上限
+func TestParsePlan_MaxSteps(t *testing.T) {
+	items := make([]string, 0, MaxPlanSteps+5)
+	for i := 0; i < MaxPlanSteps+5; i++ {
+		items = append(items, `{"command":"echo step"}`)
+	}
+
+	if got, _ := parsePlan("[" + strings.Join(items, ",") + "]"); len(got) != MaxPlanSteps {
+		t.Errorf("期望最多 %d 个步骤, 实际为 %d", MaxPlanSteps, len(got))
+	}
+}
+
+// TestTranslatePlan 测试通过 Completer 生成计划
+func TestTranslatePlan(t *testing.T) {
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			return &Completion{
+				Content: `[{"description":"创建目录","command":"mkdir -p build"},{"description":"进入并构建","command":"cd build && make"}]`,
+				Usage:   newUsage(20, 10),
+			}, nil
+		},
+	}
+
+	plan, err := TranslatePlan(context.Background(), provider, "构建项目", nil)
+	if err != nil {
+		t.Fatalf("TranslatePlan() error = %v", err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[1].Command != "cd build && make" {
+		t.Errorf("TranslatePlan() = %+v", plan.Steps)
+	}
+	if plan.Usage.TotalTokens != 30 {
+		t.Errorf("Usage.TotalTokens = %d, 期望 30", plan.Usage.TotalTokens)
+	}
+
+	// 计划请求放宽输出上限
+	var limit int
+	provider.CompleteFunc = func(ctx context.Context, messages []Message) (*Completion, error) {
+		limit = maxTokensFor(ctx, DefaultMaxTokens)
+		return &Completion{Content: `[{"command":"ls"}]`}, nil
+	}
+	if _, err := TranslatePlan(context.Background(), provider, "构建项目", nil); err != nil || limit != multiStepMaxTokens {
+		t.Errorf("计划请求的最大输出 token 数 = %d, 期望 %d (err=%v)", limit, multiStepMaxTokens, err)
+	}
+
+	// 不支持 Complete 时回退到 Translate，Translate 的错误原样返回
+	fallback, err := TranslatePlan(context.Background(), explainOnlyProvider{}, "构建项目", nil)
+	if err == nil {
+		t.Errorf("Translate 失败时应返回错误, got %+v", fallback)
+	}
+}
+
+// TestRefineStep 测试根据前面步骤的输出重新生成命令
+func TestRefineStep(t *testing.T) {
+	var received []Message
+	provider := &MockLLMProvider{
+		CompleteFunc: func(ctx context.Context, messages []Message) (*Completion, error) {
+			received = messages
+			return &Completion{Content: `{"command":"cp build/app-1.2 ~/bin/"}`}, nil
+		},
+	}
+
+	steps := []PlanStep{
+		{Description: "构建", Command: "make"},
+		{Description: "复制", Command: "cp build/app ~/bin/", UsesOutput: true},
+	}
+	result, err := RefineStep(context.Background(), provider, "构建并安装", nil, steps, []string{"built app-1.2\n"}, 1)
+	if err != nil {
+		t.Fatalf("RefineStep() error = %v", err)
+	}
+	if result.Command != "cp build/app-1.2 ~/bin/" {
+		t.Errorf("RefineStep() command = %q", result.Command)
+	}
+
+	if len(received) != 4 || received[2].Role != RoleAssistant || !strings.Contains(received[2].Content, "cp build/app ~/bin/") {
+		t.Fatalf("消息结构不正确: %+v", received)
+	}
+}
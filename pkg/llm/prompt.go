@@ -9,8 +9,13 @@ import (
 )
 
 // GetSystemPrompt 返回系统提示词
-// lang: 语言代码 (zh/en)
+// 配置了系统提示词模板时使用模板渲染，否则使用内置提示词
 func GetSystemPrompt(ctx *ExecutionContext) string {
+	return renderSystemPrompt("", ctx)
+}
+
+// builtinSystemPrompt 返回内置的系统提示词
+func builtinSystemPrompt(ctx *ExecutionContext) string {
 	lang := i18n.Lang()
 	
 	if lang == "en" {
@@ -36,6 +41,12 @@ func buildChineseSystemPrompt(ctx *ExecutionContext) string {
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelOS), ctx.OS))
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelShell), ctx.Shell))
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelWorkDir), ctx.WorkDir))
+		sb.WriteString(ctx.Details)
+
+		if ctx.Instructions != "" {
+			sb.WriteString("\n" + i18n.T(i18n.LLMInstructions) + "\n")
+			sb.WriteString(strings.TrimRight(ctx.Instructions, "\n") + "\n")
+		}
 	}
 
 	return sb.String()
@@ -58,36 +69,65 @@ func buildEnglishSystemPrompt(ctx *ExecutionContext) string {
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelOS), ctx.OS))
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelShell), ctx.Shell))
 		sb.WriteString(fmt.Sprintf("- %s: %s\n", i18n.T(i18n.LabelWorkDir), ctx.WorkDir))
+		sb.WriteString(ctx.Details)
+
+		if ctx.Instructions != "" {
+			sb.WriteString("\n" + i18n.T(i18n.LLMInstructions) + "\n")
+			sb.WriteString(strings.TrimRight(ctx.Instructions, "\n") + "\n")
+		}
 	}
 
 	return sb.String()
 }
 
 // BuildPrompt 构建用户提示词
+// 配置了用户提示词模板时使用模板渲染，否则使用内置提示词
 func BuildPrompt(input string, ctx *ExecutionContext) string {
+	return renderUserPrompt(input, ctx)
+}
+
+// builtinUserPrompt 返回内置的用户提示词
+func builtinUserPrompt(input string, ctx *ExecutionContext) string {
 	var sb strings.Builder
 
 	// 添加用户输入
 	sb.WriteString(fmt.Sprintf("%s\n%s\n", i18n.T(i18n.LLMUserPromptIntro), input))
 
 	// 如果有标准输入，添加上下文
-	if ctx != nil && ctx.Stdin != "" {
-		sb.WriteString("\n" + i18n.T(i18n.LLMStdinData) + "\n")
-
-		// 限制 stdin 的长度，避免提示词过长
-		maxStdinLen := 500
-		stdin := ctx.Stdin
-		if len(stdin) > maxStdinLen {
-			stdin = stdin[:maxStdinLen] + i18n.T(i18n.LLMTruncated)
+	if ctx != nil && (ctx.Stdin != "" || ctx.StdinSummary != "") {
+		// 超出预算的数据只发送结构摘要，避免提示词过长
+		summary := ctx.StdinSummary
+		if summary == "" {
+			summary = SummarizeStdin(ctx.Stdin, DefaultStdinBudget).Text
 		}
 
-		sb.WriteString(stdin)
+		sb.WriteString("\n")
+		sb.WriteString(summary)
 		sb.WriteString("\n")
 	}
 
 	return sb.String()
 }
 
+// BuildMessages 构建命令转换请求的对话消息（系统提示词 + 对话历史 + 用户提示词）
+func BuildMessages(input string, ctx *ExecutionContext) []Message {
+	messages := []Message{{Role: RoleSystem, Content: renderSystemPrompt(input, ctx)}}
+
+	// 追问模式：在系统提示词和本次输入之间插入之前的对话
+	if ctx != nil {
+		messages = append(messages, ctx.History...)
+	}
+
+	return append(messages, Message{Role: RoleUser, Content: BuildPrompt(input, ctx)})
+}
+
+// BuildCandidatesMessages 构建生成多个候选命令的对话消息
+func BuildCandidatesMessages(input string, ctx *ExecutionContext, n int) []Message {
+	messages := BuildMessages(input, ctx)
+	messages[0].Content += "\n" + i18n.T(i18n.LLMCandidatesPrompt, n)
+	return messages
+}
+
 // BuildContextDescription 构建执行上下文描述（用于调试和日志）
 func BuildContextDescription(ctx *ExecutionContext) string {
 	if ctx == nil {
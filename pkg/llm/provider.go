@@ -1,7 +1,12 @@
 // Package llm 提供 LLM 服务的抽象接口
 package llm
 
-import "context"
+import (
+	"context"
+	"fmt"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
 
 // Provider 定义 LLM 服务提供商的接口
 type Provider interface {
@@ -9,13 +14,86 @@ type Provider interface {
 	// ctx: 上下文，用于超时控制
 	// input: 用户的自然语言描述
 	// execCtx: 执行上下文信息（操作系统、Shell等）
-	// 返回: 转换后的命令字符串和可能的错误
-	Translate(ctx context.Context, input string, execCtx *ExecutionContext) (string, error)
+	// 返回: 结构化的转换结果和可能的错误
+	Translate(ctx context.Context, input string, execCtx *ExecutionContext) (*TranslationResult, error)
 
 	// Name 返回提供商名称
 	Name() string
 }
 
+// StreamingProvider 定义支持流式输出的 LLM 服务提供商接口
+// 这是可选接口，未实现该接口的 Provider 由调用方回退到 Translate
+type StreamingProvider interface {
+	Provider
+
+	// TranslateStream 以流式方式将自然语言转换为命令
+	// onChunk: 每收到一段命令的增量文本时调用
+	// 返回: 结构化的转换结果和可能的错误
+	TranslateStream(ctx context.Context, input string, execCtx *ExecutionContext, onChunk func(chunk string)) (*TranslationResult, error)
+}
+
+// Completer 定义可以直接发送对话消息的 LLM 服务提供商接口
+// 这是可选接口，候选命令等扩展功能基于它实现；
+// 中间件、缓存等包装器总是实现 Complete，判断是否可用应使用 AsCompleter
+type Completer interface {
+	// Complete 发送对话消息并返回模型的原始文本回复
+	Complete(ctx context.Context, messages []Message) (*Completion, error)
+}
+
+// AsCompleter 返回 p 的 Completer 实现
+// 包装器通过 SupportsCompletion 报告被包装的 Provider 是否支持 Complete，不支持时返回 false
+func AsCompleter(p Provider) (Completer, bool) {
+	completer, ok := p.(Completer)
+	if !ok {
+		return nil, false
+	}
+	if w, ok := p.(interface{ SupportsCompletion() bool }); ok && !w.SupportsCompletion() {
+		return nil, false
+	}
+	return completer, true
+}
+
+// DefaultMaxTokens 单条命令回复的默认最大输出 token 数（未配置 llm.max_tokens 时使用）
+const DefaultMaxTokens = 500
+
+// multiStepMaxTokens 计划、多候选等包含多条命令的回复至少使用的最大输出 token 数
+const multiStepMaxTokens = 2048
+
+// maxTokensKey 是上下文中最少输出 token 数的键
+type maxTokensKey struct{}
+
+// withMinMaxTokens 返回要求本次请求至少允许 n 个输出 token 的上下文
+func withMinMaxTokens(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxTokensKey{}, n)
+}
+
+// maxTokensFor 返回本次请求的最大输出 token 数：配置值（未配置时为 DefaultMaxTokens）与上下文要求的较大值
+func maxTokensFor(ctx context.Context, configured int) int {
+	if configured <= 0 {
+		configured = DefaultMaxTokens
+	}
+	if n, ok := ctx.Value(maxTokensKey{}).(int); ok && n > configured {
+		return n
+	}
+	return configured
+}
+
+// Message 表示一条对话消息
+type Message struct {
+	// Role 消息角色（system/user/assistant）
+	Role string
+
+	// Content 消息内容
+	Content string
+}
+
+// 消息角色
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
 // ExecutionContext 包含命令执行的上下文信息
 type ExecutionContext struct {
 	// OS 操作系统类型（linux/darwin/windows）
@@ -27,8 +105,23 @@ type ExecutionContext struct {
 	// WorkDir 当前工作目录
 	WorkDir string
 
-	// Stdin 标准输入数据（如果有）
+	// Stdin 标准输入数据（如果有），二进制数据不会填入
 	Stdin string
+
+	// StdinSummary 写入提示词的标准输入摘要（见 SummarizeStdin），为空时按默认预算根据 Stdin 生成
+	StdinSummary string
+
+	// Details 环境信息收集器的输出（可用工具、发行版等），已按大小预算截断
+	Details string
+
+	// Fingerprint 项目的稳定摘要（标记文件和 git 分支），只参与缓存键计算，不写入提示词
+	Fingerprint string
+
+	// Instructions 工作目录及其上级目录中 .aicli.md 的内容（团队约定），已按大小限制截断
+	Instructions string
+
+	// History 之前几轮的对话历史（追问模式使用），按时间顺序排列
+	History []Message
 }
 
 // TranslationError 表示翻译过程中的错误
@@ -48,3 +141,30 @@ func (e *TranslationError) Error() string {
 func (e *TranslationError) Unwrap() error {
 	return e.Err
 }
+
+// StatusError 表示 API 返回了非 200 的 HTTP 状态码
+type StatusError struct {
+	StatusCode int    // HTTP 状态码
+	Message    string // API 返回的错误信息（可能为空）
+}
+
+func (e *StatusError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("HTTP %d", e.StatusCode)
+}
+
+// EmptyResponseError 表示模型返回了空内容或空命令
+type EmptyResponseError struct {
+	Message string // 错误消息
+}
+
+func (e *EmptyResponseError) Error() string {
+	return e.Message
+}
+
+// newEmptyResponseError 使用指定的 i18n 键创建 EmptyResponseError
+func newEmptyResponseError(key string) error {
+	return &EmptyResponseError{Message: i18n.T(key)}
+}
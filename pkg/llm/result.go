@@ -0,0 +1,246 @@
+// Package llm 提供结构化翻译结果的定义与解析
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// TranslationResult 表示一次命令转换的结构化结果
+type TranslationResult struct {
+	// Command 转换后的命令
+	Command string `json:"command"`
+
+	// Explanation 命令的一行说明
+	Explanation string `json:"explanation,omitempty"`
+
+	// Risk 模型自评的风险等级（low/medium/high）
+	Risk string `json:"risk,omitempty"`
+
+	// Tools 命令依赖的外部程序
+	Tools []string `json:"tools,omitempty"`
+
+	// NeedsSudo 命令是否需要管理员权限
+	NeedsSudo bool `json:"needs_sudo,omitempty"`
+
+	// Interactive 命令是否需要用户交互
+	Interactive bool `json:"interactive,omitempty"`
+
+	// Usage token 用量
+	Usage Usage `json:"usage"`
+
+	// Provider 实际返回结果的提供商名称（配置了备用提供商时可能不是第一个）
+	Provider string `json:"provider,omitempty"`
+
+	// Model 实际使用的模型名称
+	Model string `json:"model,omitempty"`
+
+	// Cached 结果是否来自本地缓存
+	Cached bool `json:"-"`
+}
+
+// Usage 表示一次请求的 token 用量
+type Usage struct {
+	// PromptTokens 输入 token 数
+	PromptTokens int `json:"prompt_tokens"`
+
+	// CompletionTokens 输出 token 数
+	CompletionTokens int `json:"completion_tokens"`
+
+	// TotalTokens 总 token 数
+	TotalTokens int `json:"total_tokens"`
+}
+
+// Completion 表示一次对话补全的原始结果
+type Completion struct {
+	// Content 模型回复的文本
+	Content string
+
+	// Usage token 用量
+	Usage Usage
+
+	// Provider 实际返回结果的提供商名称（由 FallbackProvider 设置）
+	Provider string
+
+	// Model 实际使用的模型名称
+	Model string
+
+	// ToolArguments emit_command 工具调用的参数（JSON），模型未调用工具时为空
+	ToolArguments string
+}
+
+// newUsage 根据输入、输出 token 数构建 Usage
+func newUsage(promptTokens, completionTokens int) Usage {
+	return Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// newTranslationResult 从补全结果构建翻译结果
+// 模型通过工具调用返回时解析工具参数，否则解析文本回复
+func newTranslationResult(c *Completion) (*TranslationResult, error) {
+	if c.ToolArguments != "" {
+		result, err := parseToolArguments(c.ToolArguments)
+		if err != nil {
+			return nil, err
+		}
+		result.Usage = c.Usage
+		result.Provider = c.Provider
+		result.Model = c.Model
+		return result, nil
+	}
+
+	result, err := parseTranslation(c.Content)
+	if err != nil {
+		return nil, err
+	}
+	if result.Command == "" {
+		return nil, newEmptyResponseError(i18n.ErrEmptyCommandResp)
+	}
+	result.Usage = c.Usage
+	result.Provider = c.Provider
+	result.Model = c.Model
+	return result, nil
+}
+
+// parseTranslation 解析模型回复
+// 以 { 开头的回复按 JSON 对象解析（command/explanation/risk/tools），其他回复作为纯文本命令
+// JSON 无效（如被截断、转义错误）或缺少 command 时返回错误，避免把 JSON 文本当作命令执行
+func parseTranslation(text string) (*TranslationResult, error) {
+	body := stripCodeFence(strings.TrimSpace(text))
+	if !strings.HasPrefix(body, "{") {
+		return &TranslationResult{Command: cleanCommand(text)}, nil
+	}
+
+	var result TranslationResult
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrInvalidObjectResp), err)
+	}
+	if strings.TrimSpace(result.Command) == "" {
+		return nil, newEmptyResponseError(i18n.ErrEmptyCommandResp)
+	}
+
+	result.Command = cleanCommand(result.Command)
+	result.Explanation = strings.TrimSpace(result.Explanation)
+	result.Risk = strings.ToLower(strings.TrimSpace(result.Risk))
+	return &result, nil
+}
+
+// stripCodeFence 移除包裹内容的 markdown 代码块标记（包括语言标识）
+func stripCodeFence(text string) string {
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	if idx := strings.Index(text, "\n"); idx >= 0 {
+		text = text[idx+1:]
+	} else {
+		text = strings.TrimPrefix(text, "```")
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+}
+
+// filterCommandStream 包装流式回调，只把命令部分显示给用户
+// 模型输出 JSON 时只转发 command 字段的内容，否则原样转发
+func filterCommandStream(onChunk func(chunk string)) func(chunk string) {
+	if onChunk == nil {
+		return nil
+	}
+
+	var raw strings.Builder
+	emitted := 0
+	decided, jsonMode := false, false
+
+	return func(chunk string) {
+		raw.WriteString(chunk)
+		text := raw.String()
+
+		if !decided {
+			trimmed := strings.TrimLeft(text, " \t\r\n")
+			if strings.HasPrefix(trimmed, "```") {
+				// 等待代码块语言标识行结束后再判断
+				idx := strings.Index(trimmed, "\n")
+				if idx < 0 {
+					return
+				}
+				trimmed = strings.TrimLeft(trimmed[idx+1:], " \t\r\n")
+			}
+			if trimmed == "" {
+				return
+			}
+			decided, jsonMode = true, strings.HasPrefix(trimmed, "{")
+		}
+
+		if !jsonMode {
+			onChunk(text[emitted:])
+			emitted = len(text)
+			return
+		}
+
+		value := partialJSONString(text, "command")
+		if len(value) > emitted {
+			onChunk(value[emitted:])
+			emitted = len(value)
+		}
+	}
+}
+
+// partialJSONString 从可能不完整的 JSON 文本中提取指定字符串字段已生成的部分
+func partialJSONString(text, key string) string {
+	idx := strings.Index(text, strconv.Quote(key))
+	if idx < 0 {
+		return ""
+	}
+	rest := strings.TrimLeft(text[idx+len(key)+2:], " \t\r\n")
+	if !strings.HasPrefix(rest, ":") {
+		return ""
+	}
+	rest = strings.TrimLeft(rest[1:], " \t\r\n")
+	if !strings.HasPrefix(rest, "\"") {
+		return ""
+	}
+	rest = rest[1:]
+
+	var sb strings.Builder
+	for i := 0; i < len(rest); i++ {
+		c := rest[i]
+		if c == '"' {
+			break
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		// 转义序列不完整时等待后续数据
+		if i+1 >= len(rest) {
+			break
+		}
+		i++
+		switch rest[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'u':
+			if i+4 >= len(rest) {
+				return sb.String()
+			}
+			if r, err := strconv.ParseUint(rest[i+1:i+5], 16, 32); err == nil {
+				sb.WriteRune(rune(r))
+			}
+			i += 4
+		default:
+			sb.WriteByte(rest[i])
+		}
+	}
+
+	return sb.String()
+}
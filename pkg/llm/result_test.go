@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseTranslation 测试结构化结果解析
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantCommand string
+		wantExplain string
+		wantRisk    string
+		wantTools   int
+	}{
+		{
+			name:        "JSON 对象",
+			text:        `{"command":"du -sh *","explanation":"显示每个文件的大小","risk":"Low","tools":["du"]}`,
+			wantCommand: "du -sh *",
+			wantExplain: "显示每个文件的大小",
+			wantRisk:    "low",
+			wantTools:   1,
+		},
+		{
+			name:        "markdown 包裹的 JSON",
+			text:        "```json\n{\"command\":\"ls -la\",\"explanation\":\"列出文件\"}\n```",
+			wantCommand: "ls -la",
+			wantExplain: "列出文件",
+		},
+		{
+			name:        "纯文本命令",
+			text:        "```bash\nls -la\n```",
+			wantCommand: "ls -la",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseTranslation(tt.text)
+			if err != nil {
+				t.Fatalf("parseTranslation() error = %v", err)
+			}
+			if result.Command != tt.wantCommand {
+				t.Errorf("Command = %q, want %q", result.Command, tt.wantCommand)
+			}
+			if result.Explanation != tt.wantExplain {
+				t.Errorf("Explanation = %q, want %q", result.Explanation, tt.wantExplain)
+			}
+			if result.Risk != tt.wantRisk {
+				t.Errorf("Risk = %q, want %q", result.Risk, tt.wantRisk)
+			}
+			if len(result.Tools) != tt.wantTools {
+				t.Errorf("Tools = %v, want %d items", result.Tools, tt.wantTools)
+			}
+		})
+	}
+}
+
+// TestParseTranslation_InvalidJSON 测试无效或被截断的 JSON 对象返回错误，而不是被当作命令
+func TestParseTranslation_InvalidJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "无效的转义", text: `{"command":"grep -E \"\d+\" log.txt","explanation":"匹配数字"}`},
+		{name: "被截断", text: `{"command":"find . -name '*.go' -exec`},
+		{name: "markdown 包裹且被截断", text: "```json\n{\"command\":\"ls -la\",\"expla"},
+		{name: "command 为空", text: `{"explanation":"nothing"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseTranslation(tt.text)
+			if err == nil {
+				t.Fatalf("期望返回错误, 实际命令为 %q", result.Command)
+			}
+			if _, err := newTranslationResult(&Completion{Content: tt.text}); err == nil {
+				t.Error("newTranslationResult 应返回错误")
+			}
+		})
+	}
+}
+
+// TestNewTranslationResult_Empty 测试空命令返回错误
+func TestNewTranslationResult_Empty(t *testing.T) {
+	if _, err := newTranslationResult(&Completion{Content: "  "}); err == nil {
+		t.Error("期望空命令返回错误")
+	}
+}
+
+// TestFilterCommandStream 测试流式输出只显示命令部分
+func TestFilterCommandStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   string
+	}{
+		{
+			name:   "JSON 输出只显示 command 字段",
+			chunks: []string{`{"comm`, `and": "grep \"ERR`, `OR\" log.txt`, `", "explanation": "过滤"}`},
+			want:   `grep "ERROR" log.txt`,
+		},
+		{
+			name:   "代码块包裹的 JSON",
+			chunks: []string{"```js", "on\n{\"command\":\"ls\\t-la\"}", "\n```"},
+			want:   "ls\t-la",
+		},
+		{
+			name:   "纯文本原样输出",
+			chunks: []string{"ls ", "-la"},
+			want:   "ls -la",
+		},
+		{
+			name:   "不完整的 unicode 转义",
+			chunks: []string{`{"command":"echo \u00`, `e9"}`},
+			want:   "echo é",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			filter := filterCommandStream(func(chunk string) {
+				sb.WriteString(chunk)
+			})
+			for _, chunk := range tt.chunks {
+				filter(chunk)
+			}
+			if sb.String() != tt.want {
+				t.Errorf("输出 = %q, want %q", sb.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package llm 提供 HTTP 请求的重试策略
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultMaxRetries 默认最大重试次数（不含首次请求）
+	DefaultMaxRetries = 2
+
+	// DefaultRetryBackoff 默认初始退避时间
+	DefaultRetryBackoff = 500 * time.Millisecond
+
+	// maxRetryBackoff 指数退避的上限
+	maxRetryBackoff = 10 * time.Second
+)
+
+// RetryPolicy 定义 LLM HTTP 请求的重试策略
+// 对 429、5xx 和暂时性网络错误进行带随机抖动的指数退避重试，并遵循 Retry-After 响应头
+type RetryPolicy struct {
+	// MaxRetries 最大重试次数（0 表示不重试）
+	MaxRetries int
+
+	// Backoff 初始退避时间，每次重试翻倍
+	Backoff time.Duration
+}
+
+// DefaultRetryPolicy 返回默认的重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries: DefaultMaxRetries,
+		Backoff:    DefaultRetryBackoff,
+	}
+}
+
+// Do 发送请求，必要时按策略重试
+// 请求上下文的截止时间不足以等待下一次重试时，直接返回最后一次的结果
+// 返回的非 200 响应由调用方按原有方式处理
+func (p RetryPolicy) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = cloneRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := client.Do(attemptReq)
+		if attempt >= p.MaxRetries || !shouldRetry(ctx, resp, err) {
+			return resp, err
+		}
+
+		delay := p.backoff(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return resp, err
+		}
+
+		// 丢弃本次响应，等待后重试
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff 计算第 attempt 次重试前的等待时间
+// 优先使用 Retry-After 响应头，否则使用带抖动的指数退避
+func (p RetryPolicy) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	delay := p.Backoff << attempt
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+
+	// 在 [delay/2, delay] 之间随机，避免多个客户端同时重试
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// shouldRetry 判断请求结果是否值得重试
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	// 调用方已取消或超时
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return isTransientNetError(err)
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// isTransientNetError 判断传输错误是否是暂时性的：网络超时、连接被重置或中断、响应被截断
+// 连接被拒绝（服务未运行）、DNS 解析失败、TLS 证书错误、URL 格式错误等重试也不会成功，直接返回
+func isTransientNetError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// parseRetryAfter 解析 Retry-After 响应头（秒数或 HTTP 日期）
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
+
+// cloneRequest 复制请求用于重试（请求体需要重新读取）
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestRetryPolicy_RetriesServerErrors 测试 5xx 和 429 会重试直到成功
+func TestRetryPolicy_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices":[{"message":{"content":"ls -la"}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "gpt-4", server.URL)
+	provider.SetRetryPolicy(RetryPolicy{MaxRetries: 2, Backoff: time.Millisecond})
+
+	result, err := provider.Translate(context.Background(), "list files", &ExecutionContext{})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if result.Command != "ls -la" {
+		t.Errorf("Command = %s, want ls -la", result.Command)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+// TestRetryPolicy_GivesUp 测试超过最大重试次数后返回最后一次的错误
+func TestRetryPolicy_GivesUp(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"type":"rate_limit_error","message":"rate limited"}}`))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-key", "claude", server.URL)
+	provider.SetRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond})
+
+	_, err := provider.Translate(context.Background(), "list files", &ExecutionContext{})
+	if err == nil {
+		t.Fatal("Translate() should return error")
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+// TestRetryPolicy_NoRetryOnClientError 测试 4xx（除 429）不重试
+func TestRetryPolicy_NoRetryOnClientError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("bad-key", "gpt-4", server.URL)
+	provider.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond})
+
+	if _, err := provider.Translate(context.Background(), "list files", &ExecutionContext{}); err == nil {
+		t.Fatal("Translate() should return error")
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+// TestRetryPolicy_RespectsDeadline 测试 Retry-After 超过上下文截止时间时不再等待
+func TestRetryPolicy_RespectsDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-key", "gpt-4", server.URL)
+	provider.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := provider.Translate(ctx, "list files", &ExecutionContext{}); err == nil {
+		t.Fatal("Translate() should return error")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Translate() took %v, should return immediately", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+}
+
+// TestRetryPolicy_Backoff 测试退避时间计算
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, Backoff: 100 * time.Millisecond}
+
+	for attempt, max := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond} {
+		delay := policy.backoff(attempt, nil)
+		if delay < max/2 || delay > max {
+			t.Errorf("backoff(%d) = %v, want in [%v, %v]", attempt, delay, max/2, max)
+		}
+	}
+
+	if delay := policy.backoff(30, nil); delay > maxRetryBackoff {
+		t.Errorf("backoff(30) = %v, should be capped at %v", delay, maxRetryBackoff)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if delay := policy.backoff(0, resp); delay != 3*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 3s", delay)
+	}
+}
+
+// TestShouldRetry_TransportErrors 测试只有暂时性的传输错误才会重试
+func TestShouldRetry_TransportErrors(t *testing.T) {
+	post := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://api.example.com/v1/chat/completions", Err: err}
+	}
+	syscallErr := func(op string, errno syscall.Errno) error {
+		return post(&net.OpError{Op: op, Net: "tcp", Err: &os.SyscallError{Syscall: op, Err: errno}})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "读取超时", err: post(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}), want: true},
+		{name: "连接被重置", err: syscallErr("read", syscall.ECONNRESET), want: true},
+		{name: "连接被中断", err: syscallErr("write", syscall.ECONNABORTED), want: true},
+		{name: "响应被截断", err: post(io.ErrUnexpectedEOF), want: true},
+		{name: "连接被拒绝", err: syscallErr("connect", syscall.ECONNREFUSED), want: false},
+		{name: "域名不存在", err: post(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "api.example.invalid", IsNotFound: true}}), want: false},
+		{name: "证书不受信任", err: post(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), want: false},
+		{name: "证书主机名不匹配", err: post(x509.HostnameError{Host: "api.example.com"}), want: false},
+		{name: "URL 格式错误", err: post(errors.New("unsupported protocol scheme \"\"")), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := shouldRetry(context.Background(), nil, tt.err); got != tt.want {
+				t.Errorf("shouldRetry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if shouldRetry(ctx, nil, post(io.ErrUnexpectedEOF)) {
+		t.Error("调用方取消后不应重试")
+	}
+}
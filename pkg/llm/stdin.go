@@ -0,0 +1,464 @@
+package llm
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// 标准输入的数据格式
+const (
+	StdinText   = "text"
+	StdinLog    = "log"
+	StdinJSON   = "json"
+	StdinNDJSON = "ndjson"
+	StdinCSV    = "csv"
+	StdinTSV    = "tsv"
+	StdinBinary = "binary"
+)
+
+const (
+	// DefaultStdinBudget 标准输入写入提示词的默认最大字节数
+	DefaultStdinBudget = 2048
+
+	// sniffBytes 检测格式时检查的字节数
+	sniffBytes = 8192
+
+	// sniffLines 检测格式时检查的行数
+	sniffLines = 20
+
+	// maxSampleLineBytes 样例中单行的最大字节数
+	maxSampleLineBytes = 200
+
+	// maxHeadLines 和 maxTailLines 样例的最大行数
+	maxHeadLines = 10
+	maxTailLines = 5
+
+	// maxSchemaDepth JSON 结构描述的最大嵌套深度
+	maxSchemaDepth = 3
+
+	// maxMergedObjects 合并数组元素字段时检查的最大元素数
+	maxMergedObjects = 50
+)
+
+var (
+	// logTimestamp 匹配行首的 ISO 8601 时间戳或 syslog 时间戳
+	logTimestamp = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}|[A-Z][a-z]{2} +\d{1,2} \d{2}:\d{2}:\d{2})`)
+
+	// logLevel 匹配日志级别
+	logLevel = regexp.MustCompile(`\b(FATAL|ERROR|WARN|WARNING|INFO|DEBUG|TRACE)\b`)
+)
+
+// StdinSummary 是标准输入的摘要
+type StdinSummary struct {
+	// Format 检测到的数据格式
+	Format string
+
+	// Bytes 数据字节数
+	Bytes int
+
+	// Lines 数据行数
+	Lines int
+
+	// Text 写入提示词的内容：数据不超过预算时为原文，否则为结构摘要；二进制数据只包含大小说明
+	Text string
+}
+
+// SummarizeStdin 检测标准输入的格式并生成不超过 budget 字节的摘要
+// 数据不超过预算时原样发送；超出时发送结构（JSON 字段、CSV 表头等）、行数以及开头和末尾的样例
+// 二进制数据永远不会发送给模型
+func SummarizeStdin(data string, budget int) *StdinSummary {
+	if budget <= 0 {
+		budget = DefaultStdinBudget
+	}
+
+	s := &StdinSummary{
+		Format: DetectStdinFormat(data),
+		Bytes:  len(data),
+		Lines:  countLines(data),
+	}
+
+	if s.Format == StdinBinary {
+		s.Text = i18n.T(i18n.LLMStdinBinary, s.Bytes)
+		return s
+	}
+	if len(data) <= budget {
+		s.Text = i18n.T(i18n.LLMStdinData) + "\n" + data
+		return s
+	}
+
+	var sb strings.Builder
+	sb.WriteString(i18n.T(i18n.LLMStdinSummary, s.Format, s.Bytes, s.Lines) + "\n")
+
+	lines := splitLines(data)
+	switch s.Format {
+	case StdinJSON:
+		writeJSONSummary(&sb, data, budget)
+	case StdinNDJSON:
+		sb.WriteString(i18n.T(i18n.LLMStdinFields) + ": " + jsonSchema(mergeObjects(parseJSONLines(lines)), 0) + "\n")
+		writeSamples(&sb, lines, budget)
+	case StdinCSV, StdinTSV:
+		sample := nonEmpty(lines, sniffLines)
+		comma := detectDelimiter(sample)
+		header := splitRecord(sample[0], comma)
+		sb.WriteString(i18n.T(i18n.LLMStdinColumns, len(header)) + ": " + strings.Join(header, ", ") + "\n")
+		if comma != ',' && comma != '\t' {
+			sb.WriteString(i18n.T(i18n.LLMStdinDelimiter) + ": " + string(comma) + "\n")
+		}
+		writeSamples(&sb, lines[1:], budget)
+	case StdinLog:
+		if levels := countLevels(lines); levels != "" {
+			sb.WriteString(i18n.T(i18n.LLMStdinLevels) + ": " + levels + "\n")
+		}
+		writeSamples(&sb, lines, budget)
+	default:
+		writeSamples(&sb, lines, budget)
+	}
+
+	s.Text = truncateUTF8(sb.String(), budget)
+	return s
+}
+
+// DetectStdinFormat 检测标准输入的数据格式
+func DetectStdinFormat(data string) string {
+	if isBinary(data) {
+		return StdinBinary
+	}
+
+	trimmed := strings.TrimSpace(data)
+	if trimmed == "" {
+		return StdinText
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid([]byte(trimmed)) {
+		return StdinJSON
+	}
+
+	lines := splitLines(data)
+	sample := nonEmpty(lines, sniffLines)
+	if isNDJSON(sample) {
+		return StdinNDJSON
+	}
+	switch detectDelimiter(sample) {
+	case '\t':
+		return StdinTSV
+	case 0:
+	default:
+		return StdinCSV
+	}
+	if isLog(sample) {
+		return StdinLog
+	}
+	return StdinText
+}
+
+// isBinary 判断数据是否为二进制：包含 NUL 字节，或无效 UTF-8 和控制字符超过 10%
+func isBinary(data string) bool {
+	sniff := data
+	if len(sniff) > sniffBytes {
+		sniff = sniff[:sniffBytes]
+	}
+	if strings.IndexByte(sniff, 0) >= 0 {
+		return true
+	}
+
+	bad, total := 0, 0
+	for i := 0; i < len(sniff); {
+		r, size := utf8.DecodeRuneInString(sniff[i:])
+		// 末尾被截断的多字节字符不计入
+		if r == utf8.RuneError && size == 1 && len(sniff)-i < utf8.UTFMax && len(sniff) < len(data) {
+			break
+		}
+		if r == utf8.RuneError && size == 1 || r < 0x20 && !strings.ContainsRune("\t\n\r\f\v\x1b", r) {
+			bad++
+		}
+		total++
+		i += size
+	}
+	return total > 0 && bad*10 > total
+}
+
+// isNDJSON 判断每一行是否都是 JSON 对象（至少两行）
+func isNDJSON(lines []string) bool {
+	if len(lines) < 2 {
+		return false
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") || !json.Valid([]byte(line)) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectDelimiter 检测 CSV/TSV 分隔符：每行字段数相同且至少两列、两行；未检测到时返回 0
+func detectDelimiter(lines []string) rune {
+	if len(lines) < 2 {
+		return 0
+	}
+	for _, comma := range []rune{'\t', ',', ';', '|'} {
+		if !strings.ContainsRune(lines[0], comma) {
+			continue
+		}
+		r := csv.NewReader(strings.NewReader(strings.Join(lines, "\n")))
+		r.Comma = comma
+		r.LazyQuotes = true
+		records, err := r.ReadAll()
+		if err == nil && len(records) >= 2 && len(records[0]) >= 2 {
+			return comma
+		}
+	}
+	return 0
+}
+
+// splitRecord 按分隔符解析一行 CSV/TSV
+func splitRecord(line string, comma rune) []string {
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = comma
+	r.LazyQuotes = true
+	fields, err := r.Read()
+	if err != nil {
+		return strings.Split(line, string(comma))
+	}
+	return fields
+}
+
+// isLog 判断是否为日志：至少一半的行以时间戳开头或包含日志级别
+func isLog(lines []string) bool {
+	matched := 0
+	for _, line := range lines {
+		if logTimestamp.MatchString(line) || logLevel.MatchString(line) {
+			matched++
+		}
+	}
+	return len(lines) > 0 && matched*2 >= len(lines)
+}
+
+// countLevels 统计日志级别出现的行数，如 "ERROR 3, INFO 120"
+func countLevels(lines []string) string {
+	counts := make(map[string]int)
+	var order []string
+	for _, line := range lines {
+		level := logLevel.FindString(line)
+		if level == "" {
+			continue
+		}
+		if counts[level] == 0 {
+			order = append(order, level)
+		}
+		counts[level]++
+	}
+
+	parts := make([]string, len(order))
+	for i, level := range order {
+		parts[i] = fmt.Sprintf("%s %d", level, counts[level])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// writeJSONSummary 写入 JSON 文档的结构，数组还会附带第一个和最后一个元素
+func writeJSONSummary(sb *strings.Builder, data string, budget int) {
+	var v any
+	if err := json.Unmarshal([]byte(data), &v); err != nil {
+		return
+	}
+	sb.WriteString(i18n.T(i18n.LLMStdinSchema) + ": " + jsonSchema(v, 0) + "\n")
+
+	items, ok := v.([]any)
+	if !ok || len(items) == 0 {
+		return
+	}
+	samples := []string{compactJSON(items[0])}
+	if len(items) > 1 {
+		samples = append(samples, compactJSON(items[len(items)-1]))
+	}
+	writeSamples(sb, samples, budget)
+}
+
+// jsonSchema 描述 JSON 值的结构，如 [120 × {id: number, name: string}]
+func jsonSchema(v any, depth int) string {
+	switch val := v.(type) {
+	case map[string]any:
+		if depth >= maxSchemaDepth {
+			return "object"
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, k := range keys {
+			parts[i] = k + ": " + jsonSchema(val[k], depth+1)
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case []any:
+		if len(val) == 0 {
+			return "[]"
+		}
+		if depth >= maxSchemaDepth {
+			return fmt.Sprintf("array[%d]", len(val))
+		}
+		return fmt.Sprintf("[%d × %s]", len(val), jsonSchema(mergeObjects(val), depth+1))
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case nil:
+		return "null"
+	}
+	return "unknown"
+}
+
+// mergeObjects 合并数组中对象元素的字段（字段可能只出现在部分元素中），非对象数组返回第一个元素
+func mergeObjects(items []any) any {
+	if len(items) == 0 {
+		return nil
+	}
+	if _, ok := items[0].(map[string]any); !ok {
+		return items[0]
+	}
+
+	merged := make(map[string]any)
+	for i, item := range items {
+		if i >= maxMergedObjects {
+			break
+		}
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		for k, v := range obj {
+			if existing, ok := merged[k]; !ok || existing == nil {
+				merged[k] = v
+			}
+		}
+	}
+	return merged
+}
+
+// parseJSONLines 解析 NDJSON 的前若干行
+func parseJSONLines(lines []string) []any {
+	var items []any
+	for _, line := range lines {
+		if len(items) >= maxMergedObjects {
+			break
+		}
+		var v any
+		if json.Unmarshal([]byte(line), &v) == nil {
+			items = append(items, v)
+		}
+	}
+	return items
+}
+
+// compactJSON 将 JSON 值编码为单行文本
+func compactJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// writeSamples 写入开头和末尾的样例行，总长度不超过 budget
+// 开头的样例占剩余空间的三分之二，末尾的样例占剩余部分，中间省略的行数单独说明
+func writeSamples(sb *strings.Builder, lines []string, budget int) {
+	remain := budget - sb.Len()
+	if remain <= 0 || len(lines) == 0 {
+		return
+	}
+
+	headBudget := remain * 2 / 3
+	var head []string
+	used := 0
+	for _, line := range lines {
+		line = truncateLine(line)
+		if len(head) >= maxHeadLines || used+len(line)+1 > headBudget {
+			break
+		}
+		head = append(head, line)
+		used += len(line) + 1
+	}
+
+	tailBudget := remain - used - 64
+	var tail []string
+	used = 0
+	for i := len(lines) - 1; i >= len(head); i-- {
+		line := truncateLine(lines[i])
+		if len(tail) >= maxTailLines || used+len(line)+1 > tailBudget {
+			break
+		}
+		tail = append([]string{line}, tail...)
+		used += len(line) + 1
+	}
+
+	if len(head) > 0 {
+		sb.WriteString(i18n.T(i18n.LLMStdinHead) + ":\n" + strings.Join(head, "\n") + "\n")
+	}
+	if omitted := len(lines) - len(head) - len(tail); omitted > 0 {
+		sb.WriteString(i18n.T(i18n.LLMStdinOmitted, omitted) + "\n")
+	}
+	if len(tail) > 0 {
+		sb.WriteString(i18n.T(i18n.LLMStdinTail) + ":\n" + strings.Join(tail, "\n") + "\n")
+	}
+}
+
+// truncateLine 截断过长的样例行
+func truncateLine(line string) string {
+	if len(line) <= maxSampleLineBytes {
+		return line
+	}
+	return truncateUTF8(line, maxSampleLineBytes) + "..."
+}
+
+// truncateUTF8 将字符串截断为最多 n 个字节，不拆分多字节字符
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// splitLines 按行拆分数据（去掉末尾换行和 \r）
+func splitLines(data string) []string {
+	lines := strings.Split(strings.TrimRight(data, "\r\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// nonEmpty 返回前 n 个非空行
+func nonEmpty(lines []string, n int) []string {
+	var out []string
+	for _, line := range lines {
+		if len(out) >= n {
+			break
+		}
+		if strings.TrimSpace(line) != "" {
+			out = append(out, line)
+		}
+	}
+	return out
+}
+
+// countLines 统计行数（最后一行没有换行符时也计入）
+func countLines(data string) int {
+	if data == "" {
+		return 0
+	}
+	n := strings.Count(data, "\n")
+	if !strings.HasSuffix(data, "\n") {
+		n++
+	}
+	return n
+}
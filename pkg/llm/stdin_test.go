@@ -0,0 +1,178 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestDetectStdinFormat 测试标准输入格式检测
+func TestDetectStdinFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"empty", "", StdinText},
+		{"json object", `{"a": 1, "b": [1, 2]}`, StdinJSON},
+		{"json array", "[\n  {\"id\": 1},\n  {\"id\": 2}\n]\n", StdinJSON},
+		{"ndjson", "{\"id\": 1}\n{\"id\": 2}\n{\"id\": 3}\n", StdinNDJSON},
+		{"csv", "id,name\n1,alice\n2,\"bob, jr\"\n", StdinCSV},
+		{"semicolon csv", "id;name\n1;alice\n2;bob\n", StdinCSV},
+		{"tsv", "id\tname\n1\talice\n2\tbob\n", StdinTSV},
+		{"log", "2024-01-02T10:00:00Z INFO started\n2024-01-02T10:00:01Z ERROR failed\n", StdinLog},
+		{"syslog", "Jan  2 10:00:00 host sshd[1]: accepted\nJan  2 10:00:01 host sshd[1]: closed\n", StdinLog},
+		{"text", "hello world\nthis is, a sentence\nanother line\n", StdinText},
+		{"binary", "\x7fELF\x02\x01\x01\x00\x00\x00", StdinBinary},
+		{"invalid utf8", strings.Repeat("\xff\xfe\xfd", 10), StdinBinary},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectStdinFormat(tt.data); got != tt.want {
+				t.Errorf("DetectStdinFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSummarizeStdin_Small 测试不超过预算的数据原样发送
+func TestSummarizeStdin_Small(t *testing.T) {
+	data := "id,name\n1,alice\n"
+	s := SummarizeStdin(data, 100)
+	if s.Format != StdinCSV || s.Lines != 2 || s.Bytes != len(data) {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if !strings.HasSuffix(s.Text, data) {
+		t.Errorf("small stdin should be sent verbatim, got %q", s.Text)
+	}
+}
+
+// TestSummarizeStdin_CSV 测试大 CSV 只发送表头和开头、末尾的样例
+func TestSummarizeStdin_CSV(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,name,email\n")
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&sb, "%d,user%d,user%d@example.com\n", i, i, i)
+	}
+
+	s := SummarizeStdin(sb.String(), 512)
+	if s.Format != StdinCSV || s.Lines != 501 {
+		t.Errorf("unexpected summary: format=%s lines=%d", s.Format, s.Lines)
+	}
+	if len(s.Text) > 512 {
+		t.Errorf("summary exceeds budget: %d bytes", len(s.Text))
+	}
+	for _, want := range []string{"id, name, email", "1,user1,", "500,user500,"} {
+		if !strings.Contains(s.Text, want) {
+			t.Errorf("summary should contain %q:\n%s", want, s.Text)
+		}
+	}
+	if strings.Contains(s.Text, "250,user250,") {
+		t.Errorf("summary should not contain middle rows:\n%s", s.Text)
+	}
+}
+
+// TestSummarizeStdin_JSON 测试大 JSON 数组发送合并后的字段结构
+func TestSummarizeStdin_JSON(t *testing.T) {
+	items := make([]string, 200)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"id": %d, "name": "item%d", "tags": ["a", "b"]}`, i, i)
+	}
+	// 只有最后一个元素带 deleted 字段
+	items[len(items)-1] = `{"id": 199, "deleted": true}`
+	data := "[" + strings.Join(items, ",") + "]"
+
+	s := SummarizeStdin(data, 1024)
+	if s.Format != StdinJSON {
+		t.Fatalf("Format = %q, want json", s.Format)
+	}
+	want := "[200 × {id: number, name: string, tags: [2 × string]}]"
+	if !strings.Contains(s.Text, want) {
+		t.Errorf("summary should contain schema %q:\n%s", want, s.Text)
+	}
+	if !strings.Contains(s.Text, `"deleted":true`) {
+		t.Errorf("summary should contain the last element:\n%s", s.Text)
+	}
+}
+
+// TestSummarizeStdin_NDJSON 测试 NDJSON 发送字段和样例
+func TestSummarizeStdin_NDJSON(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&sb, `{"level": "info", "msg": "request %d", "status": 200}`+"\n", i)
+	}
+
+	s := SummarizeStdin(sb.String(), 600)
+	if s.Format != StdinNDJSON {
+		t.Fatalf("Format = %q, want ndjson", s.Format)
+	}
+	if !strings.Contains(s.Text, "{level: string, msg: string, status: number}") {
+		t.Errorf("summary should contain fields:\n%s", s.Text)
+	}
+	if !strings.Contains(s.Text, "request 99") {
+		t.Errorf("summary should contain the last line:\n%s", s.Text)
+	}
+}
+
+// TestSummarizeStdin_Log 测试日志统计级别
+func TestSummarizeStdin_Log(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		level := "INFO"
+		if i%10 == 0 {
+			level = "ERROR"
+		}
+		fmt.Fprintf(&sb, "2024-01-02 10:00:%02d %s message %d\n", i%60, level, i)
+	}
+
+	s := SummarizeStdin(sb.String(), 800)
+	if s.Format != StdinLog {
+		t.Fatalf("Format = %q, want log", s.Format)
+	}
+	if !strings.Contains(s.Text, "ERROR 10, INFO 90") {
+		t.Errorf("summary should count levels:\n%s", s.Text)
+	}
+}
+
+// TestSummarizeStdin_Binary 测试二进制数据不发送内容
+func TestSummarizeStdin_Binary(t *testing.T) {
+	data := "PK\x03\x04\x00\x00secret-content"
+	s := SummarizeStdin(data, 1024)
+	if s.Format != StdinBinary {
+		t.Fatalf("Format = %q, want binary", s.Format)
+	}
+	if strings.Contains(s.Text, "secret-content") {
+		t.Errorf("binary content should not be sent: %q", s.Text)
+	}
+}
+
+// TestSummarizeStdin_UTF8 测试截断不拆分多字节字符
+func TestSummarizeStdin_UTF8(t *testing.T) {
+	data := strings.Repeat("中文日志内容，没有任何结构\n", 200)
+	for _, budget := range []int{100, 257, 1000} {
+		s := SummarizeStdin(data, budget)
+		if len(s.Text) > budget {
+			t.Errorf("budget %d: summary is %d bytes", budget, len(s.Text))
+		}
+		if !utf8.ValidString(s.Text) {
+			t.Errorf("budget %d: summary is not valid UTF-8", budget)
+		}
+	}
+}
+
+// TestBuildPrompt_StdinSummary 测试提示词优先使用预先生成的摘要
+func TestBuildPrompt_StdinSummary(t *testing.T) {
+	ctx := &ExecutionContext{Stdin: "raw data", StdinSummary: "summary text"}
+	prompt := BuildPrompt("count lines", ctx)
+	if !strings.Contains(prompt, "summary text") || strings.Contains(prompt, "raw data") {
+		t.Errorf("prompt should use StdinSummary: %q", prompt)
+	}
+
+	// 没有摘要时按默认预算生成
+	ctx = &ExecutionContext{Stdin: "raw data"}
+	if prompt := BuildPrompt("count lines", ctx); !strings.Contains(prompt, "raw data") {
+		t.Errorf("prompt should contain small stdin: %q", prompt)
+	}
+}
@@ -0,0 +1,98 @@
+// Package llm 提供流式响应解析功能
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+const (
+	// sseDataPrefix SSE 数据行前缀
+	sseDataPrefix = "data:"
+
+	// sseDoneMarker OpenAI 流结束标记
+	sseDoneMarker = "[DONE]"
+
+	// maxStreamLineSize 单行流数据的最大长度
+	maxStreamLineSize = 1024 * 1024
+)
+
+// readSSE 解析 Server-Sent Events 流（OpenAI/Anthropic 使用）
+// 每个事件的 data 字段会传给 fn，遇到 [DONE] 时结束
+func readSSE(r io.Reader, fn func(data string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	var data strings.Builder
+	flush := func() error {
+		if data.Len() == 0 {
+			return nil
+		}
+		payload := data.String()
+		data.Reset()
+		return fn(payload)
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 空行表示一个事件结束
+		if line == "" {
+			if err := flush(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 只关心 data 字段，忽略 event/id/注释行
+		if !strings.HasPrefix(line, sseDataPrefix) {
+			continue
+		}
+
+		payload := strings.TrimPrefix(line, sseDataPrefix)
+		payload = strings.TrimPrefix(payload, " ")
+		if payload == sseDoneMarker {
+			return flush()
+		}
+
+		if data.Len() > 0 {
+			data.WriteString("\n")
+		}
+		data.WriteString(payload)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+	}
+
+	// 流结束时可能没有最后的空行
+	return flush()
+}
+
+// readNDJSON 解析以换行分隔的 JSON 流（Ollama 使用）
+// 每个非空行会传给 fn
+func readNDJSON(r io.Reader, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", i18n.T(i18n.ErrReadResponse), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReadSSE 测试 SSE 流解析
+func TestReadSSE(t *testing.T) {
+	input := ": comment\n" +
+		"event: message\n" +
+		"data: first\n\n" +
+		"data: line1\n" +
+		"data: line2\n\n" +
+		"data: [DONE]\n\n" +
+		"data: ignored\n\n"
+
+	var events []string
+	err := readSSE(strings.NewReader(input), func(data string) error {
+		events = append(events, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	want := []string{"first", "line1\nline2"}
+	if len(events) != len(want) {
+		t.Fatalf("期望 %d 个事件, 实际为 %d: %v", len(want), len(events), events)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Errorf("事件 %d = %q, 期望 %q", i, events[i], want[i])
+		}
+	}
+}
+
+// TestReadSSE_NoTrailingBlankLine 测试流末尾没有空行的情况
+func TestReadSSE_NoTrailingBlankLine(t *testing.T) {
+	var events []string
+	err := readSSE(strings.NewReader("data: last"), func(data string) error {
+		events = append(events, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(events) != 1 || events[0] != "last" {
+		t.Errorf("期望事件 [last], 实际为 %v", events)
+	}
+}
+
+// TestReadNDJSON 测试 NDJSON 流解析
+func TestReadNDJSON(t *testing.T) {
+	var lines []string
+	err := readNDJSON(strings.NewReader("{\"a\":1}\n\n  {\"b\":2}  \n"), func(line []byte) error {
+		lines = append(lines, string(line))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if len(lines) != 2 || lines[0] != `{"a":1}` || lines[1] != `{"b":2}` {
+		t.Errorf("解析结果不符合预期: %v", lines)
+	}
+}
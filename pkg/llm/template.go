@@ -0,0 +1,188 @@
+// Package llm 提供了用户自定义提示词模板的功能
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sync"
+	"text/template"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+// PromptData 是提示词模板可以使用的变量
+type PromptData struct {
+	// Input 用户输入的自然语言描述
+	Input string
+
+	// OS 操作系统
+	OS string
+
+	// Shell 类型
+	Shell string
+
+	// WorkDir 当前工作目录
+	WorkDir string
+
+	// Stdin 标准输入数据（未截断，可用 truncate 函数限制长度）
+	Stdin string
+
+	// StdinSummary 标准输入摘要（格式、结构、开头和末尾的样例），已按预算截断
+	StdinSummary string
+
+	// Details 环境信息收集器的输出（可用工具、发行版等）
+	Details string
+
+	// Instructions 项目说明文件（.aicli.md）的内容
+	Instructions string
+
+	// Language 界面语言（zh/en）
+	Language string
+
+	// Default 内置的提示词，模板可以在其基础上追加规则
+	Default string
+}
+
+// PromptTemplates 用户自定义的系统提示词和用户提示词模板
+// 未配置的模板使用内置提示词
+type PromptTemplates struct {
+	system      *template.Template
+	user        *template.Template
+	fingerprint string
+}
+
+var (
+	promptTemplates   *PromptTemplates
+	promptTemplatesMu sync.RWMutex
+)
+
+// promptFuncs 模板中可用的函数
+var promptFuncs = template.FuncMap{
+	// truncate 将字符串截断为最多 n 个字节，不拆分多字节字符
+	"truncate": func(n int, s string) string {
+		if n < 0 || len(s) <= n {
+			return s
+		}
+		return truncateUTF8(s, n) + i18n.T(i18n.LLMTruncated)
+	},
+}
+
+// LoadPromptTemplates 从文件加载提示词模板（text/template 语法）
+// 路径为空表示使用内置提示词；模板在加载时使用示例数据试渲染，以便尽早发现错误
+func LoadPromptTemplates(systemPath, userPath string) (*PromptTemplates, error) {
+	t := &PromptTemplates{}
+	hash := sha256.New()
+
+	var err error
+	if t.system, err = loadPromptTemplate("system", systemPath, hash); err != nil {
+		return nil, err
+	}
+	if t.user, err = loadPromptTemplate("user", userPath, hash); err != nil {
+		return nil, err
+	}
+
+	if t.system == nil && t.user == nil {
+		return nil, nil
+	}
+
+	t.fingerprint = hex.EncodeToString(hash.Sum(nil))
+	return t, nil
+}
+
+// loadPromptTemplate 读取、解析并试渲染一个模板文件
+func loadPromptTemplate(name, path string, hash interface{ Write([]byte) (int, error) }) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadPromptTemplate, path), err)
+	}
+
+	tmpl, err := template.New(name).Funcs(promptFuncs).Option("missingkey=error").Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadPromptTemplate, path), err)
+	}
+
+	sample := PromptData{Input: "list files", OS: "linux", Shell: "bash", WorkDir: "/tmp", Language: "en"}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrLoadPromptTemplate, path), err)
+	}
+
+	hash.Write([]byte(name + "\x00"))
+	hash.Write(data)
+	hash.Write([]byte{0})
+	return tmpl, nil
+}
+
+// SetPromptTemplates 设置全局使用的提示词模板，nil 表示恢复内置提示词
+func SetPromptTemplates(t *PromptTemplates) {
+	promptTemplatesMu.Lock()
+	defer promptTemplatesMu.Unlock()
+	promptTemplates = t
+}
+
+// currentPromptTemplates 返回当前使用的提示词模板
+func currentPromptTemplates() *PromptTemplates {
+	promptTemplatesMu.RLock()
+	defer promptTemplatesMu.RUnlock()
+	return promptTemplates
+}
+
+// promptFingerprint 返回当前模板内容的摘要（用于缓存键），使用内置提示词时为空
+func promptFingerprint() string {
+	if t := currentPromptTemplates(); t != nil {
+		return t.fingerprint
+	}
+	return ""
+}
+
+// renderSystemPrompt 渲染系统提示词，未配置模板或渲染失败时返回内置提示词
+func renderSystemPrompt(input string, ctx *ExecutionContext) string {
+	builtin := builtinSystemPrompt(ctx)
+	if t := currentPromptTemplates(); t != nil && t.system != nil {
+		return renderPrompt(t.system, newPromptData(input, ctx, builtin), builtin)
+	}
+	return builtin
+}
+
+// renderUserPrompt 渲染用户提示词，未配置模板或渲染失败时返回内置提示词
+func renderUserPrompt(input string, ctx *ExecutionContext) string {
+	builtin := builtinUserPrompt(input, ctx)
+	if t := currentPromptTemplates(); t != nil && t.user != nil {
+		return renderPrompt(t.user, newPromptData(input, ctx, builtin), builtin)
+	}
+	return builtin
+}
+
+// renderPrompt 执行模板，失败时返回 fallback
+func renderPrompt(tmpl *template.Template, data PromptData, fallback string) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fallback
+	}
+	return buf.String()
+}
+
+// newPromptData 构建模板变量
+func newPromptData(input string, ctx *ExecutionContext, builtin string) PromptData {
+	data := PromptData{
+		Input:    input,
+		Language: i18n.Lang(),
+		Default:  builtin,
+	}
+	if ctx != nil {
+		data.OS = ctx.OS
+		data.Shell = ctx.Shell
+		data.WorkDir = ctx.WorkDir
+		data.Stdin = ctx.Stdin
+		data.StdinSummary = ctx.StdinSummary
+		data.Details = ctx.Details
+		data.Instructions = ctx.Instructions
+	}
+	return data
+}
@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// writeTemplate 在临时目录中写入模板文件
+func writeTemplate(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("写入模板失败: %v", err)
+	}
+	return path
+}
+
+// TestPromptTemplates 测试自定义模板渲染和变量
+func TestPromptTemplates(t *testing.T) {
+	systemPath := writeTemplate(t, "system.tmpl", "{{.Default}}\n- Prefer ripgrep (rg) over grep.\n- Shell: {{.Shell}}\n")
+	userPath := writeTemplate(t, "user.tmpl", "[{{.Language}}] {{.Input}} on {{.OS}} in {{.WorkDir}}\n{{truncate 3 .Stdin}}")
+
+	templates, err := LoadPromptTemplates(systemPath, userPath)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	SetPromptTemplates(templates)
+	defer SetPromptTemplates(nil)
+
+	ctx := &ExecutionContext{OS: "linux", Shell: "zsh", WorkDir: "/srv", Stdin: "abcdef"}
+	messages := BuildMessages("find TODOs", ctx)
+
+	system := messages[0].Content
+	if !strings.HasPrefix(system, builtinSystemPrompt(ctx)) {
+		t.Errorf("系统提示词应以内置提示词开头, 实际为 %q", system)
+	}
+	if !strings.Contains(system, "Prefer ripgrep") || !strings.Contains(system, "Shell: zsh") {
+		t.Errorf("系统提示词缺少自定义规则: %q", system)
+	}
+
+	user := messages[len(messages)-1].Content
+	if !strings.HasPrefix(user, "[zh] find TODOs on linux in /srv\nabc") {
+		t.Errorf("用户提示词渲染错误: %q", user)
+	}
+	if strings.Contains(user, "def") {
+		t.Errorf("truncate 未截断 stdin: %q", user)
+	}
+}
+
+// TestPromptTemplates_TruncateUTF8 测试 truncate 不拆分中文等多字节字符
+func TestPromptTemplates_TruncateUTF8(t *testing.T) {
+	userPath := writeTemplate(t, "user.tmpl", "{{truncate 4 .Stdin}}")
+	templates, err := LoadPromptTemplates("", userPath)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	SetPromptTemplates(templates)
+	defer SetPromptTemplates(nil)
+
+	// "日志" 每个字 3 个字节，4 字节处位于第二个字中间
+	messages := BuildMessages("统计", &ExecutionContext{Stdin: "日志文件"})
+	user := messages[len(messages)-1].Content
+	if !utf8.ValidString(user) {
+		t.Fatalf("truncate 拆分了多字节字符: %q", user)
+	}
+	if !strings.HasPrefix(user, "日") || strings.HasPrefix(user, "日志") {
+		t.Errorf("truncate 4 应只保留第一个字, 实际为 %q", user)
+	}
+}
+
+// TestPromptTemplates_Fallback 测试未配置模板时使用内置提示词
+func TestPromptTemplates_Fallback(t *testing.T) {
+	templates, err := LoadPromptTemplates("", "")
+	if err != nil || templates != nil {
+		t.Fatalf("空路径应返回 nil, 实际为 %v, %v", templates, err)
+	}
+
+	// 只配置用户提示词时，系统提示词使用内置版本
+	userPath := writeTemplate(t, "user.tmpl", "{{.Input}}")
+	templates, err = LoadPromptTemplates("", userPath)
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	SetPromptTemplates(templates)
+	defer SetPromptTemplates(nil)
+
+	ctx := &ExecutionContext{OS: "linux", Shell: "bash"}
+	if got := GetSystemPrompt(ctx); got != builtinSystemPrompt(ctx) {
+		t.Errorf("期望使用内置系统提示词, 实际为 %q", got)
+	}
+	if got := BuildPrompt("list files", ctx); got != "list files" {
+		t.Errorf("期望用户提示词为 'list files', 实际为 %q", got)
+	}
+}
+
+// TestLoadPromptTemplates_Errors 测试模板文件不存在、语法错误和未知变量
+func TestLoadPromptTemplates_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"不存在", filepath.Join(t.TempDir(), "missing.tmpl")},
+		{"语法错误", writeTemplate(t, "bad.tmpl", "{{.Input")},
+		{"未知变量", writeTemplate(t, "unknown.tmpl", "{{.Unknown}}")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadPromptTemplates(tt.path, ""); err == nil {
+				t.Error("期望返回错误")
+			}
+		})
+	}
+}
+
+// TestCacheKey_PromptTemplates 测试模板内容参与缓存键计算
+func TestCacheKey_PromptTemplates(t *testing.T) {
+	ctx := &ExecutionContext{OS: "linux", Shell: "bash"}
+	builtin := CacheKey("list files", ctx, "gpt-4", false)
+
+	templates, err := LoadPromptTemplates(writeTemplate(t, "system.tmpl", "{{.Default}}\n- never use sudo"), "")
+	if err != nil {
+		t.Fatalf("加载模板失败: %v", err)
+	}
+	SetPromptTemplates(templates)
+	defer SetPromptTemplates(nil)
+
+	if CacheKey("list files", ctx, "gpt-4", false) == builtin {
+		t.Error("修改提示词模板后缓存键应该变化")
+	}
+}
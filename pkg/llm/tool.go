@@ -0,0 +1,72 @@
+// Package llm 提供通过工具调用（function calling）返回命令的功能
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/studyzy/aicli/pkg/i18n"
+)
+
+const (
+	// emitCommandToolName 返回命令的工具名称
+	emitCommandToolName = "emit_command"
+
+	// emitCommandToolDescription 工具说明（发送给模型，使用英文）
+	emitCommandToolDescription = "Return the single shell command that fulfils the user's request, together with a short explanation."
+)
+
+// emitCommandSchema 返回 emit_command 工具参数的 JSON Schema
+func emitCommandSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"command": map[string]interface{}{
+				"type":        "string",
+				"description": "The exact command to execute, without markdown or comments.",
+			},
+			"explanation": map[string]interface{}{
+				"type":        "string",
+				"description": "One-line explanation of what the command does.",
+			},
+			"needs_sudo": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether the command requires administrator privileges.",
+			},
+			"interactive": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Whether the command waits for user input or opens an interactive program.",
+			},
+			"risk": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"low", "medium", "high"},
+				"description": "Risk level of running the command.",
+			},
+			"tools": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "External programs the command depends on.",
+			},
+		},
+		"required": []string{"command", "explanation", "needs_sudo", "interactive"},
+	}
+}
+
+// parseToolArguments 解析 emit_command 工具调用的参数
+// 工具参数已经是结构化数据，命令只去除首尾空白，不再做 markdown 清理
+func parseToolArguments(args string) (*TranslationResult, error) {
+	var result TranslationResult
+	if err := json.Unmarshal([]byte(args), &result); err != nil {
+		return nil, fmt.Errorf("%s: %w", i18n.T(i18n.ErrParseResponse), err)
+	}
+
+	result.Command = strings.TrimSpace(result.Command)
+	if result.Command == "" {
+		return nil, newEmptyResponseError(i18n.ErrEmptyCommandResp)
+	}
+	result.Explanation = strings.TrimSpace(result.Explanation)
+	result.Risk = strings.ToLower(strings.TrimSpace(result.Risk))
+
+	return &result, nil
+}
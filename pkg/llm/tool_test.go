@@ -0,0 +1,218 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const toolArgsFindLogs = `{"command":"find . -name '*.log'","explanation":"查找日志文件","needs_sudo":false,"interactive":false,"risk":"low"}`
+
+// TestParseToolArguments 测试解析 emit_command 工具参数
+func TestParseToolArguments(t *testing.T) {
+	result, err := parseToolArguments(`{"command":"  sudo apt update ","explanation":"更新软件源","needs_sudo":true,"interactive":false,"risk":"Medium"}`)
+	if err != nil {
+		t.Fatalf("解析工具参数失败: %v", err)
+	}
+	if result.Command != "sudo apt update" {
+		t.Errorf("期望命令为 'sudo apt update', 实际为 '%s'", result.Command)
+	}
+	if !result.NeedsSudo || result.Interactive {
+		t.Errorf("needs_sudo/interactive 解析错误: %+v", result)
+	}
+	if result.Risk != "medium" {
+		t.Errorf("期望风险为 medium, 实际为 %s", result.Risk)
+	}
+
+	if _, err := parseToolArguments(`{"command":""}`); err == nil {
+		t.Error("期望空命令返回错误")
+	}
+	if _, err := parseToolArguments(`{"command":`); err == nil {
+		t.Error("期望无效 JSON 返回错误")
+	}
+}
+
+// TestOpenAIProvider_Translate_ToolCall 测试 OpenAI 通过工具调用返回命令
+func TestOpenAIProvider_Translate_ToolCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody openAIRequest
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if len(reqBody.Tools) != 1 || reqBody.Tools[0].Function.Name != emitCommandToolName {
+			t.Errorf("期望请求声明 emit_command 工具, 实际为 %+v", reqBody.Tools)
+		}
+		if reqBody.ToolChoice == nil || reqBody.ToolChoice.Function.Name != emitCommandToolName {
+			t.Errorf("期望 tool_choice 指定 emit_command, 实际为 %+v", reqBody.ToolChoice)
+		}
+
+		resp := map[string]interface{}{
+			"choices": []map[string]interface{}{{
+				"message": map[string]interface{}{
+					"content": nil,
+					"tool_calls": []map[string]interface{}{{
+						"id":       "call_1",
+						"type":     "function",
+						"function": map[string]interface{}{"name": emitCommandToolName, "arguments": toolArgsFindLogs},
+					}},
+				},
+			}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+	result, err := provider.Translate(context.Background(), "查找日志文件", &ExecutionContext{OS: "linux"})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != "find . -name '*.log'" {
+		t.Errorf("期望命令为 find . -name '*.log', 实际为 '%s'", result.Command)
+	}
+	if result.Explanation != "查找日志文件" || result.Risk != "low" {
+		t.Errorf("工具参数解析错误: %+v", result)
+	}
+}
+
+// TestOpenAIProvider_ToolCallingDisabled 测试关闭工具调用后不发送 tools 参数
+func TestOpenAIProvider_ToolCallingDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if _, ok := reqBody["tools"]; ok {
+			t.Error("关闭工具调用后不应发送 tools 参数")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ls -la"}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+	provider.SetToolCalling(false)
+	result, err := provider.Translate(context.Background(), "列出文件", &ExecutionContext{OS: "linux"})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != commandLsla {
+		t.Errorf("期望命令为 'ls -la', 实际为 '%s'", result.Command)
+	}
+
+	// Complete 用于通用对话，始终不声明工具
+	provider.SetToolCalling(true)
+	if _, err := provider.Complete(context.Background(), []Message{{Role: RoleUser, Content: "hi"}}); err != nil {
+		t.Fatalf("Complete 失败: %v", err)
+	}
+}
+
+// TestOpenAIProvider_TranslateStream_ToolCall 测试流式工具调用参数的拼接
+func TestOpenAIProvider_TranslateStream_ToolCall(t *testing.T) {
+	args := `{"command":"ls -la","explanation":"列出文件","needs_sudo":false,"interactive":false}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, part := range []string{args[:14], args[14:20], args[20:]} {
+			chunk := map[string]interface{}{
+				"choices": []map[string]interface{}{{
+					"delta": map[string]interface{}{
+						"tool_calls": []map[string]interface{}{{
+							"index":    0,
+							"function": map[string]interface{}{"arguments": part},
+						}},
+					},
+				}},
+			}
+			data, _ := json.Marshal(chunk)
+			w.Write([]byte("data: " + string(data) + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAIProvider("test-api-key", "gpt-4", server.URL)
+
+	var streamed strings.Builder
+	result, err := provider.TranslateStream(context.Background(), "列出文件", &ExecutionContext{OS: "linux"}, func(chunk string) {
+		streamed.WriteString(chunk)
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != commandLsla || result.Explanation != "列出文件" {
+		t.Errorf("工具参数解析错误: %+v", result)
+	}
+	if streamed.String() != commandLsla {
+		t.Errorf("期望流式输出命令 'ls -la', 实际为 '%s'", streamed.String())
+	}
+}
+
+// TestAnthropicProvider_Translate_ToolUse 测试 Anthropic 通过 tool_use 返回命令
+func TestAnthropicProvider_Translate_ToolUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqBody anthropicRequest
+		json.NewDecoder(r.Body).Decode(&reqBody)
+		if len(reqBody.Tools) != 1 || reqBody.Tools[0].Name != emitCommandToolName {
+			t.Errorf("期望请求声明 emit_command 工具, 实际为 %+v", reqBody.Tools)
+		}
+		if reqBody.ToolChoice == nil || reqBody.ToolChoice.Type != "tool" {
+			t.Errorf("期望 tool_choice 类型为 tool, 实际为 %+v", reqBody.ToolChoice)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"tool_use","id":"toolu_1","name":"emit_command","input":` + toolArgsFindLogs + `}],
+			"usage":{"input_tokens":30,"output_tokens":12}}`))
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-api-key", "claude-3-sonnet-20240229", server.URL)
+	result, err := provider.Translate(context.Background(), "查找日志文件", &ExecutionContext{OS: "linux"})
+	if err != nil {
+		t.Fatalf("转换失败: %v", err)
+	}
+	if result.Command != "find . -name '*.log'" {
+		t.Errorf("期望命令为 find . -name '*.log', 实际为 '%s'", result.Command)
+	}
+	if result.Usage.TotalTokens != 42 {
+		t.Errorf("期望 token 总数为 42, 实际为 %d", result.Usage.TotalTokens)
+	}
+}
+
+// TestAnthropicProvider_TranslateStream_ToolUse 测试 Anthropic 流式 input_json_delta 的拼接
+func TestAnthropicProvider_TranslateStream_ToolUse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"usage":{"input_tokens":30}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","name":"emit_command","input":{}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"command\":\"ls"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":" -la\",\"explanation\":\"列出文件\",\"needs_sudo\":false,\"interactive\":false}"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","usage":{"output_tokens":12}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			w.Write([]byte("data: " + e + "\n\n"))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test-api-key", "claude-3-sonnet-20240229", server.URL)
+
+	var streamed strings.Builder
+	result, err := provider.TranslateStream(context.Background(), "列出文件", &ExecutionContext{OS: "linux"}, func(chunk string) {
+		streamed.WriteString(chunk)
+	})
+	if err != nil {
+		t.Fatalf("流式转换失败: %v", err)
+	}
+	if result.Command != commandLsla || result.Explanation != "列出文件" {
+		t.Errorf("工具参数解析错误: %+v", result)
+	}
+	if streamed.String() != commandLsla {
+		t.Errorf("期望流式输出命令 'ls -la', 实际为 '%s'", streamed.String())
+	}
+	if result.Usage.TotalTokens != 42 {
+		t.Errorf("期望 token 总数为 42, 实际为 %d", result.Usage.TotalTokens)
+	}
+}